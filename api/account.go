@@ -2,18 +2,17 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/suryansh74/simplebank/db"
 	"github.com/suryansh74/simplebank/db/sqlc"
 	"github.com/suryansh74/simplebank/token"
 )
 
 type createAccountRequest struct {
-	Currency sqlc.Currency `json:"currency" binding:"required,oneof=USD EUR"`
+	Currency string `json:"currency" binding:"required,currency"`
 }
 
 func (server *Server) createAccount(context *gin.Context) {
@@ -21,7 +20,7 @@ func (server *Server) createAccount(context *gin.Context) {
 	var req createAccountRequest
 	err := context.ShouldBindJSON(&req)
 	if err != nil {
-		context.JSON(http.StatusBadRequest, errorResponse(err))
+		context.Error(statusError(http.StatusBadRequest, err))
 		return
 	}
 
@@ -32,19 +31,9 @@ func (server *Server) createAccount(context *gin.Context) {
 		Balance:  0,
 	}
 
-	account, err := server.store.CreateAccount(context, args)
+	account, err := server.store.CreateAccountTx(context, args)
 	if err != nil {
-		if pqErr, ok := err.(*pgconn.PgError); ok {
-			switch pqErr.Code {
-			case "23503": // foreign_key_violation
-				context.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			case "23505": // unique_violation
-				context.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		context.Error(err)
 		return
 	}
 
@@ -59,24 +48,20 @@ func (server *Server) getAccount(context *gin.Context) {
 	var req getAccountRequest
 	err := context.ShouldBindUri(&req)
 	if err != nil {
-		context.JSON(http.StatusBadRequest, errorResponse(err))
+		context.Error(statusError(http.StatusBadRequest, err))
 		return
 	}
 
 	account, err := server.store.GetAccount(context, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			context.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		context.Error(err)
 		return
 	}
 
 	authPayload := context.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
+	if !canViewAccount(authPayload, account) {
 		err := errors.New("account doesn't belong to authenticated user")
-		context.JSON(http.StatusUnauthorized, errorResponse(err))
+		context.Error(statusError(http.StatusUnauthorized, err))
 		return
 	}
 
@@ -92,7 +77,7 @@ func (server *Server) listAccount(context *gin.Context) {
 	var req listAccountRequest
 	err := context.ShouldBindQuery(&req)
 	if err != nil {
-		context.JSON(http.StatusBadRequest, errorResponse(err))
+		context.Error(statusError(http.StatusBadRequest, err))
 		return
 	}
 
@@ -105,9 +90,94 @@ func (server *Server) listAccount(context *gin.Context) {
 
 	accounts, err := server.store.ListAccounts(context, args)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		context.Error(err)
 		return
 	}
 
 	context.JSON(http.StatusOK, accounts)
 }
+
+type changeAccountStatusRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+func (server *Server) freezeAccount(context *gin.Context) {
+	server.setAccountStatus(context, sqlc.AccountStatusFrozen)
+}
+
+func (server *Server) unfreezeAccount(context *gin.Context) {
+	server.setAccountStatus(context, sqlc.AccountStatusActive)
+}
+
+func (server *Server) closeAccount(context *gin.Context) {
+	server.setAccountStatus(context, sqlc.AccountStatusClosed)
+}
+
+// setAccountStatus backs the freeze, unfreeze and close routes. Freezing is admin only,
+// which the router enforces, while an account can only be closed by its owner.
+func (server *Server) setAccountStatus(context *gin.Context, status sqlc.AccountStatus) {
+	var uri getAccountRequest
+	err := context.ShouldBindUri(&uri)
+	if err != nil {
+		context.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req changeAccountStatusRequest
+	err = context.ShouldBindJSON(&req)
+	if err != nil {
+		context.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	authPayload := context.MustGet(authorizationPayloadKey).(*token.Payload)
+	if status == sqlc.AccountStatusClosed {
+		account, err := server.store.GetAccount(context, uri.ID)
+		if err != nil {
+			context.Error(err)
+			return
+		}
+
+		if account.Owner != authPayload.Username {
+			err := errors.New("account doesn't belong to the authenticated user")
+			context.Error(statusError(http.StatusUnauthorized, err))
+			return
+		}
+	}
+
+	result, err := server.store.ChangeAccountStatusTx(context, db.ChangeAccountStatusTxParams{
+		AccountID: uri.ID,
+		Status:    status,
+		Reason:    req.Reason,
+		ChangedBy: authPayload.Username,
+	})
+	if err != nil {
+		context.Error(err)
+		return
+	}
+
+	context.JSON(http.StatusOK, result)
+}
+
+// listAccountStatusChanges shows who froze, unfroze or closed an account and why
+func (server *Server) listAccountStatusChanges(context *gin.Context) {
+	var req getAccountRequest
+	err := context.ShouldBindUri(&req)
+	if err != nil {
+		context.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	account, valid := server.viewableAccount(context, req.ID)
+	if !valid {
+		return
+	}
+
+	changes, err := server.store.ListAccountStatusChanges(context, account.ID)
+	if err != nil {
+		context.Error(err)
+		return
+	}
+
+	context.JSON(http.StatusOK, changes)
+}
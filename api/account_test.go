@@ -13,8 +13,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
 	"github.com/suryansh74/simplebank/db/mock"
 	"github.com/suryansh74/simplebank/db/sqlc"
 	"github.com/suryansh74/simplebank/token"
@@ -37,7 +37,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "OK",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).
@@ -67,7 +67,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "UnauthorizedUser",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).
@@ -78,16 +78,32 @@ func TestGetAccountAPI(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name:      "BankerViewsAnyAccount",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker_user", utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
 		{
 			name:      "NotFound",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 					Times(1).
-					Return(sqlc.Account{}, sql.ErrNoRows)
+					Return(sqlc.Account{}, db.ErrNotFound)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -97,7 +113,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "InternalError",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).
@@ -112,7 +128,7 @@ func TestGetAccountAPI(t *testing.T) {
 			name:      "BadRequest",
 			accountID: 0,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).
@@ -172,7 +188,7 @@ func TestListAccountAPI(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				arg := sqlc.ListAccountsParams{
@@ -216,7 +232,7 @@ func TestListAccountAPI(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -235,7 +251,7 @@ func TestListAccountAPI(t *testing.T) {
 				pageSize: n,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -253,7 +269,7 @@ func TestListAccountAPI(t *testing.T) {
 				pageSize: 10000,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -307,7 +323,7 @@ func TestCreateAccountAPI(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				arg := sqlc.CreateAccountParams{
@@ -317,7 +333,7 @@ func TestCreateAccountAPI(t *testing.T) {
 				}
 
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					CreateAccountTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
 					Return(account, nil)
 			},
@@ -336,7 +352,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -349,11 +365,11 @@ func TestCreateAccountAPI(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(sqlc.Account{}, sql.ErrConnDone)
 			},
@@ -361,17 +377,58 @@ func TestCreateAccountAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "CurrencyFromRegistry",
+			body: gin.H{
+				"currency": "JPY",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				arg := sqlc.CreateAccountParams{
+					Owner:    user.Username,
+					Currency: "JPY",
+					Balance:  0,
+				}
+
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(sqlc.Account{Owner: user.Username, Currency: "JPY"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "DisabledCurrency",
+			body: gin.H{
+				"currency": "VEF",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidCurrency",
 			body: gin.H{
 				"currency": "INVALID",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -382,11 +439,11 @@ func TestCreateAccountAPI(t *testing.T) {
 			name: "MissingFields",
 			body: gin.H{},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -399,19 +456,19 @@ func TestCreateAccountAPI(t *testing.T) {
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateAccount(gomock.Any(), gomock.Any()).
+					CreateAccountTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(sqlc.Account{}, &pgconn.PgError{
-						Code:    "23505",
-						Message: "duplicate key value violates unique constraint",
+					Return(sqlc.Account{}, &db.Error{
+						Kind:       db.ErrConflict,
+						Constraint: "owner_currency_key",
 					})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Equal(t, http.StatusConflict, recorder.Code)
 			},
 		},
 	}
@@ -443,13 +500,400 @@ func TestCreateAccountAPI(t *testing.T) {
 	}
 }
 
+func TestAccountStatusAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	reason := "suspicious activity"
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		action        string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "Freeze",
+			accountID: account.ID,
+			action:    "freeze",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				frozen := account
+				frozen.Status = sqlc.AccountStatusFrozen
+
+				arg := db.ChangeAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    sqlc.AccountStatusFrozen,
+					Reason:    reason,
+					ChangedBy: "admin_user",
+				}
+
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{Account: frozen}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var result db.ChangeAccountStatusTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &result)
+				require.NoError(t, err)
+				require.Equal(t, sqlc.AccountStatusFrozen, result.Account.Status)
+			},
+		},
+		{
+			name:      "Unfreeze",
+			accountID: account.ID,
+			action:    "unfreeze",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.ChangeAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    sqlc.AccountStatusActive,
+					Reason:    reason,
+					ChangedBy: "admin_user",
+				}
+
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Close",
+			accountID: account.ID,
+			action:    "close",
+			body:      gin.H{"reason": "moving abroad"},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				arg := db.ChangeAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    sqlc.AccountStatusClosed,
+					Reason:    "moving abroad",
+					ChangedBy: user.Username,
+				}
+
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "CloseNotOwner",
+			accountID: account.ID,
+			action:    "close",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "CloseNotEmpty",
+			accountID: account.ID,
+			action:    "close",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, fmt.Errorf("%w: balance", db.ErrAccountNotEmpty))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidTransition",
+			accountID: account.ID,
+			action:    "freeze",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, fmt.Errorf("%w: closed", db.ErrInvalidStatusTransition))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "Banker",
+			accountID: account.ID,
+			action:    "freeze",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker_user", utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "Owner",
+			accountID: account.ID,
+			action:    "unfreeze",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			action:    "freeze",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, db.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountID: account.ID,
+			action:    "freeze",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingReason",
+			accountID: account.ID,
+			action:    "freeze",
+			body:      gin.H{},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "BadRequest",
+			accountID: 0,
+			action:    "freeze",
+			body:      gin.H{"reason": reason},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/%s", tc.accountID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAccountStatusChangesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	changes := []sqlc.AccountStatusChange{
+		{
+			ID:         1,
+			AccountID:  account.ID,
+			FromStatus: sqlc.AccountStatusActive,
+			ToStatus:   sqlc.AccountStatusFrozen,
+			Reason:     "court order",
+			ChangedBy:  "admin_user",
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					ListAccountStatusChanges(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(changes, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotChanges []sqlc.AccountStatusChange
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotChanges)
+				require.NoError(t, err)
+				require.Equal(t, changes, gotChanges)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					ListAccountStatusChanges(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/status_changes", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 // Helper functions
 func randomAccount(owner string) sqlc.Account {
+	balance := utils.RandomMoney()
 	return sqlc.Account{
-		ID:       utils.RandomInt(1, 1000),
-		Owner:    owner,
-		Balance:  utils.RandomMoney(),
-		Currency: utils.RandomCurrency(),
+		ID:               utils.RandomInt(1, 1000),
+		Owner:            owner,
+		Balance:          balance,
+		Currency:         utils.RandomCurrency(),
+		Status:           sqlc.AccountStatusActive,
+		AvailableBalance: balance,
 	}
 }
 
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+// createAPIKeyRequest must name at least one scope, an API key never has everything the role allows
+type createAPIKeyRequest struct {
+	Name      string    `json:"name" binding:"required,max=100"`
+	Scopes    []string  `json:"scopes" binding:"required,min=1,dive,scope"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// apiKeyResponse leaves out the hash, the key itself is only in createAPIKeyResponse
+type apiKeyResponse struct {
+	ID         int64              `json:"id"`
+	Name       string             `json:"name"`
+	Prefix     string             `json:"prefix"`
+	Scopes     []string           `json:"scopes"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type createAPIKeyResponse struct {
+	// Key cannot be shown again, only its hash is stored
+	Key string `json:"key"`
+	apiKeyResponse
+}
+
+func newAPIKeyResponse(apiKey sqlc.ApiKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		RevokedAt:  apiKey.RevokedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
+
+func (server *Server) createAPIKey(ctx *gin.Context) {
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	if !req.ExpiresAt.IsZero() && !req.ExpiresAt.After(time.Now()) {
+		err := errors.New("expires_at must be in the future")
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	key, prefix, hashedKey, err := utils.NewAPIKey()
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	apiKey, err := server.store.CreateAPIKeyTx(ctx, sqlc.CreateAPIKeyParams{
+		Username:  authPayload.Username,
+		Name:      req.Name,
+		Prefix:    prefix,
+		HashedKey: hashedKey,
+		Scopes:    req.Scopes,
+		ExpiresAt: optionalTimestamptz(req.ExpiresAt),
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createAPIKeyResponse{
+		Key:            key,
+		apiKeyResponse: newAPIKeyResponse(apiKey),
+	})
+}
+
+func (server *Server) listAPIKeys(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	apiKeys, err := server.store.ListAPIKeys(ctx, authPayload.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := make([]apiKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		rsp[i] = newAPIKeyResponse(apiKey)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokeAPIKeyRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// revokeAPIKey answers with the key as it is when it was already revoked
+func (server *Server) revokeAPIKey(ctx *gin.Context) {
+	var req revokeAPIKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	apiKey, err := server.store.GetAPIKey(ctx, req.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if apiKey.Username != authPayload.Username {
+		err := errors.New("api key doesn't belong to authenticated user")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	if !apiKey.RevokedAt.Valid {
+		apiKey, err = server.store.RevokeAPIKeyTx(ctx, apiKey.ID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, newAPIKeyResponse(apiKey))
+}
@@ -0,0 +1,465 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+// randomAPIKey returns a stored key of user along with the key itself
+func randomAPIKey(t *testing.T, username string, scopes ...string) (sqlc.ApiKey, string) {
+	key, prefix, hashedKey, err := utils.NewAPIKey()
+	require.NoError(t, err)
+
+	apiKey := sqlc.ApiKey{
+		ID:        utils.RandomInt(1, 1000),
+		Username:  username,
+		Name:      "reporting",
+		Prefix:    prefix,
+		HashedKey: hashedKey,
+		Scopes:    scopes,
+		CreatedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	return apiKey, key
+}
+
+func addAPIKeyAuthorization(request *http.Request, key string) {
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("ApiKey %s", key))
+}
+
+func TestCreateAPIKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	expiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"name": "reporting", "scopes": []string{utils.AccountsReadScope}, "expires_at": expiresAt},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateAPIKeyTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, "reporting", arg.Name)
+						require.Equal(t, []string{utils.AccountsReadScope}, arg.Scopes)
+						require.True(t, arg.ExpiresAt.Time.Equal(expiresAt))
+						return sqlc.ApiKey{
+							ID:        1,
+							Username:  arg.Username,
+							Name:      arg.Name,
+							Prefix:    arg.Prefix,
+							HashedKey: arg.HashedKey,
+							Scopes:    arg.Scopes,
+							ExpiresAt: arg.ExpiresAt,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "hashed_key")
+
+				var rsp createAPIKeyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				prefix, err := utils.APIKeyPrefix(rsp.Key)
+				require.NoError(t, err)
+				require.Equal(t, prefix, rsp.Prefix)
+				require.Equal(t, []string{utils.AccountsReadScope}, rsp.Scopes)
+			},
+		},
+		{
+			name: "NoScopes",
+			body: gin.H{"name": "reporting", "scopes": []string{}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnsupportedScope",
+			body: gin.H{"name": "reporting", "scopes": []string{"everything"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiresInPast",
+			body: gin.H{"name": "reporting", "scopes": []string{utils.AccountsReadScope}, "expires_at": time.Now().Add(-time.Hour)},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ScopedToken",
+			body: gin.H{"name": "reporting", "scopes": []string{utils.AccountsReadScope}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				accessToken, _, err := tokenMaker.CreateToken(user.Username, utils.DepositorRole, token.AccessToken, uuid.Nil, time.Minute, utils.AccountsReadScope)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			body:      gin.H{"name": "reporting", "scopes": []string{utils.AccountsReadScope}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAPIKeysAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	apiKey1, _ := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+	apiKey2, _ := randomAPIKey(t, user.Username, utils.TransfersWriteScope)
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().ListAPIKeys(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]sqlc.ApiKey{apiKey1, apiKey2}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api-keys", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), apiKey1.HashedKey)
+
+	var rsp []apiKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp, 2)
+	require.Equal(t, apiKey1.Prefix, rsp[0].Prefix)
+	require.Equal(t, apiKey2.Scopes, rsp[1].Scopes)
+}
+
+func TestRevokeAPIKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	apiKey, _ := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+
+	revoked := apiKey
+	revoked.RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(apiKey, nil)
+				store.EXPECT().RevokeAPIKeyTx(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(revoked, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp apiKeyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, rsp.RevokedAt.Valid)
+			},
+		},
+		{
+			name:     "AlreadyRevoked",
+			username: user.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(revoked, nil)
+				store.EXPECT().RevokeAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: "someone_else",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(apiKey, nil)
+				store.EXPECT().RevokeAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(sqlc.ApiKey{}, db.ErrNotFound)
+				store.EXPECT().RevokeAPIKeyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api-keys/%d", apiKey.ID), nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, utils.DepositorRole, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAPIKeyAuthorization(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		setupKey      func(t *testing.T, store *mock.MockStore) string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupKey: func(t *testing.T, store *mock.MockStore) string {
+				apiKey, key := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				return key
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RecentlyUsed",
+			setupKey: func(t *testing.T, store *mock.MockStore) string {
+				apiKey, key := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+				apiKey.LastUsedAt = pgtype.Timestamptz{Time: time.Now().Add(-apiKeyTouchInterval / 2), Valid: true}
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				return key
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MissingScope",
+			setupKey: func(t *testing.T, store *mock.MockStore) string {
+				apiKey, key := randomAPIKey(t, user.Username, utils.TransfersWriteScope)
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1).Return(nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				return key
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Malformed",
+			setupKey: func(t *testing.T, store *mock.MockStore) string {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(0)
+				return "not-a-key"
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownPrefix",
+			setupKey: func(t *testing.T, store *mock.MockStore) string {
+				_, key := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.ApiKey{}, db.ErrNotFound)
+				return key
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "WrongSecret",
+			setupKey: func(t *testing.T, store *mock.MockStore) string {
+				apiKey, _ := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+				_, otherKey := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+				return apiKey.Prefix + otherKey[len(apiKey.Prefix):]
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Revoked",
+			setupKey: func(t *testing.T, store *mock.MockStore) string {
+				apiKey, key := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+				apiKey.RevokedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+				return key
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Expired",
+			setupKey: func(t *testing.T, store *mock.MockStore) string {
+				apiKey, key := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+				apiKey.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+				return key
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			key := tc.setupKey(t, store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+			require.NoError(t, err)
+			addAPIKeyAuthorization(request, key)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// logging out with an API key would revoke nothing, the key has to be revoked instead
+func TestLogoutWithAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	apiKey, key := randomAPIKey(t, user.Username, utils.AccountsReadScope)
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1)
+	store.EXPECT().RevokeTokenTx(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().BlockSessionTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodPost, "/users/logout", http.NoBody)
+	require.NoError(t, err)
+	addAPIKeyAuthorization(request, key)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	rsp := requireErrorResponse(t, recorder, "bad_request")
+	require.Equal(t, errLogoutWithAPIKey.Error(), rsp.Message)
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestListAuditEventsAPI(t *testing.T) {
+	n := 5
+	events := make([]sqlc.AuditEvent, n)
+	for i := range events {
+		events[i] = sqlc.AuditEvent{
+			ID:         int64(n - i),
+			Actor:      utils.RandomOwner(),
+			Action:     db.AuditActionCreateAccount,
+			TargetType: "account",
+			TargetID:   "1",
+			After:      []byte(`{"id":1}`),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "page_size=5&action=account.create&actor=someone",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				arg := sqlc.ListAuditEventsParams{
+					Actor:    optionalText("someone"),
+					Action:   optionalText(db.AuditActionCreateAccount),
+					PageSize: int32(n),
+				}
+
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Events []struct {
+						ID     int64           `json:"id"`
+						Before json.RawMessage `json:"before"`
+						After  json.RawMessage `json:"after"`
+					} `json:"events"`
+					NextCursor int64 `json:"next_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Events, n)
+				require.Equal(t, "null", string(rsp.Events[0].Before))
+				require.JSONEq(t, `{"id":1}`, string(rsp.Events[0].After))
+				require.Equal(t, events[n-1].ID, rsp.NextCursor)
+			},
+		},
+		{
+			name:  "Banker",
+			query: "page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker_user", utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: "page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			query: "page_size=1000",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "page_size=5",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]sqlc.AuditEvent{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/audit?"+tc.query, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
+	"github.com/suryansh74/simplebank/token"
+)
+
+// batchTransferRequest makes all of its transfers or none of them,
+// each one is checked like a single POST /transfers
+type batchTransferRequest struct {
+	Transfers []transferRequest `json:"transfers" binding:"required,min=1,max=500,dive"`
+}
+
+// createBatchTransfer checks every transfer before making any. When one is rejected,
+// here or by the store, the response names it with its position in transfer_index.
+func (server *Server) createBatchTransfer(ctx *gin.Context) {
+	var req batchTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	accounts := make(map[int64]sqlc.Account)
+	rates := make(map[string]exchange.Rate)
+	arg := db.BatchTransferTxParams{Transfers: make([]db.TransferTxParams, len(req.Transfers))}
+
+	for i, transfer := range req.Transfers {
+		fromAccount, err := server.batchAccount(ctx, accounts, transfer.FromAccountID)
+		if err != nil {
+			ctx.Error(&db.BatchTransferError{Index: i, Err: err})
+			return
+		}
+
+		if fromAccount.Currency != transfer.Currency {
+			err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", fromAccount.ID, fromAccount.Currency, transfer.Currency)
+			ctx.Error(&db.BatchTransferError{Index: i, Err: statusError(http.StatusBadRequest, err)})
+			return
+		}
+
+		if fromAccount.Owner != authPayload.Username {
+			err := errors.New("from account doesn't belong to the authenticated user")
+			ctx.Error(&db.BatchTransferError{Index: i, Err: statusError(http.StatusUnauthorized, err)})
+			return
+		}
+
+		toAccount, err := server.batchAccount(ctx, accounts, transfer.ToAccountID)
+		if err != nil {
+			ctx.Error(&db.BatchTransferError{Index: i, Err: err})
+			return
+		}
+
+		arg.Transfers[i] = db.TransferTxParams{
+			FromAccountID: transfer.FromAccountID,
+			ToAccountID:   transfer.ToAccountID,
+			Amount:        transfer.Amount,
+		}
+
+		if toAccount.Currency != fromAccount.Currency {
+			pair := fromAccount.Currency + "/" + toAccount.Currency
+			rate, found := rates[pair]
+			if !found {
+				rate, err = server.lookupRate(ctx, fromAccount.Currency, toAccount.Currency)
+				if err != nil {
+					ctx.Error(&db.BatchTransferError{Index: i, Err: err})
+					return
+				}
+				rates[pair] = rate
+			}
+			arg.Transfers[i].Rate = &rate
+		}
+	}
+
+	result, err := server.store.BatchTransferTx(db.WithTxOptions(ctx, transferTxOptions), arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// batchAccount loads an account once per batch and makes sure it can send or receive
+func (server *Server) batchAccount(ctx *gin.Context, accounts map[int64]sqlc.Account, accountID int64) (sqlc.Account, error) {
+	if account, found := accounts[accountID]; found {
+		return account, nil
+	}
+
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Status != sqlc.AccountStatusActive {
+		return account, &db.AccountNotActiveError{AccountID: accountID, Status: account.Status}
+	}
+
+	accounts[accountID] = account
+	return account, nil
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestCreateBatchTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account3 := randomAccount(user2.Username)
+	account1.ID, account2.ID, account3.ID = 1, 2, 3
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+	account3.Currency = utils.EUR
+
+	leg := func(toAccountID int64, amount int64) gin.H {
+		return gin.H{
+			"from_account_id": account1.ID,
+			"to_account_id":   toAccountID,
+			"amount":          amount,
+			"currency":        utils.USD,
+		}
+	}
+
+	asUser1 := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			body:      gin.H{"transfers": []gin.H{leg(account2.ID, 10), leg(account3.ID, 20), leg(account2.ID, 30)}},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				// every account is loaded once however many transfers it is in
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+
+				rate, err := newTestRates(t).Rate(context.Background(), utils.USD, utils.EUR)
+				require.NoError(t, err)
+
+				arg := db.BatchTransferTxParams{Transfers: []db.TransferTxParams{
+					{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+					{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 20, Rate: &rate},
+					{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 30},
+				}}
+				result := db.BatchTransferTxResult{Transfers: []db.TransferTxResult{
+					{Transfer: sqlc.Transfer{ID: 1}},
+					{Transfer: sqlc.Transfer{ID: 2}},
+					{Transfer: sqlc.Transfer{ID: 3}},
+				}}
+				store.EXPECT().BatchTransferTx(EqTxOptions(transferTxOptions), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var result db.BatchTransferTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+				require.Len(t, result.Transfers, 3)
+			},
+		},
+		{
+			name:      "Empty",
+			body:      gin.H{"transfers": []gin.H{}},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidTransfer",
+			body:      gin.H{"transfers": []gin.H{leg(account2.ID, 10), leg(account2.ID, -1)}},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{"transfers": []gin.H{leg(account2.ID, 10), {
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        utils.EUR,
+			}}},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireTransferIndex(t, recorder, 1)
+			},
+		},
+		{
+			name: "NotOwner",
+			body: gin.H{"transfers": []gin.H{leg(account2.ID, 10)}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireTransferIndex(t, recorder, 0)
+			},
+		},
+		{
+			name:      "ToAccountFrozen",
+			body:      gin.H{"transfers": []gin.H{leg(account2.ID, 10), leg(account3.ID, 10)}},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				frozen := account3
+				frozen.Status = sqlc.AccountStatusFrozen
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(frozen, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireTransferIndex(t, recorder, 1)
+			},
+		},
+		{
+			name:      "InsufficientFunds",
+			body:      gin.H{"transfers": []gin.H{leg(account2.ID, 10), leg(account2.ID, 20)}},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				err := &db.BatchTransferError{
+					Index: 1,
+					Err:   &db.InsufficientFundsError{AccountID: account1.ID, Amount: 20, Available: 5},
+				}
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, err)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				rsp := requireErrorResponse(t, recorder, "insufficient_funds")
+				require.Equal(t, float64(1), rsp.Details["transfer_index"])
+				require.Equal(t, float64(5), rsp.Details["available_balance"])
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			body:      gin.H{"transfers": []gin.H{leg(account2.ID, 10)}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireTransferIndex(t *testing.T, recorder *httptest.ResponseRecorder, index int) {
+	var rsp ErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, float64(index), rsp.Details["transfer_index"])
+}
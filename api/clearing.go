@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+// clearingRequest is the body of both deposits and withdrawals, amount is in minor units of the account currency
+type clearingRequest struct {
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	ExternalRef string `json:"external_ref" binding:"required,max=255"`
+}
+
+// createDeposit funds an account from outside the bank, only staff can do it
+func (server *Server) createDeposit(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req clearingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	if !server.withinLimit(ctx, account.Currency, req.Amount, server.config.MaxDepositAmount) {
+		return
+	}
+
+	result, err := server.store.DepositTx(db.WithTxOptions(ctx, transferTxOptions), db.DepositTxParams{
+		AccountID:   account.ID,
+		Amount:      req.Amount,
+		ExternalRef: req.ExternalRef,
+	})
+	if err != nil {
+		clearingError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// createWithdrawal pays money out of an account, for its owner or staff
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req clearingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username && !hasRole(authPayload, utils.BankerRole, utils.AdminRole) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	if !server.withinLimit(ctx, account.Currency, req.Amount, server.config.MaxWithdrawalAmount) {
+		return
+	}
+
+	result, err := server.store.WithdrawTx(db.WithTxOptions(ctx, transferTxOptions), db.WithdrawTxParams{
+		AccountID:   account.ID,
+		Amount:      req.Amount,
+		ExternalRef: req.ExternalRef,
+	})
+	if err != nil {
+		clearingError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// withinLimit checks amount, in minor units, against a limit configured in major units
+// so one setting works for currencies with different exponents. Zero means no limit.
+func (server *Server) withinLimit(ctx *gin.Context, currencyCode string, amount int64, limit int64) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	currency, _ := server.currencies.Get(currencyCode)
+	minorLimit := limit * int64(math.Pow10(int(currency.Exponent)))
+	if amount > minorLimit {
+		err := fmt.Errorf("amount %d exceeds the limit of %d %s", amount, limit, currencyCode)
+		ctx.Error(statusError(http.StatusUnprocessableEntity, err))
+		return false
+	}
+	return true
+}
+
+// clearingError names the external reference when it is the one that was already used
+func clearingError(ctx *gin.Context, err error) {
+	if errors.Is(err, db.ErrConflict) {
+		err = statusError(http.StatusConflict, fmt.Errorf("external_ref was already used: %w", err))
+	}
+	ctx.Error(err)
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/exchange"
+)
+
+// ErrorResponse is the body of every failed request
+type ErrorResponse struct {
+	// Code names the error for clients, it does not change when Message is reworded
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"request_id"`
+}
+
+// apiError is an error together with the status and code it is answered with
+type apiError struct {
+	status int
+	code   string
+	err    error
+	// message replaces the text of err in the response when set
+	message string
+	details map[string]any
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+// withDetail returns a copy of e that also reports key in its details
+func (e *apiError) withDetail(key string, value any) *apiError {
+	copied := *e
+	copied.details = maps.Clone(e.details)
+	if copied.details == nil {
+		copied.details = make(map[string]any)
+	}
+	copied.details[key] = value
+	return &copied
+}
+
+// statusError answers err with status, for the failures a handler detects itself
+// such as a request that does not bind or an account owned by someone else
+func statusError(status int, err error) *apiError {
+	return &apiError{
+		status: status,
+		code:   strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+		err:    err,
+	}
+}
+
+// domainErrors maps the errors of the store and the exchange package to a status and a code,
+// the first one an error matches wins
+var domainErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{db.ErrInsufficientFunds, http.StatusUnprocessableEntity, "insufficient_funds"},
+	{db.ErrAccountNotActive, http.StatusForbidden, "account_not_active"},
+	{db.ErrInvalidStatusTransition, http.StatusConflict, "invalid_status_transition"},
+	{db.ErrAccountNotEmpty, http.StatusUnprocessableEntity, "account_not_empty"},
+	{db.ErrScheduledTransferEnded, http.StatusConflict, "scheduled_transfer_ended"},
+	{db.ErrTransferNotReversible, http.StatusUnprocessableEntity, "transfer_not_reversible"},
+	{db.ErrReversalExceedsTransfer, http.StatusUnprocessableEntity, "reversal_exceeds_transfer"},
+	{db.ErrHoldNotActive, http.StatusConflict, "hold_not_active"},
+	{db.ErrHoldExpired, http.StatusConflict, "hold_expired"},
+	{db.ErrCaptureExceedsHold, http.StatusUnprocessableEntity, "capture_exceeds_hold"},
+	{exchange.ErrAmountTooSmall, http.StatusBadRequest, "amount_too_small"},
+	{exchange.ErrRateNotFound, http.StatusUnprocessableEntity, "rate_not_found"},
+	{db.ErrNotFound, http.StatusNotFound, "not_found"},
+	{db.ErrConflict, http.StatusConflict, "conflict"},
+	{db.ErrForeignKey, http.StatusUnprocessableEntity, "invalid_reference"},
+}
+
+// translateError finds the status, code and details err is answered with,
+// anything it doesn't know is an internal error
+func translateError(err error) *apiError {
+	var batchErr *db.BatchTransferError
+	if errors.As(err, &batchErr) {
+		return translateError(batchErr.Err).withDetail("transfer_index", batchErr.Index)
+	}
+
+	if fields := fieldErrors(err); fields != nil {
+		messages := make([]string, len(fields))
+		for i, field := range fields {
+			messages[i] = field.Message
+		}
+		return &apiError{
+			status:  http.StatusBadRequest,
+			code:    "validation_failed",
+			err:     err,
+			message: strings.Join(messages, "; "),
+			details: map[string]any{"fields": fields},
+		}
+	}
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, domainErr := range domainErrors {
+		if errors.Is(err, domainErr.err) {
+			return &apiError{
+				status:  domainErr.status,
+				code:    domainErr.code,
+				err:     err,
+				details: errorDetails(err),
+			}
+		}
+	}
+
+	return statusError(http.StatusInternalServerError, err)
+}
+
+// errorDetails exposes the fields of the store errors that carry some
+func errorDetails(err error) map[string]any {
+	var fundsErr *db.InsufficientFundsError
+	if errors.As(err, &fundsErr) {
+		return map[string]any{
+			"account_id":        fundsErr.AccountID,
+			"amount":            fundsErr.Amount,
+			"available_balance": fundsErr.Available,
+		}
+	}
+
+	var notActiveErr *db.AccountNotActiveError
+	if errors.As(err, &notActiveErr) {
+		return map[string]any{
+			"account_id": notActiveErr.AccountID,
+			"status":     notActiveErr.Status,
+		}
+	}
+
+	var reversalErr *db.ReversalExceedsTransferError
+	if errors.As(err, &reversalErr) {
+		return map[string]any{
+			"transfer_id":       reversalErr.TransferID,
+			"amount":            reversalErr.Amount,
+			"reversible_amount": reversalErr.Reversible,
+		}
+	}
+
+	return nil
+}
+
+// errorMiddleware answers with the last error added to the gin context by a handler
+// or a middleware that did not write a response itself
+func errorMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		writeError(ctx)
+	}
+}
+
+// writeError is errorMiddleware for middlewares that need the response before it returns,
+// it does nothing once a response was written
+func writeError(ctx *gin.Context) {
+	if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+		return
+	}
+
+	apiErr := translateError(ctx.Errors.Last().Err)
+	message := apiErr.Error()
+	if apiErr.message != "" {
+		message = apiErr.message
+	}
+	if apiErr.status >= http.StatusInternalServerError {
+		// the cause is logged with the request, not shown to the client
+		message = http.StatusText(apiErr.status)
+	}
+
+	ctx.JSON(apiErr.status, ErrorResponse{
+		Code:      apiErr.code,
+		Message:   message,
+		Details:   apiErr.details,
+		RequestID: db.AuditMetadataFrom(ctx.Request.Context()).RequestID,
+	})
+}
+
+// abortWithError stops the chain, errorMiddleware answers with err
+func abortWithError(ctx *gin.Context, err error) {
+	ctx.Error(err)
+	ctx.Abort()
+}
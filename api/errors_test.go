@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestTranslateError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		details map[string]any
+	}{
+		{
+			name:   "NotFound",
+			err:    fmt.Errorf("get account: %w", db.ErrNotFound),
+			status: http.StatusNotFound,
+			code:   "not_found",
+		},
+		{
+			name:   "Conflict",
+			err:    &db.Error{Kind: db.ErrConflict, Constraint: "users_pkey"},
+			status: http.StatusConflict,
+			code:   "conflict",
+		},
+		{
+			name:   "ForeignKey",
+			err:    &db.Error{Kind: db.ErrForeignKey, Constraint: "accounts_owner_fkey"},
+			status: http.StatusUnprocessableEntity,
+			code:   "invalid_reference",
+		},
+		{
+			name:   "InsufficientFunds",
+			err:    &db.InsufficientFundsError{AccountID: 1, Amount: 10, Available: 5},
+			status: http.StatusUnprocessableEntity,
+			code:   "insufficient_funds",
+			details: map[string]any{
+				"account_id":        int64(1),
+				"amount":            int64(10),
+				"available_balance": int64(5),
+			},
+		},
+		{
+			name:   "BatchTransfer",
+			err:    &db.BatchTransferError{Index: 2, Err: &db.AccountNotActiveError{AccountID: 3, Status: sqlc.AccountStatusFrozen}},
+			status: http.StatusForbidden,
+			code:   "account_not_active",
+			details: map[string]any{
+				"account_id":     int64(3),
+				"status":         sqlc.AccountStatusFrozen,
+				"transfer_index": 2,
+			},
+		},
+		{
+			name:   "Status",
+			err:    statusError(http.StatusUnauthorized, errors.New("not yours")),
+			status: http.StatusUnauthorized,
+			code:   "unauthorized",
+		},
+		{
+			name:   "Unknown",
+			err:    errors.New("connection reset"),
+			status: http.StatusInternalServerError,
+			code:   "internal_server_error",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := translateError(tc.err)
+			require.Equal(t, tc.status, apiErr.status)
+			require.Equal(t, tc.code, apiErr.code)
+			require.Equal(t, tc.details, apiErr.details)
+		})
+	}
+}
+
+func TestErrorResponseBody(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Account{}, errors.New("connection reset"))
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/accounts/1", nil)
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeaderKey, "request-1")
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	rsp := requireErrorResponse(t, recorder, "internal_server_error")
+	require.Equal(t, "request-1", rsp.RequestID)
+	// the cause of an internal error stays in the logs
+	require.NotContains(t, rsp.Message, "connection reset")
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name   string
+		path   string
+		body   gin.H
+		fields []fieldError
+	}{
+		{
+			name: "Rules",
+			path: "/transfers",
+			body: gin.H{"from_account_id": 1, "to_account_id": 2, "amount": -1, "currency": "XYZ"},
+			fields: []fieldError{
+				{Field: "amount", Rule: "gt", Param: "0", Message: "amount must be greater than 0"},
+				{Field: "currency", Rule: "currency", Message: "currency is not a supported currency"},
+			},
+		},
+		{
+			name: "Required",
+			path: "/transfers",
+			body: gin.H{"from_account_id": 1, "to_account_id": 2, "amount": 10},
+			fields: []fieldError{
+				{Field: "currency", Rule: "required", Message: "currency is a required field"},
+			},
+		},
+		{
+			name: "Nested",
+			path: "/transfers/batch",
+			body: gin.H{"transfers": []gin.H{
+				{"from_account_id": 1, "to_account_id": 2, "amount": 10, "currency": utils.USD},
+				{"from_account_id": 1, "to_account_id": 0, "amount": 10, "currency": utils.USD},
+			}},
+			fields: []fieldError{
+				{Field: "transfers[1].to_account_id", Rule: "required", Message: "to_account_id is a required field"},
+			},
+		},
+		{
+			name: "Scope",
+			path: "/api-keys",
+			body: gin.H{"name": "reporting", "scopes": []string{utils.AccountsReadScope, "everything"}},
+			fields: []fieldError{
+				{Field: "scopes[1]", Rule: "scope", Message: "scopes[1] is not a supported scope"},
+			},
+		},
+		{
+			name: "Type",
+			path: "/transfers",
+			body: gin.H{"from_account_id": "one", "to_account_id": 2, "amount": 10, "currency": utils.USD},
+			fields: []fieldError{
+				{Field: "from_account_id", Rule: "type", Param: "int64", Message: "from_account_id must be of type int64"},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mock.NewMockStore(ctrl))
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, tc.path, bytes.NewReader(data))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+			var rsp struct {
+				Code    string `json:"code"`
+				Details struct {
+					Fields []fieldError `json:"fields"`
+				} `json:"details"`
+			}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+			require.Equal(t, "validation_failed", rsp.Code)
+			require.Equal(t, tc.fields, rsp.Details.Fields)
+		})
+	}
+}
+
+// requireErrorResponse checks recorder holds an error body with code and returns it
+func requireErrorResponse(t *testing.T, recorder *httptest.ResponseRecorder, code string) ErrorResponse {
+	var rsp ErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, code, rsp.Code)
+	require.NotEmpty(t, rsp.Message)
+	require.NotEmpty(t, rsp.RequestID)
+	return rsp
+}
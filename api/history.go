@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+)
+
+// listHistoryRequest holds the filters shared by the entry and transfer history endpoints.
+// Pages are keyset based: pass the next_cursor of the previous page to get the next one.
+type listHistoryRequest struct {
+	PageSize  int32     `form:"page_size" binding:"required,min=5,max=100"`
+	Cursor    int64     `form:"cursor" binding:"omitempty,min=1"`
+	StartTime time.Time `form:"start_time"`
+	EndTime   time.Time `form:"end_time" binding:"omitempty,gtfield=StartTime"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=in out"`
+	MinAmount int64     `form:"min_amount" binding:"omitempty,min=1"`
+	MaxAmount int64     `form:"max_amount" binding:"omitempty,min=1,gtefield=MinAmount"`
+}
+
+type listEntriesResponse struct {
+	Entries    []sqlc.Entry `json:"entries"`
+	NextCursor int64        `json:"next_cursor,omitempty"`
+}
+
+type listTransfersResponse struct {
+	Transfers  []sqlc.Transfer `json:"transfers"`
+	NextCursor int64           `json:"next_cursor,omitempty"`
+}
+
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req listHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	if _, ok := server.viewableAccount(ctx, uri.ID); !ok {
+		return
+	}
+
+	entries, err := server.store.ListAccountEntries(ctx, sqlc.ListAccountEntriesParams{
+		AccountID: uri.ID,
+		Cursor:    optionalInt8(req.Cursor),
+		StartTime: optionalTimestamptz(req.StartTime),
+		EndTime:   optionalTimestamptz(req.EndTime),
+		Direction: optionalText(req.Direction),
+		MinAmount: optionalInt8(req.MinAmount),
+		MaxAmount: optionalInt8(req.MaxAmount),
+		PageSize:  req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := listEntriesResponse{Entries: entries}
+	if len(entries) == int(req.PageSize) {
+		rsp.NextCursor = entries[len(entries)-1].ID
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req listHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	if _, ok := server.viewableAccount(ctx, uri.ID); !ok {
+		return
+	}
+
+	transfers, err := server.store.ListAccountTransfers(ctx, sqlc.ListAccountTransfersParams{
+		AccountID: uri.ID,
+		Cursor:    optionalInt8(req.Cursor),
+		StartTime: optionalTimestamptz(req.StartTime),
+		EndTime:   optionalTimestamptz(req.EndTime),
+		Direction: optionalText(req.Direction),
+		MinAmount: optionalInt8(req.MinAmount),
+		MaxAmount: optionalInt8(req.MaxAmount),
+		PageSize:  req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := listTransfersResponse{Transfers: transfers}
+	if len(transfers) == int(req.PageSize) {
+		rsp.NextCursor = transfers[len(transfers)-1].ID
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// viewableAccount loads the account and makes sure the authenticated user may see it,
+// reporting the error itself when it may not
+func (server *Server) viewableAccount(ctx *gin.Context, accountID int64) (sqlc.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		ctx.Error(err)
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canViewAccount(authPayload, account) {
+		err := errors.New("account doesn't belong to authenticated user")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// zero values mean the filter was not given
+func optionalInt8(value int64) pgtype.Int8 {
+	return pgtype.Int8{Int64: value, Valid: value != 0}
+}
+
+func optionalText(value string) pgtype.Text {
+	return pgtype.Text{String: value, Valid: value != ""}
+}
+
+func optionalTimestamptz(value time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: value, Valid: !value.IsZero()}
+}
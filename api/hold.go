@@ -0,0 +1,278 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+// defaultHoldDuration is how long a hold stays capturable when the request doesn't say
+const defaultHoldDuration = 7 * 24 * time.Hour
+
+// placeHoldRequest reserves amount, in the currency of the held account, for a later
+// capture by to_account_id. It expires after expires_in_seconds, at most 30 days.
+// Like a transfer only the owner of the held account can place one, staff placing a hold
+// toward an account of their choosing could then capture it into that account.
+type placeHoldRequest struct {
+	ToAccountID      int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount           int64  `json:"amount" binding:"required,gt=0"`
+	Currency         string `json:"currency" binding:"required,currency"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds" binding:"omitempty,min=60,max=2592000"`
+}
+
+func (server *Server) placeHold(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req placeHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	if req.ToAccountID == uri.ID {
+		err := errors.New("an account cannot hold money for itself")
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	if account.Currency != req.Currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, req.Currency)
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	toAccount, valid := server.validAccount(ctx, req.ToAccountID)
+	if !valid {
+		return
+	}
+
+	// the rate is taken at capture, this only makes sure there will be one
+	if toAccount.Currency != account.Currency {
+		if _, valid := server.exchangeRate(ctx, account.Currency, toAccount.Currency); !valid {
+			return
+		}
+	}
+
+	duration := defaultHoldDuration
+	if req.ExpiresInSeconds != 0 {
+		duration = time.Duration(req.ExpiresInSeconds) * time.Second
+	}
+
+	hold, err := server.store.PlaceHoldTx(ctx, sqlc.CreateHoldParams{
+		AccountID:   account.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      req.Amount,
+		ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(duration), Valid: true},
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, hold)
+}
+
+type listAccountHoldsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listAccountHolds(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req listAccountHoldsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	if _, ok := server.viewableAccount(ctx, uri.ID); !ok {
+		return
+	}
+
+	holds, err := server.store.ListAccountHolds(ctx, sqlc.ListAccountHoldsParams{
+		AccountID: uri.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holds)
+}
+
+type getHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getHold shows a hold to the owners of either account, bankers and admins
+func (server *Server) getHold(ctx *gin.Context) {
+	var req getHoldRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	hold, ok := server.findHold(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !hasRole(authPayload, utils.BankerRole, utils.AdminRole) {
+		for _, accountID := range []int64{hold.AccountID, hold.ToAccountID} {
+			account, err := server.store.GetAccount(ctx, accountID)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			if account.Owner == authPayload.Username {
+				ctx.JSON(http.StatusOK, hold)
+				return
+			}
+		}
+		err := errors.New("hold doesn't involve an account of the authenticated user")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+type captureHoldRequest struct {
+	// Amount is in the currency of the held account, leave it out to capture the whole hold
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// captureHold turns a hold into a transfer, any part not captured is released.
+// Only the owner of the account the hold was placed for can capture it.
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri getHoldRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req captureHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	hold, toAccount, ok := server.payeeHold(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	fromAccount, err := server.store.GetAccount(ctx, hold.AccountID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	arg := db.CaptureHoldTxParams{
+		HoldID: hold.ID,
+		Amount: req.Amount,
+	}
+
+	if toAccount.Currency != fromAccount.Currency {
+		rate, valid := server.exchangeRate(ctx, fromAccount.Currency, toAccount.Currency)
+		if !valid {
+			return
+		}
+		arg.Rate = &rate
+	}
+
+	result, err := server.store.CaptureHoldTx(db.WithTxOptions(ctx, transferTxOptions), arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// releaseHold gives up a hold without moving any money
+func (server *Server) releaseHold(ctx *gin.Context) {
+	var uri getHoldRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	hold, _, ok := server.payeeHold(ctx, uri.ID, utils.BankerRole, utils.AdminRole)
+	if !ok {
+		return
+	}
+
+	hold, err := server.store.ReleaseHoldTx(ctx, hold.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+// payeeHold loads a hold for capture or release, which only the owner of the account
+// it was placed for or a user with one of roles may do
+func (server *Server) payeeHold(ctx *gin.Context, id int64, roles ...string) (sqlc.Hold, sqlc.Account, bool) {
+	hold, ok := server.findHold(ctx, id)
+	if !ok {
+		return hold, sqlc.Account{}, false
+	}
+
+	toAccount, err := server.store.GetAccount(ctx, hold.ToAccountID)
+	if err != nil {
+		ctx.Error(err)
+		return hold, toAccount, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if toAccount.Owner != authPayload.Username && !hasRole(authPayload, roles...) {
+		err := errors.New("only the account a hold was placed for can capture or release it")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return hold, toAccount, false
+	}
+
+	return hold, toAccount, true
+}
+
+func (server *Server) findHold(ctx *gin.Context, id int64) (sqlc.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return hold, false
+	}
+	return hold, true
+}
@@ -0,0 +1,556 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestPlaceHoldAPI(t *testing.T) {
+	payer, _ := randomUser(t)
+	payee, _ := randomUser(t)
+
+	account1 := randomAccount(payer.Username)
+	account2 := randomAccount(payee.Username)
+	account3 := randomAccount(payee.Username)
+	account1.ID, account2.ID, account3.ID = 1, 2, 3
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+	account3.Currency = utils.EUR
+
+	amount := int64(10)
+	hold := sqlc.Hold{
+		ID:          utils.RandomInt(1, 1000),
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      amount,
+		Status:      sqlc.HoldStatusActive,
+	}
+
+	asPayer := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, payer.Username, utils.DepositorRole, time.Minute)
+	}
+
+	// expectPlaceHold checks the hold expires about duration from now
+	expectPlaceHold := func(store *mock.MockStore, toAccountID int64, duration time.Duration) {
+		store.EXPECT().
+			PlaceHoldTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg sqlc.CreateHoldParams) (sqlc.Hold, error) {
+				require.Equal(t, account1.ID, arg.AccountID)
+				require.Equal(t, toAccountID, arg.ToAccountID)
+				require.Equal(t, amount, arg.Amount)
+				require.WithinDuration(t, time.Now().Add(duration), arg.ExpiresAt.Time, time.Minute)
+				return hold, nil
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"to_account_id": account2.ID,
+				"amount":        amount,
+				"currency":      utils.USD,
+			},
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				expectPlaceHold(store, account2.ID, defaultHoldDuration)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatchHold(t, recorder.Body, hold)
+			},
+		},
+		{
+			name: "ExpiresInOK",
+			body: gin.H{
+				"to_account_id":      account3.ID,
+				"amount":             amount,
+				"currency":           utils.USD,
+				"expires_in_seconds": 3600,
+			},
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				expectPlaceHold(store, account3.ID, time.Hour)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiresInTooLong",
+			body: gin.H{
+				"to_account_id":      account2.ID,
+				"amount":             amount,
+				"currency":           utils.USD,
+				"expires_in_seconds": 31 * 24 * 3600,
+			},
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "SameAccount",
+			body: gin.H{
+				"to_account_id": account1.ID,
+				"amount":        amount,
+				"currency":      utils.USD,
+			},
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"to_account_id": account2.ID,
+				"amount":        amount,
+				"currency":      utils.EUR,
+			},
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwner",
+			body: gin.H{
+				"to_account_id": account2.ID,
+				"amount":        amount,
+				"currency":      utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, payee.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Banker",
+			body: gin.H{
+				"to_account_id": account2.ID,
+				"amount":        amount,
+				"currency":      utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "teller", utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"to_account_id": account2.ID,
+				"amount":        amount,
+				"currency":      utils.USD,
+			},
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					PlaceHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.Hold{}, &db.InsufficientFundsError{AccountID: account1.ID, Amount: amount, Available: 4})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				rsp := requireErrorResponse(t, recorder, "insufficient_funds")
+				require.Equal(t, float64(4), rsp.Details["available_balance"])
+			},
+		},
+		{
+			name: "AccountNotActive",
+			body: gin.H{
+				"to_account_id": account2.ID,
+				"amount":        amount,
+				"currency":      utils.USD,
+			},
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					PlaceHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.Hold{}, &db.AccountNotActiveError{AccountID: account1.ID, Status: sqlc.AccountStatusFrozen})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/holds", account1.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestHoldAPI(t *testing.T) {
+	payer, _ := randomUser(t)
+	payee, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	account1 := randomAccount(payer.Username)
+	account2 := randomAccount(payee.Username)
+	account1.ID, account2.ID = 1, 2
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+
+	hold := sqlc.Hold{
+		ID:          utils.RandomInt(1, 1000),
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      10,
+		Status:      sqlc.HoldStatusActive,
+	}
+	released := hold
+	released.Status = sqlc.HoldStatusReleased
+
+	asPayer := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, payer.Username, utils.DepositorRole, time.Minute)
+	}
+	asPayee := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, payee.Username, utils.DepositorRole, time.Minute)
+	}
+	asAdmin := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin", utils.AdminRole, time.Minute)
+	}
+	path := fmt.Sprintf("/holds/%d", hold.ID)
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "GetAsPayerOK",
+			method:    http.MethodGet,
+			url:       path,
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchHold(t, recorder.Body, hold)
+			},
+		},
+		{
+			name:      "GetAsPayeeOK",
+			method:    http.MethodGet,
+			url:       path,
+			setupAuth: asPayee,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "GetOtherUser",
+			method: http.MethodGet,
+			url:    path,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, other.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "GetNotFound",
+			method:    http.MethodGet,
+			url:       path,
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(sqlc.Hold{}, db.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "ListOK",
+			method:    http.MethodGet,
+			url:       fmt.Sprintf("/accounts/%d/holds?page_id=1&page_size=5", account1.ID),
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+
+				arg := sqlc.ListAccountHoldsParams{AccountID: account1.ID, Limit: 5, Offset: 0}
+				store.EXPECT().ListAccountHolds(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]sqlc.Hold{hold}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "CaptureOK",
+			method:    http.MethodPost,
+			url:       path + "/capture",
+			body:      gin.H{"amount": 6},
+			setupAuth: asPayee,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+
+				arg := db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 6}
+				store.EXPECT().CaptureHoldTx(EqTxOptions(transferTxOptions), gomock.Eq(arg)).Times(1).Return(db.CaptureHoldTxResult{Hold: hold}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name:      "CaptureAsPayer",
+			method:    http.MethodPost,
+			url:       path + "/capture",
+			body:      gin.H{},
+			setupAuth: asPayer,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "CaptureAsAdmin",
+			method:    http.MethodPost,
+			url:       path + "/capture",
+			body:      gin.H{},
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "CaptureExceedsHold",
+			method:    http.MethodPost,
+			url:       path + "/capture",
+			body:      gin.H{"amount": 11},
+			setupAuth: asPayee,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account2, nil)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CaptureHoldTxResult{}, db.ErrCaptureExceedsHold)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:      "CaptureExpired",
+			method:    http.MethodPost,
+			url:       path + "/capture",
+			body:      gin.H{},
+			setupAuth: asPayee,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account2, nil)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CaptureHoldTxResult{}, db.ErrHoldExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "ReleaseOK",
+			method:    http.MethodPost,
+			url:       path + "/release",
+			setupAuth: asPayee,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReleaseHoldTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(released, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchHold(t, recorder.Body, released)
+			},
+		},
+		{
+			name:      "ReleaseAsAdminOK",
+			method:    http.MethodPost,
+			url:       path + "/release",
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReleaseHoldTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(released, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "ReleaseNotActive",
+			method:    http.MethodPost,
+			url:       path + "/release",
+			setupAuth: asPayee,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(released, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReleaseHoldTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(sqlc.Hold{}, db.ErrHoldNotActive)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "ReleaseInternalError",
+			method:    http.MethodPost,
+			url:       path + "/release",
+			setupAuth: asPayee,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReleaseHoldTx(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.Hold{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			method:    http.MethodGet,
+			url:       path,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				err := json.NewEncoder(&body).Encode(tc.body)
+				require.NoError(t, err)
+			}
+
+			request, err := http.NewRequest(tc.method, tc.url, &body)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchHold(t *testing.T, body *bytes.Buffer, hold sqlc.Hold) {
+	var gotHold sqlc.Hold
+	err := json.Unmarshal(body.Bytes(), &gotHold)
+	require.NoError(t, err)
+	require.Equal(t, hold, gotHold)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
+
+// bodyRecorder keeps a copy of everything the handler writes so it can be stored for replays
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// idempotencyMiddleware makes a request safe to retry when the client sends an Idempotency-Key header.
+// The first request with a key runs the handler and stores its response, a retry with the same key and
+// body gets the stored response back, the same key with a different body is rejected with 409.
+// It must run after authMiddleware since keys are scoped per user.
+func idempotencyMiddleware(store db.Store, ttl time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyKeyHeader)
+		if len(key) == 0 {
+			ctx.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			err := fmt.Errorf("%s header must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+			abortWithError(ctx, statusError(http.StatusBadRequest, err))
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			abortWithError(ctx, statusError(http.StatusBadRequest, err))
+			return
+		}
+		// handler still needs to bind the body
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		requestHash := hashRequest(ctx.Request.Method, ctx.Request.URL.Path, body)
+
+		_, err = store.CreateIdempotencyKey(ctx, sqlc.CreateIdempotencyKeyParams{
+			Key:         key,
+			Username:    authPayload.Username,
+			RequestHash: requestHash,
+			ExpiredAt:   pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				// key is already taken and still live
+				replayIdempotentResponse(ctx, store, authPayload.Username, key, requestHash)
+				return
+			}
+			abortWithError(ctx, err)
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = recorder
+		ctx.Next()
+		// the error response has to be written now to be stored with the key
+		writeError(ctx)
+
+		status := ctx.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			// nothing was committed, so let the client retry with the same key
+			err = store.DeleteIdempotencyKey(ctx, sqlc.DeleteIdempotencyKeyParams{
+				Username: authPayload.Username,
+				Key:      key,
+			})
+			if err != nil {
+				ctx.Error(err)
+			}
+			return
+		}
+
+		_, err = store.UpdateIdempotencyKeyResponse(ctx, sqlc.UpdateIdempotencyKeyResponseParams{
+			Username:     authPayload.Username,
+			Key:          key,
+			ResponseCode: int32(status),
+			ResponseBody: recorder.body.Bytes(),
+		})
+		if err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+func replayIdempotentResponse(ctx *gin.Context, store db.Store, username string, key string, requestHash string) {
+	record, err := store.GetIdempotencyKey(ctx, sqlc.GetIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	if record.RequestHash != requestHash {
+		err := fmt.Errorf("%s %q was already used for a different request", idempotencyKeyHeader, key)
+		abortWithError(ctx, statusError(http.StatusConflict, err))
+		return
+	}
+
+	if record.ResponseCode == 0 {
+		err := fmt.Errorf("request with %s %q is still in progress", idempotencyKeyHeader, key)
+		abortWithError(ctx, statusError(http.StatusConflict, err))
+		return
+	}
+
+	ctx.Data(int(record.ResponseCode), "application/json; charset=utf-8", record.ResponseBody)
+	ctx.Abort()
+}
+
+// hashRequest fingerprints a request so a reused key can be told apart from a genuine retry
+func hashRequest(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
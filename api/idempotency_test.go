@@ -0,0 +1,306 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	username := utils.RandomOwner()
+	key := utils.RandomString(16)
+	idempotentPath := "/idempotent"
+	body := `{"amount":10}`
+	requestHash := hashRequest(http.MethodPost, idempotentPath, []byte(body))
+	storedBody := []byte(`{"stored":true}`)
+
+	testCases := []struct {
+		name          string
+		key           string
+		handlerStatus int
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int)
+	}{
+		{
+			name:          "NoKey",
+			key:           "",
+			handlerStatus: http.StatusCreated,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			name:          "FirstRequest",
+			key:           key,
+			handlerStatus: http.StatusCreated,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg sqlc.CreateIdempotencyKeyParams) (sqlc.IdempotencyKey, error) {
+						require.Equal(t, key, arg.Key)
+						require.Equal(t, username, arg.Username)
+						require.Equal(t, requestHash, arg.RequestHash)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiredAt.Time, time.Second)
+						return sqlc.IdempotencyKey{Key: arg.Key, Username: arg.Username, RequestHash: arg.RequestHash}, nil
+					})
+
+				arg := sqlc.UpdateIdempotencyKeyResponseParams{
+					Username:     username,
+					Key:          key,
+					ResponseCode: http.StatusCreated,
+					ResponseBody: []byte(`{"handled":true}`),
+				}
+				store.EXPECT().
+					UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(sqlc.IdempotencyKey{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			name:          "Replay",
+			key:           key,
+			handlerStatus: http.StatusCreated,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.IdempotencyKey{}, db.ErrNotFound)
+
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Eq(sqlc.GetIdempotencyKeyParams{Username: username, Key: key})).
+					Times(1).
+					Return(sqlc.IdempotencyKey{
+						Key:          key,
+						Username:     username,
+						RequestHash:  requestHash,
+						ResponseCode: http.StatusCreated,
+						ResponseBody: storedBody,
+					}, nil)
+
+				store.EXPECT().
+					UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, storedBody, recorder.Body.Bytes())
+				require.Zero(t, handlerCalls)
+			},
+		},
+		{
+			name:          "KeyReusedWithDifferentRequest",
+			key:           key,
+			handlerStatus: http.StatusCreated,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.IdempotencyKey{}, db.ErrNotFound)
+
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.IdempotencyKey{
+						Key:          key,
+						Username:     username,
+						RequestHash:  hashRequest(http.MethodPost, idempotentPath, []byte(`{"amount":99}`)),
+						ResponseCode: http.StatusCreated,
+						ResponseBody: storedBody,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Zero(t, handlerCalls)
+			},
+		},
+		{
+			name:          "InProgress",
+			key:           key,
+			handlerStatus: http.StatusCreated,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.IdempotencyKey{}, db.ErrNotFound)
+
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.IdempotencyKey{
+						Key:         key,
+						Username:    username,
+						RequestHash: requestHash,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Zero(t, handlerCalls)
+			},
+		},
+		{
+			name:          "HandlerInternalError",
+			key:           key,
+			handlerStatus: http.StatusInternalServerError,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.IdempotencyKey{}, nil)
+
+				store.EXPECT().
+					DeleteIdempotencyKey(gomock.Any(), gomock.Eq(sqlc.DeleteIdempotencyKeyParams{Username: username, Key: key})).
+					Times(1).
+					Return(nil)
+
+				store.EXPECT().
+					UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			name:          "CreateKeyError",
+			key:           key,
+			handlerStatus: http.StatusCreated,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.IdempotencyKey{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Zero(t, handlerCalls)
+			},
+		},
+		{
+			name:          "KeyTooLong",
+			key:           strings.Repeat("k", maxIdempotencyKeyLength+1),
+			handlerStatus: http.StatusCreated,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Zero(t, handlerCalls)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			handlerCalls := 0
+			server.router.POST(
+				idempotentPath,
+				authMiddleware(server.tokenMaker, server.revoker, server.store),
+				idempotencyMiddleware(store, server.config.IdempotencyKeyTTL),
+				func(ctx *gin.Context) {
+					handlerCalls++
+					// the body must still be readable after the middleware hashed it
+					var req struct {
+						Amount int64 `json:"amount" binding:"required"`
+					}
+					require.NoError(t, ctx.ShouldBindJSON(&req))
+					ctx.JSON(tc.handlerStatus, gin.H{"handled": true})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, idempotentPath, bytes.NewReader([]byte(body)))
+			require.NoError(t, err)
+			if len(tc.key) > 0 {
+				request.Header.Set(idempotencyKeyHeader, tc.key)
+			}
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, utils.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, handlerCalls)
+		})
+	}
+}
+
+func TestIdempotentTransferReplay(t *testing.T) {
+	user, _ := randomUser(t)
+	key := utils.RandomString(16)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	body := []byte(`{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`)
+	storedBody := []byte(`{"transfer":{"id":7,"from_account_id":1,"to_account_id":2,"amount":10}}`)
+
+	store.EXPECT().
+		CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(sqlc.IdempotencyKey{}, db.ErrNotFound)
+
+	store.EXPECT().
+		GetIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(sqlc.IdempotencyKey{
+			Key:          key,
+			Username:     user.Username,
+			RequestHash:  hashRequest(http.MethodPost, "/transfers", body),
+			ResponseCode: http.StatusCreated,
+			ResponseBody: storedBody,
+		}, nil)
+
+	// a replay must never move money again
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Any()).
+		Times(0)
+	store.EXPECT().
+		TransferTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set(idempotencyKeyHeader, key)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	require.Equal(t, storedBody, recorder.Body.Bytes())
+}
@@ -6,22 +6,66 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
 	"github.com/suryansh74/simplebank/utils"
 )
 
 func newTestServer(t *testing.T, store db.Store) *Server {
-	config := utils.Config{
-		TokenSymmetricKey:   utils.RandomString(32),
-		AccessTokenDuration: time.Minute,
+	return newTestServerWithConfig(t, store, testConfig())
+}
+
+func newTestServerWithConfig(t *testing.T, store db.Store, config utils.Config) *Server {
+	if store == nil {
+		store = mock.NewMockStore(gomock.NewController(t))
+	}
+	// the currency registry is loaded when the server starts
+	if mockStore, ok := store.(*mock.MockStore); ok {
+		mockStore.EXPECT().
+			ListCurrencies(gomock.Any()).
+			AnyTimes().
+			Return(testCurrencies, nil)
 	}
 
 	server, err := NewServer(config, store)
 	require.NoError(t, err)
+
+	server.rates = newTestRates(t)
 	return server
 }
 
+func testConfig() utils.Config {
+	return utils.Config{
+		TokenSymmetricKey:    utils.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		IdempotencyKeyTTL:    time.Hour,
+		TokenRevocationStore: "memory",
+		ExchangeRatesFile:    "../exchange_rates.json",
+		MaxDepositAmount:     1000,
+		MaxWithdrawalAmount:  100,
+	}
+}
+
+var testCurrencies = []sqlc.Currency{
+	{Code: utils.EUR, Exponent: 2, Enabled: true},
+	{Code: utils.GBP, Exponent: 2, Enabled: true},
+	{Code: "JPY", Exponent: 0, Enabled: true},
+	{Code: utils.USD, Exponent: 2, Enabled: true},
+	{Code: "VEF", Exponent: 2, Enabled: false},
+}
+
+// newTestRates has no GBP rates, so transfers involving GBP cannot be converted
+func newTestRates(t *testing.T) exchange.RateProvider {
+	rates, err := exchange.NewStaticProvider(map[string]string{"USD/EUR": "0.9", "USD/JPY": "150", "USD/VEF": "10"})
+	require.NoError(t, err)
+	return rates
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
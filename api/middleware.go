@@ -4,48 +4,238 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
 	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
 )
 
 const (
 	authorizationHeaderKey  = "authorization"         // ← Fixed spelling
 	authorizationTypeBearer = "bearer"                // ← Also fix this variable name
 	authorizationPayloadKey = "authorization_payload" // ← And this one
+	authorizationTypeAPIKey = "apikey"
+	requestIDHeaderKey      = "X-Request-ID"
+	maxRequestIDLength      = 128
+	// apiKeyTouchInterval is how stale last_used_at of an API key gets before it is updated
+	apiKeyTouchInterval = time.Minute
+	// authorizationTypeKey holds the lowercased authorization type a request was authenticated with
+	authorizationTypeKey = "authorization_type"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+var (
+	errRevokedAPIKey  = errors.New("api key has been revoked")
+	errExpiredAPIKey  = errors.New("api key is expired")
+	errNotAccessToken = errors.New("token is not an access token")
+	errBlockedSession = errors.New("session of the token has been blocked")
+)
+
+// requestMetadataMiddleware gives every request an ID, echoed back in the response, and puts it
+// in the request context along with the client address so the store can audit changes
+func requestMetadataMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeaderKey)
+		if len(requestID) == 0 || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(requestIDHeaderKey, requestID)
+
+		setAuditMetadata(ctx, db.AuditMetadata{
+			RequestID: requestID,
+			ClientIP:  ctx.ClientIP(),
+			UserAgent: ctx.Request.UserAgent(),
+		})
+		ctx.Next()
+	}
+}
+
+// setAuditMetadata stores metadata in the request context, the router falls back to it
+// so passing the gin context to the store is enough
+func setAuditMetadata(ctx *gin.Context, metadata db.AuditMetadata) {
+	ctx.Request = ctx.Request.WithContext(db.WithAuditMetadata(ctx.Request.Context(), metadata))
+}
+
+// setAuditActor records username as the actor of the changes made by this request
+func setAuditActor(ctx *gin.Context, username string) {
+	metadata := db.AuditMetadataFrom(ctx.Request.Context())
+	metadata.Actor = username
+	setAuditMetadata(ctx, metadata)
+}
+
+// authMiddleware verifies the bearer token and rejects it if its ID was revoked by a logout or
+// its session was blocked, or looks up the API key of an ApiKey authorization
+func authMiddleware(tokenMaker token.Maker, revoker token.Revoker, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
 			err := errors.New("authorization header is not provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, statusError(http.StatusUnauthorized, err))
 			return
 		}
 
 		fields := strings.Fields(authorizationHeader)
 		if len(fields) < 2 {
 			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, statusError(http.StatusUnauthorized, err))
 			return
 		}
 
+		var payload *token.Payload
+		var err error
 		authroizatoinType := strings.ToLower(fields[0])
-		if authroizatoinType != authorizationTypeBearer {
-			err := fmt.Errorf("unsupported authroizatoin type %s", authorizationHeader)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		switch authroizatoinType {
+		case authorizationTypeBearer:
+			payload, err = verifyAccessToken(ctx, tokenMaker, revoker, store, fields[1])
+		case authorizationTypeAPIKey:
+			payload, err = verifyAPIKey(ctx, store, fields[1])
+		default:
+			err = statusError(http.StatusUnauthorized, fmt.Errorf("unsupported authroizatoin type %s", fields[0]))
+		}
+		if err != nil {
+			abortWithError(ctx, err)
 			return
 		}
 
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Set(authorizationTypeKey, authroizatoinType)
+		setAuditActor(ctx, payload.Username)
+		ctx.Next()
+	}
+}
+
+func verifyAccessToken(ctx *gin.Context, tokenMaker token.Maker, revoker token.Revoker, store db.Store, accessToken string) (*token.Payload, error) {
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return nil, statusError(http.StatusUnauthorized, err)
+	}
+	// refresh tokens live much longer and are only meant for renewing
+	if payload.Use != token.AccessToken {
+		return nil, statusError(http.StatusUnauthorized, errNotAccessToken)
+	}
+
+	revoked, err := revoker.IsRevoked(ctx, payload.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, statusError(http.StatusUnauthorized, token.ErrRevokedToken)
+	}
+
+	// blocking a session takes the access tokens issued for it down too, tokens issued
+	// before access tokens named their session have none
+	if payload.SessionID != uuid.Nil {
+		session, err := store.GetSession(ctx, payload.SessionID)
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, statusError(http.StatusUnauthorized, errBlockedSession)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if session.IsBlocked {
+			return nil, statusError(http.StatusUnauthorized, errBlockedSession)
+		}
+	}
+	return payload, nil
+}
+
+// verifyAPIKey turns an API key into the payload of a token with its scopes, the role is
+// read from the user on every request so a role change applies to the keys right away
+func verifyAPIKey(ctx *gin.Context, store db.Store, key string) (*token.Payload, error) {
+	prefix, err := utils.APIKeyPrefix(key)
+	if err != nil {
+		return nil, statusError(http.StatusUnauthorized, err)
+	}
+
+	apiKey, err := store.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, statusError(http.StatusUnauthorized, utils.ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = utils.CheckAPIKey(key, apiKey.HashedKey)
+	if err != nil {
+		return nil, statusError(http.StatusUnauthorized, err)
+	}
+	if apiKey.RevokedAt.Valid {
+		return nil, statusError(http.StatusUnauthorized, errRevokedAPIKey)
+	}
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		return nil, statusError(http.StatusUnauthorized, errExpiredAPIKey)
+	}
+
+	user, err := store.GetUser(ctx, apiKey.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	// last_used_at is only a hint for the owner, it is not worth a write on every request
+	if !apiKey.LastUsedAt.Valid || time.Since(apiKey.LastUsedAt.Time) > apiKeyTouchInterval {
+		err = store.TouchAPIKey(ctx, apiKey.ID)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return nil, err
+		}
+	}
+
+	return &token.Payload{
+		Username:  user.Username,
+		Role:      string(user.Role),
+		Scopes:    apiKey.Scopes,
+		IssuedAt:  apiKey.CreatedAt.Time,
+		NotBefore: apiKey.CreatedAt.Time,
+		// zero for a key that does not expire
+		ExpiredAt: apiKey.ExpiresAt.Time,
+	}, nil
+}
+
+// requireRole must run after authMiddleware, it rejects tokens whose role is not one of roles
+func requireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !hasRole(authPayload, roles...) {
+			err := fmt.Errorf("role %q is not allowed to access this resource", authPayload.Role)
+			abortWithError(ctx, statusError(http.StatusForbidden, err))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// requireScope must run after authMiddleware, a token restricted to scopes needs one of
+// scopes. With none given only tokens without scopes are accepted.
+func requireScope(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !hasScope(authPayload, scopes...) {
+			err := fmt.Errorf("token scopes %q do not allow access to this resource", authPayload.Scopes)
+			abortWithError(ctx, statusError(http.StatusForbidden, err))
 			return
 		}
-		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+func hasScope(payload *token.Payload, scopes ...string) bool {
+	return len(payload.Scopes) == 0 || slices.ContainsFunc(scopes, payload.HasScope)
+}
+
+func hasRole(payload *token.Payload, roles ...string) bool {
+	for _, role := range roles {
+		if payload.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// canViewAccount lets bankers and admins look at any account, depositors only at their own
+func canViewAccount(payload *token.Payload, account sqlc.Account) bool {
+	return account.Owner == payload.Username || hasRole(payload, utils.BankerRole, utils.AdminRole)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,8 +9,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
 	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
 )
 
 func addAuthorization(
@@ -18,10 +24,12 @@ func addAuthorization(
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration,
 ) {
-	token, err := tokenMaker.CreateToken(username, duration)
+	token, payload, err := tokenMaker.CreateToken(username, role, token.AccessToken, uuid.Nil, duration)
 	require.NoError(t, err)
+	require.NotEmpty(t, payload)
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
 	request.Header.Set(authorizationHeaderKey, authorizationHeader)
 }
@@ -29,13 +37,13 @@ func addAuthorization(
 func TestAuthMiddleware(t *testing.T) {
 	testCases := []struct {
 		name          string
-		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", utils.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -43,7 +51,7 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "NoAuthorization",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker) {
 				// Don't add any authorization header
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -52,8 +60,8 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "UnsupportedAuthorization",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "unsupported", "user", time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker) {
+				addAuthorization(t, request, tokenMaker, "unsupported", "user", utils.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -61,7 +69,7 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "InvalidAuthorizationFormat",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker) {
 				request.Header.Set(authorizationHeaderKey, "")
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -70,7 +78,7 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "InvalidAuthorizationFormatSingleField",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker) {
 				request.Header.Set(authorizationHeaderKey, "bearer-only-without-token")
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -79,8 +87,31 @@ func TestAuthMiddleware(t *testing.T) {
 		},
 		{
 			name: "ExpiredToken",
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", -time.Minute)
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", utils.DepositorRole, -time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker) {
+				refreshToken, _, err := tokenMaker.CreateToken("user", utils.DepositorRole, token.RefreshToken, uuid.Nil, time.Hour)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, refreshToken))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RevokedToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, revoker token.Revoker) {
+				accessToken, payload, err := tokenMaker.CreateToken("user", utils.DepositorRole, token.AccessToken, uuid.Nil, time.Minute)
+				require.NoError(t, err)
+				require.NoError(t, revoker.Revoke(context.Background(), payload))
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -97,7 +128,7 @@ func TestAuthMiddleware(t *testing.T) {
 
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, server.revoker, server.store),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -107,9 +138,298 @@ func TestAuthMiddleware(t *testing.T) {
 			request, err := http.NewRequest(http.MethodGet, authPath, nil)
 			require.NoError(t, err)
 
-			tc.setupAuth(t, request, server.tokenMaker)
+			tc.setupAuth(t, request, server.tokenMaker, server.revoker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
 	}
 }
+
+func TestRequireRole(t *testing.T) {
+	testCases := []struct {
+		name         string
+		role         string
+		expectedCode int
+	}{
+		{
+			name:         "Admin",
+			role:         utils.AdminRole,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Banker",
+			role:         utils.BankerRole,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Depositor",
+			role:         utils.DepositorRole,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "NoRole",
+			role:         "",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			authPath := "/staff"
+
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revoker, server.store),
+				requireRole(utils.BankerRole, utils.AdminRole),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	testCases := []struct {
+		name          string
+		tokenScopes   []string
+		requireScopes []string
+		expectedCode  int
+	}{
+		{
+			name:          "Unscoped",
+			requireScopes: []string{utils.TransfersWriteScope},
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "HasScope",
+			tokenScopes:   []string{utils.AccountsReadScope, utils.TransfersWriteScope},
+			requireScopes: []string{utils.TransfersWriteScope},
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "MissingScope",
+			tokenScopes:   []string{utils.AccountsReadScope},
+			requireScopes: []string{utils.TransfersWriteScope},
+			expectedCode:  http.StatusForbidden,
+		},
+		{
+			name:         "UnscopedOnly",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "ScopedOnUnscopedOnly",
+			tokenScopes:  []string{utils.AccountsReadScope},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			authPath := "/scoped"
+
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revoker, server.store),
+				requireScope(tc.requireScopes...),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			accessToken, _, err := server.tokenMaker.CreateToken("user", utils.DepositorRole, token.AccessToken, uuid.Nil, time.Minute, tc.tokenScopes...)
+			require.NoError(t, err)
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestRouteScopes(t *testing.T) {
+	testCases := []struct {
+		name         string
+		method       string
+		url          string
+		tokenScopes  []string
+		expectedCode int
+	}{
+		{
+			name:         "WithdrawalAccountsWrite",
+			method:       http.MethodPost,
+			url:          "/accounts/1/withdrawals",
+			tokenScopes:  []string{utils.AccountsWriteScope},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "CaptureAccountsWrite",
+			method:       http.MethodPost,
+			url:          "/holds/1/capture",
+			tokenScopes:  []string{utils.AccountsWriteScope},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "LogoutScoped",
+			method:       http.MethodPost,
+			url:          "/users/logout",
+			tokenScopes:  []string{utils.AccountsReadScope},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// no store call is expected, the scope is checked first
+			server := newTestServer(t, mock.NewMockStore(ctrl))
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tc.method, tc.url, http.NoBody)
+			require.NoError(t, err)
+
+			accessToken, _, err := server.tokenMaker.CreateToken("user", utils.DepositorRole, token.AccessToken, uuid.Nil, time.Minute, tc.tokenScopes...)
+			require.NoError(t, err)
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestAuthMiddlewareClaims(t *testing.T) {
+	config := testConfig()
+	config.TokenIssuer = "https://bank.example"
+	config.TokenAudience = "simplebank"
+	server := newTestServerWithConfig(t, nil, config)
+
+	// the same key used by a server with another audience
+	other := config
+	other.TokenAudience = "reporting"
+	otherServer := newTestServerWithConfig(t, nil, other)
+
+	testCases := []struct {
+		name         string
+		tokenMaker   token.Maker
+		expectedCode int
+	}{
+		{
+			name:         "OK",
+			tokenMaker:   server.tokenMaker,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "OtherAudience",
+			tokenMaker:   otherServer.tokenMaker,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	authPath := "/auth"
+	server.router.GET(authPath, authMiddleware(server.tokenMaker, server.revoker, server.store), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, tc.tokenMaker, authorizationTypeBearer, "user", utils.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestRequestMetadataMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		requestID     string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, metadata db.AuditMetadata)
+	}{
+		{
+			name:      "GivenRequestID",
+			requestID: "req-123",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, metadata db.AuditMetadata) {
+				require.Equal(t, "req-123", recorder.Header().Get(requestIDHeaderKey))
+				require.Equal(t, "req-123", metadata.RequestID)
+			},
+		},
+		{
+			name:      "NoRequestID",
+			requestID: "",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, metadata db.AuditMetadata) {
+				require.NotEmpty(t, metadata.RequestID)
+				require.Equal(t, metadata.RequestID, recorder.Header().Get(requestIDHeaderKey))
+			},
+		},
+		{
+			name:      "RequestIDTooLong",
+			requestID: utils.RandomString(maxRequestIDLength + 1),
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, metadata db.AuditMetadata) {
+				require.Len(t, metadata.RequestID, 36)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			authPath := "/metadata"
+
+			var metadata db.AuditMetadata
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revoker, server.store),
+				func(ctx *gin.Context) {
+					// read through the gin context, the way handlers hand it to the store
+					metadata = db.AuditMetadataFrom(ctx)
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+			request.Header.Set(requestIDHeaderKey, tc.requestID)
+			request.Header.Set("User-Agent", "simplebank-test")
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", utils.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			require.Equal(t, "user", metadata.Actor)
+			require.Equal(t, "simplebank-test", metadata.UserAgent)
+			tc.checkResponse(t, recorder, metadata)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/reconcile"
+	"github.com/suryansh74/simplebank/token"
+)
+
+// createReconciliation runs a reconciliation right away, next to the scheduled ones
+func (server *Server) createReconciliation(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	report, err := server.reconciler.Run(ctx, authPayload.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, report)
+}
+
+type listReconciliationsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listReconciliations(ctx *gin.Context) {
+	var req listReconciliationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	runs, err := server.store.ListReconciliationRuns(ctx, sqlc.ListReconciliationRunsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+}
+
+type getReconciliationRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getReconciliation(ctx *gin.Context) {
+	var req getReconciliationRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	run, err := server.store.GetReconciliationRun(ctx, req.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	discrepancies, err := server.store.ListReconciliationDiscrepancies(ctx, run.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reconcile.Report{Run: run, Discrepancies: discrepancies})
+}
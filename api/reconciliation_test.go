@@ -0,0 +1,215 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/reconcile"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestReconciliationAPI(t *testing.T) {
+	run := sqlc.ReconciliationRun{ID: 4, TriggeredBy: "admin_user", AccountsChecked: 2, Discrepancies: 1}
+	discrepancy := sqlc.ReconciliationDiscrepancy{ID: 1, RunID: run.ID, AccountID: 9, Balance: 20, EntriesSum: 15, Difference: 5}
+
+	asAdmin := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "admin_user", utils.AdminRole, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "CreateOK",
+			method:    http.MethodPost,
+			url:       "/reconciliations",
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateReconciliationRunTx(gomock.Any(), gomock.Eq("admin_user")).
+					Times(1).
+					Return(sqlc.ReconciliationRun{ID: run.ID, TriggeredBy: run.TriggeredBy}, nil)
+				store.EXPECT().
+					ListAccountBalanceSums(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]sqlc.ListAccountBalanceSumsRow{
+						{AccountID: 8, Balance: 10, EntriesSum: 10},
+						{AccountID: 9, Balance: 20, EntriesSum: 15},
+					}, nil)
+				store.EXPECT().
+					CreateReconciliationDiscrepancyTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(discrepancy, nil)
+				store.EXPECT().
+					FinishReconciliationRunTx(gomock.Any(), gomock.Eq(sqlc.FinishReconciliationRunParams{ID: run.ID, AccountsChecked: 2, Discrepancies: 1})).
+					Times(1).
+					Return(run, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatchReport(t, recorder, reconcile.Report{Run: run, Discrepancies: []sqlc.ReconciliationDiscrepancy{discrepancy}})
+			},
+		},
+		{
+			name:   "CreateBanker",
+			method: http.MethodPost,
+			url:    "/reconciliations",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker_user", utils.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateReconciliationRunTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "CreateInternalError",
+			method:    http.MethodPost,
+			url:       "/reconciliations",
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateReconciliationRunTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.ReconciliationRun{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "ListOK",
+			method:    http.MethodGet,
+			url:       "/reconciliations?page_id=2&page_size=5",
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListReconciliationRuns(gomock.Any(), gomock.Eq(sqlc.ListReconciliationRunsParams{Limit: 5, Offset: 5})).
+					Times(1).
+					Return([]sqlc.ReconciliationRun{run}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotRuns []sqlc.ReconciliationRun
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotRuns)
+				require.NoError(t, err)
+				require.Equal(t, []sqlc.ReconciliationRun{run}, gotRuns)
+			},
+		},
+		{
+			name:      "ListInvalidPageSize",
+			method:    http.MethodGet,
+			url:       "/reconciliations?page_id=1&page_size=1000",
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListReconciliationRuns(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "GetOK",
+			method:    http.MethodGet,
+			url:       "/reconciliations/4",
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).
+					Times(1).
+					Return(run, nil)
+				store.EXPECT().
+					ListReconciliationDiscrepancies(gomock.Any(), gomock.Eq(run.ID)).
+					Times(1).
+					Return([]sqlc.ReconciliationDiscrepancy{discrepancy}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchReport(t, recorder, reconcile.Report{Run: run, Discrepancies: []sqlc.ReconciliationDiscrepancy{discrepancy}})
+			},
+		},
+		{
+			name:      "GetNotFound",
+			method:    http.MethodGet,
+			url:       "/reconciliations/4",
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).
+					Times(1).
+					Return(sqlc.ReconciliationRun{}, db.ErrNotFound)
+				store.EXPECT().
+					ListReconciliationDiscrepancies(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "GetInvalidID",
+			method:    http.MethodGet,
+			url:       "/reconciliations/0",
+			setupAuth: asAdmin,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetReconciliationRun(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(tc.method, tc.url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchReport(t *testing.T, recorder *httptest.ResponseRecorder, report reconcile.Report) {
+	var gotReport reconcile.Report
+	err := json.Unmarshal(recorder.Body.Bytes(), &gotReport)
+	require.NoError(t, err)
+	require.Equal(t, report, gotReport)
+}
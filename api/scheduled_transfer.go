@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/schedule"
+	"github.com/suryansh74/simplebank/token"
+)
+
+// createScheduledTransferRequest runs once at start_at, or recurs from start_at
+// on a cron expression or every interval_seconds
+type createScheduledTransferRequest struct {
+	FromAccountID   int64     `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID     int64     `json:"to_account_id" binding:"required,min=1,nefield=FromAccountID"`
+	Amount          int64     `json:"amount" binding:"required,gt=0"`
+	Currency        string    `json:"currency" binding:"required,currency"`
+	StartAt         time.Time `json:"start_at" binding:"required"`
+	Cron            string    `json:"cron" binding:"omitempty,excluded_with=IntervalSeconds"`
+	IntervalSeconds int64     `json:"interval_seconds" binding:"omitempty,min=60"`
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var recurrence schedule.Recurrence
+	switch {
+	case req.Cron != "":
+		cron, err := schedule.ParseCron(req.Cron)
+		if err != nil {
+			ctx.Error(statusError(http.StatusBadRequest, err))
+			return
+		}
+		recurrence = cron
+	case req.IntervalSeconds != 0:
+		recurrence = schedule.Every{Start: req.StartAt, Interval: time.Duration(req.IntervalSeconds) * time.Second}
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID)
+	if !valid {
+		return
+	}
+
+	if fromAccount.Currency != req.Currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", fromAccount.ID, fromAccount.Currency, req.Currency)
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := errors.New("from account doesn't belong to the authenticated user")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	toAccount, valid := server.validAccount(ctx, req.ToAccountID)
+	if !valid {
+		return
+	}
+
+	if toAccount.Currency != fromAccount.Currency {
+		err := fmt.Errorf("scheduled transfers need both accounts in the same currency: %s vs %s", fromAccount.Currency, toAccount.Currency)
+		ctx.Error(statusError(http.StatusUnprocessableEntity, err))
+		return
+	}
+
+	transfer, err := server.store.CreateScheduledTransferTx(ctx, sqlc.CreateScheduledTransferParams{
+		Owner:           authPayload.Username,
+		FromAccountID:   req.FromAccountID,
+		ToAccountID:     req.ToAccountID,
+		Amount:          req.Amount,
+		StartAt:         pgtype.Timestamptz{Time: req.StartAt, Valid: true},
+		Cron:            optionalText(req.Cron),
+		IntervalSeconds: optionalInt8(req.IntervalSeconds),
+		NextRunAt:       pgtype.Timestamptz{Time: schedule.FirstRun(req.StartAt, recurrence), Valid: true},
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, transfer)
+}
+
+type getScheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	transfer, ok := server.ownedScheduledTransfer(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	transfers, err := server.store.ListScheduledTransfers(ctx, sqlc.ListScheduledTransfersParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
+
+// updateScheduledTransferRequest pauses or resumes a scheduled transfer, or changes its amount
+type updateScheduledTransferRequest struct {
+	Amount int64  `json:"amount" binding:"omitempty,gt=0"`
+	Status string `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+func (server *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	server.changeScheduledTransfer(ctx, db.UpdateScheduledTransferTxParams{
+		ID:     uri.ID,
+		Amount: req.Amount,
+		Status: sqlc.ScheduledTransferStatus(req.Status),
+	})
+}
+
+// cancelScheduledTransfer stops a scheduled transfer for good, its run history is kept
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	server.changeScheduledTransfer(ctx, db.UpdateScheduledTransferTxParams{
+		ID:     uri.ID,
+		Status: sqlc.ScheduledTransferStatusCancelled,
+	})
+}
+
+func (server *Server) changeScheduledTransfer(ctx *gin.Context, arg db.UpdateScheduledTransferTxParams) {
+	if _, ok := server.ownedScheduledTransfer(ctx, arg.ID); !ok {
+		return
+	}
+
+	transfer, err := server.store.UpdateScheduledTransferTx(ctx, arg)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+}
+
+type listScheduledTransferRunsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+func (server *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req listScheduledTransferRunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	if _, ok := server.ownedScheduledTransfer(ctx, uri.ID); !ok {
+		return
+	}
+
+	runs, err := server.store.ListScheduledTransferRuns(ctx, sqlc.ListScheduledTransferRunsParams{
+		ScheduledTransferID: uri.ID,
+		Limit:               req.PageSize,
+		Offset:              (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+}
+
+// ownedScheduledTransfer loads a scheduled transfer of the authenticated user,
+// reporting the error itself when there is none
+func (server *Server) ownedScheduledTransfer(ctx *gin.Context, id int64) (sqlc.ScheduledTransfer, bool) {
+	transfer, err := server.store.GetScheduledTransfer(ctx, id)
+	if err != nil {
+		ctx.Error(err)
+		return transfer, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if transfer.Owner != authPayload.Username {
+		err := errors.New("scheduled transfer doesn't belong to the authenticated user")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return transfer, false
+	}
+
+	return transfer, true
+}
@@ -0,0 +1,476 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account3 := randomAccount(user2.Username)
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+	account3.Currency = utils.EUR
+
+	amount := int64(10)
+	startAt := time.Date(2030, time.January, 1, 8, 30, 0, 0, time.UTC)
+
+	asUser1 := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OnceOK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"start_at":        startAt,
+			},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := sqlc.CreateScheduledTransferParams{
+					Owner:         user1.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					StartAt:       pgtype.Timestamptz{Time: startAt, Valid: true},
+					NextRunAt:     pgtype.Timestamptz{Time: startAt, Valid: true},
+				}
+				store.EXPECT().
+					CreateScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(sqlc.ScheduledTransfer{ID: 1, Owner: user1.Username}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "CronOK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"start_at":        startAt,
+				"cron":            "0 9 1 * *",
+			},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				// the first 9:00 on the 1st at or after start_at
+				firstRun := time.Date(2030, time.January, 1, 9, 0, 0, 0, time.UTC)
+				arg := sqlc.CreateScheduledTransferParams{
+					Owner:         user1.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+					StartAt:       pgtype.Timestamptz{Time: startAt, Valid: true},
+					Cron:          pgtype.Text{String: "0 9 1 * *", Valid: true},
+					NextRunAt:     pgtype.Timestamptz{Time: firstRun, Valid: true},
+				}
+				store.EXPECT().
+					CreateScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(sqlc.ScheduledTransfer{ID: 1, Owner: user1.Username}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCron",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"start_at":        startAt,
+				"cron":            "0 9 30 2 *",
+			},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CronAndInterval",
+			body: gin.H{
+				"from_account_id":  account1.ID,
+				"to_account_id":    account2.ID,
+				"amount":           amount,
+				"currency":         utils.USD,
+				"start_at":         startAt,
+				"cron":             "0 9 1 * *",
+				"interval_seconds": 3600,
+			},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "IntervalTooShort",
+			body: gin.H{
+				"from_account_id":  account1.ID,
+				"to_account_id":    account2.ID,
+				"amount":           amount,
+				"currency":         utils.USD,
+				"start_at":         startAt,
+				"interval_seconds": 59,
+			},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"start_at":        startAt,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CrossCurrency",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"start_at":        startAt,
+			},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().CreateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "SameAccount",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account1.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"start_at":        startAt,
+			},
+			setupAuth: asUser1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/scheduled-transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	transfer := sqlc.ScheduledTransfer{
+		ID:            utils.RandomInt(1, 1000),
+		Owner:         user.Username,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        10,
+		Status:        sqlc.ScheduledTransferStatusActive,
+	}
+	paused := transfer
+	paused.Status = sqlc.ScheduledTransferStatusPaused
+
+	asOwner := func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, utils.DepositorRole, time.Minute)
+	}
+	path := fmt.Sprintf("/scheduled-transfers/%d", transfer.ID)
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "GetOK",
+			method:    http.MethodGet,
+			url:       path,
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchScheduledTransfer(t, recorder.Body, transfer)
+			},
+		},
+		{
+			name:      "GetNotFound",
+			method:    http.MethodGet,
+			url:       path,
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(sqlc.ScheduledTransfer{}, db.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "GetOtherOwner",
+			method: http.MethodGet,
+			url:    path,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, other.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "ListOK",
+			method:    http.MethodGet,
+			url:       "/scheduled-transfers?page_id=1&page_size=5",
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				arg := sqlc.ListScheduledTransfersParams{Owner: user.Username, Limit: 5, Offset: 0}
+				store.EXPECT().ListScheduledTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]sqlc.ScheduledTransfer{transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "PauseOK",
+			method:    http.MethodPatch,
+			url:       path,
+			body:      gin.H{"status": "paused"},
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+
+				arg := db.UpdateScheduledTransferTxParams{ID: transfer.ID, Status: sqlc.ScheduledTransferStatusPaused}
+				store.EXPECT().UpdateScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(paused, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchScheduledTransfer(t, recorder.Body, paused)
+			},
+		},
+		{
+			name:      "UpdateInvalidStatus",
+			method:    http.MethodPatch,
+			url:       path,
+			body:      gin.H{"status": "completed"},
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "UpdateEnded",
+			method:    http.MethodPatch,
+			url:       path,
+			body:      gin.H{"amount": 20},
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().
+					UpdateScheduledTransferTx(gomock.Any(), gomock.Eq(db.UpdateScheduledTransferTxParams{ID: transfer.ID, Amount: 20})).
+					Times(1).
+					Return(sqlc.ScheduledTransfer{}, db.ErrScheduledTransferEnded)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "CancelOK",
+			method:    http.MethodDelete,
+			url:       path,
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+
+				arg := db.UpdateScheduledTransferTxParams{ID: transfer.ID, Status: sqlc.ScheduledTransferStatusCancelled}
+				store.EXPECT().UpdateScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "CancelInternalError",
+			method:    http.MethodDelete,
+			url:       path,
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().UpdateScheduledTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(sqlc.ScheduledTransfer{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "ListRunsOK",
+			method:    http.MethodGet,
+			url:       path + "/runs?page_id=2&page_size=5",
+			setupAuth: asOwner,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+
+				arg := sqlc.ListScheduledTransferRunsParams{ScheduledTransferID: transfer.ID, Limit: 5, Offset: 5}
+				runs := []sqlc.ScheduledTransferRun{{ID: 1, ScheduledTransferID: transfer.ID, Attempt: 1}}
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Eq(arg)).Times(1).Return(runs, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			method:    http.MethodGet,
+			url:       path,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				err := json.NewEncoder(&body).Encode(tc.body)
+				require.NoError(t, err)
+			}
+
+			request, err := http.NewRequest(tc.method, tc.url, &body)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireBodyMatchScheduledTransfer(t *testing.T, body *bytes.Buffer, transfer sqlc.ScheduledTransfer) {
+	var gotTransfer sqlc.ScheduledTransfer
+	err := json.Unmarshal(body.Bytes(), &gotTransfer)
+	require.NoError(t, err)
+	require.Equal(t, transfer, gotTransfer)
+}
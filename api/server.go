@@ -2,32 +2,82 @@ package api
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/suryansh74/simplebank/currency"
 	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/exchange"
+	"github.com/suryansh74/simplebank/reconcile"
 	"github.com/suryansh74/simplebank/token"
 	"github.com/suryansh74/simplebank/utils"
 )
 
+// memoryRevokerCapacity bounds the in-memory revocation store, tokens past it are forgotten oldest first
+const memoryRevokerCapacity = 10000
+
 type Server struct {
 	config     utils.Config
 	store      db.Store
 	tokenMaker token.Maker
+	revoker    token.Revoker
+	rates      exchange.RateProvider
+	currencies *currency.Registry
+	reconciler *reconcile.Reconciler
 	router     *gin.Engine
 }
 
 func NewServer(config utils.Config, store db.Store) (*Server, error) {
 	// initzile token maker
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	tokenMaker, err := token.NewMaker(token.MakerConfig{
+		Type:         config.TokenType,
+		SymmetricKey: config.TokenSymmetricKey,
+		PrivateKey:   config.TokenPrivateKey,
+		KeyID:        config.TokenKeyID,
+		KeysDir:      config.TokenKeysDir,
+		Claims: token.Claims{
+			Issuer:   config.TokenIssuer,
+			Audience: config.TokenAudience,
+		},
+	})
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w ", err)
 	}
+
+	revoker, err := newRevoker(config.TokenRevocationStore, store)
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := exchange.NewFileProvider(config.ExchangeRatesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	currencies, err := currency.NewRegistry(context.Background(), store)
+	if err != nil {
+		return nil, err
+	}
+
 	server := &Server{
 		config:     config,
 		store:      store,
 		tokenMaker: tokenMaker,
+		revoker:    revoker,
+		rates:      rates,
+		currencies: currencies,
+		reconciler: reconcile.NewReconciler(store),
+	}
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		err = registerValidations(v, currencies)
+		if err != nil {
+			return nil, fmt.Errorf("cannot register validations: %w", err)
+		}
 	}
 
 	server.setupRoutes()
@@ -35,25 +85,85 @@ func NewServer(config utils.Config, store db.Store) (*Server, error) {
 	return server, nil
 }
 
+func newRevoker(kind string, store db.Store) (token.Revoker, error) {
+	switch kind {
+	case "", "postgres":
+		return db.NewRevoker(store), nil
+	case "memory":
+		return token.NewMemoryRevoker(memoryRevokerCapacity), nil
+	default:
+		return nil, fmt.Errorf("unknown token revocation store %q", kind)
+	}
+}
+
 func (server *Server) Start(address string) error {
 	return server.router.Run(address)
 }
 
 func (server *Server) setupRoutes() {
 	router := gin.Default()
+	// lets the store read request metadata from the gin context
+	router.ContextWithFallback = true
+	router.Use(requestMetadataMiddleware(), errorMiddleware())
 
 	// public routes
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+	router.GET("/.well-known/jwks.json", server.getJWKS)
+	router.GET("/.well-known/openid-configuration", server.getOpenIDConfiguration)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revoker, server.store))
+	idempotent := idempotencyMiddleware(server.store, server.config.IdempotencyKeyTTL)
 
 	// private route
-	authRoutes.POST("/accounts", server.createAccount)
-	authRoutes.GET("/accounts/:id", server.getAccount)
-	authRoutes.GET("/accounts", server.listAccount)
-
-	authRoutes.POST("/transfers", server.createTransfer)
+	// any token can log itself out whatever its scopes, logging out only takes access away
+	authRoutes.POST("/users/logout", server.logoutUser)
+	// scoped tokens cannot manage sessions or read the debug counters
+	authRoutes.POST("/users/:username/revoke_sessions", requireScope(), server.revokeUserSessions)
+
+	// API keys can't manage API keys, they always have scopes
+	authRoutes.POST("/api-keys", requireScope(), server.createAPIKey)
+	authRoutes.GET("/api-keys", requireScope(), server.listAPIKeys)
+	authRoutes.DELETE("/api-keys/:id", requireScope(), server.revokeAPIKey)
+
+	authRoutes.POST("/accounts", requireScope(utils.AccountsWriteScope), idempotent, server.createAccount)
+	authRoutes.GET("/accounts/:id", requireScope(utils.AccountsReadScope), server.getAccount)
+	authRoutes.GET("/accounts", requireScope(utils.AccountsReadScope), server.listAccount)
+	authRoutes.GET("/accounts/:id/entries", requireScope(utils.AccountsReadScope), server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/transfers", requireScope(utils.AccountsReadScope), server.listAccountTransfers)
+	authRoutes.POST("/accounts/:id/deposits", requireRole(utils.BankerRole, utils.AdminRole), requireScope(utils.AccountsWriteScope), idempotent, server.createDeposit)
+	// withdrawals and captures move money out of an account like transfers do
+	authRoutes.POST("/accounts/:id/withdrawals", requireScope(utils.TransfersWriteScope), idempotent, server.createWithdrawal)
+	authRoutes.POST("/accounts/:id/freeze", requireRole(utils.AdminRole), requireScope(utils.AccountsWriteScope), server.freezeAccount)
+	authRoutes.POST("/accounts/:id/unfreeze", requireRole(utils.AdminRole), requireScope(utils.AccountsWriteScope), server.unfreezeAccount)
+	authRoutes.POST("/accounts/:id/close", requireScope(utils.AccountsWriteScope), server.closeAccount)
+	authRoutes.GET("/accounts/:id/status_changes", requireScope(utils.AccountsReadScope), server.listAccountStatusChanges)
+	authRoutes.POST("/accounts/:id/holds", requireScope(utils.AccountsWriteScope), idempotent, server.placeHold)
+	authRoutes.GET("/accounts/:id/holds", requireScope(utils.AccountsReadScope), server.listAccountHolds)
+
+	authRoutes.GET("/holds/:id", requireScope(utils.AccountsReadScope), server.getHold)
+	authRoutes.POST("/holds/:id/capture", requireScope(utils.TransfersWriteScope), idempotent, server.captureHold)
+	authRoutes.POST("/holds/:id/release", requireScope(utils.AccountsWriteScope), server.releaseHold)
+
+	authRoutes.POST("/transfers", requireScope(utils.TransfersWriteScope), idempotent, server.createTransfer)
+	authRoutes.POST("/transfers/batch", requireScope(utils.TransfersWriteScope), idempotent, server.createBatchTransfer)
+	authRoutes.POST("/transfers/:id/reverse", requireScope(utils.TransfersWriteScope), idempotent, server.reverseTransfer)
+
+	authRoutes.POST("/scheduled-transfers", requireScope(utils.TransfersWriteScope), idempotent, server.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", requireScope(utils.AccountsReadScope), server.listScheduledTransfers)
+	authRoutes.GET("/scheduled-transfers/:id", requireScope(utils.AccountsReadScope), server.getScheduledTransfer)
+	authRoutes.PATCH("/scheduled-transfers/:id", requireScope(utils.TransfersWriteScope), server.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled-transfers/:id", requireScope(utils.TransfersWriteScope), server.cancelScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id/runs", requireScope(utils.AccountsReadScope), server.listScheduledTransferRuns)
+
+	authRoutes.GET("/audit", requireRole(utils.AdminRole), requireScope(utils.AuditReadScope), server.listAuditEvents)
+	authRoutes.POST("/reconciliations", requireRole(utils.AdminRole), requireScope(utils.ReconciliationsWriteScope), server.createReconciliation)
+	authRoutes.GET("/reconciliations", requireRole(utils.AdminRole), requireScope(utils.ReconciliationsReadScope), server.listReconciliations)
+	authRoutes.GET("/reconciliations/:id", requireRole(utils.AdminRole), requireScope(utils.ReconciliationsReadScope), server.getReconciliation)
+
+	// expvar counters such as db_tx_retries
+	authRoutes.GET("/debug/vars", requireRole(utils.AdminRole), requireScope(), gin.WrapH(expvar.Handler()))
 
 	server.router = router
 }
@@ -71,9 +181,3 @@ func (server *Server) StartWithShutdown(ctx context.Context, address string) err
 
 	return srv.ListenAndServe()
 }
-
-func errorResponse(err error) gin.H {
-	return gin.H{
-		"error": err.Error(),
-	}
-}
@@ -2,13 +2,16 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/utils"
 )
 
 func TestServerStart(t *testing.T) {
@@ -69,3 +72,49 @@ func TestServerStartWithoutShutdown(t *testing.T) {
 	// Note: This test doesn't achieve full coverage because Start() never returns
 	// unless there's an error or external shutdown
 }
+
+func TestDebugVarsAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		role          string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Admin",
+			role: utils.AdminRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var vars map[string]json.RawMessage
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &vars))
+				require.Contains(t, vars, "db_tx_retries")
+			},
+		},
+		{
+			name: "Depositor",
+			role: utils.DepositorRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mock.NewMockStore(ctrl))
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/debug/vars", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "someone", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
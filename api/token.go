@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/suryansh74/simplebank/token"
+)
+
+var errNotRefreshToken = errors.New("token is not a refresh token")
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req renewAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+	if refreshPayload.Use != token.RefreshToken {
+		ctx.Error(statusError(http.StatusUnauthorized, errNotRefreshToken))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	if session.IsBlocked {
+		err := errors.New("blocked session")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		err := errors.New("incorrect session user")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		err := errors.New("mismatched session token")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt.Time) {
+		err := errors.New("expired session")
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	// the role is read again, a user promoted or demoted since logging in gets the new one
+	user, err := server.store.GetUser(ctx, session.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, string(user.Role), token.AccessToken, session.ID, server.config.AccessTokenDuration, refreshPayload.Scopes...)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	rsp := renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	// promoted after the refresh tokens of the test cases were issued as depositor
+	user, _ := randomUser(t)
+	user.Role = sqlc.UserRoleBanker
+
+	testCases := []struct {
+		name     string
+		duration time.Duration
+		// use of the token sent for renewal, a refresh token when empty
+		use           token.TokenUse
+		buildSession  func(refreshToken string, payload *token.Payload) sqlc.Session
+		getSessionErr error
+		// getUserErr fails loading the user once the session is valid
+		getUserErr    error
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name:     "OK",
+			duration: time.Hour,
+			buildSession: func(refreshToken string, payload *token.Payload) sqlc.Session {
+				return randomSession(user.Username, refreshToken, payload)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.WithinDuration(t, time.Now().Add(time.Minute), rsp.AccessTokenExpiresAt, time.Second)
+
+				payload, err := tokenMaker.VerifyToken(rsp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+				require.Equal(t, string(user.Role), payload.Role)
+				require.Equal(t, token.AccessToken, payload.Use)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			duration: time.Hour,
+			buildSession: func(refreshToken string, payload *token.Payload) sqlc.Session {
+				return randomSession(user.Username, refreshToken, payload)
+			},
+			getUserErr: db.ErrNotFound,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "ExpiredRefreshToken",
+			duration: -time.Minute,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "AccessToken",
+			duration: time.Hour,
+			use:      token.AccessToken,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:          "SessionNotFound",
+			duration:      time.Hour,
+			getSessionErr: db.ErrNotFound,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:          "InternalError",
+			duration:      time.Hour,
+			getSessionErr: sql.ErrConnDone,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:     "BlockedSession",
+			duration: time.Hour,
+			buildSession: func(refreshToken string, payload *token.Payload) sqlc.Session {
+				session := randomSession(user.Username, refreshToken, payload)
+				session.IsBlocked = true
+				return session
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "IncorrectSessionUser",
+			duration: time.Hour,
+			buildSession: func(refreshToken string, payload *token.Payload) sqlc.Session {
+				return randomSession("someone_else", refreshToken, payload)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "MismatchedSessionToken",
+			duration: time.Hour,
+			buildSession: func(refreshToken string, payload *token.Payload) sqlc.Session {
+				return randomSession(user.Username, "another_token", payload)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "ExpiredSession",
+			duration: time.Hour,
+			buildSession: func(refreshToken string, payload *token.Payload) sqlc.Session {
+				session := randomSession(user.Username, refreshToken, payload)
+				session.ExpiresAt = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+				return session
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			use := tc.use
+			if use == "" {
+				use = token.RefreshToken
+			}
+			refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, utils.DepositorRole, use, uuid.Nil, tc.duration)
+			require.NoError(t, err)
+
+			// only a session that passes every check loads the user
+			getUserCalls := 0
+			switch {
+			case tc.duration < 0 || use != token.RefreshToken:
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			case tc.getSessionErr != nil:
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(refreshPayload.ID)).
+					Times(1).
+					Return(sqlc.Session{}, tc.getSessionErr)
+			default:
+				session := tc.buildSession(refreshToken, refreshPayload)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(refreshPayload.ID)).
+					Times(1).
+					Return(session, nil)
+				if !session.IsBlocked && session.Username == user.Username &&
+					session.RefreshToken == refreshToken && time.Now().Before(session.ExpiresAt.Time) {
+					getUserCalls = 1
+				}
+			}
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(user.Username)).
+				Times(getUserCalls).
+				Return(user, tc.getUserErr)
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.tokenMaker)
+		})
+	}
+}
+
+func randomSession(username string, refreshToken string, payload *token.Payload) sqlc.Session {
+	return sqlc.Session{
+		ID:           payload.ID,
+		Username:     username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    pgtype.Timestamptz{Time: payload.ExpiredAt, Valid: true},
+	}
+}
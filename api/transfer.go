@@ -1,22 +1,33 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	"github.com/suryansh74/simplebank/db"
 	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
 	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
 )
 
+// transferTxOptions runs the transactions that move money between accounts, their row locks
+// taken in account id order keep them consistent at read committed
+var transferTxOptions = db.TxOptions{
+	IsoLevel:    pgx.ReadCommitted,
+	MaxAttempts: db.DefaultMaxAttempts,
+}
+
+// transferRequest is in the currency of the from account, when the to account
+// holds another currency the amount is converted at the current exchange rate
 type transferRequest struct {
-	FromAccountID int64         `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64         `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64         `json:"amount" binding:"required,gt=0"`
-	Currency      sqlc.Currency `json:"currency" binding:"required,oneof=USD EUR"`
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
 }
 
 func (server *Server) createTransfer(context *gin.Context) {
@@ -24,24 +35,29 @@ func (server *Server) createTransfer(context *gin.Context) {
 	var req transferRequest
 	err := context.ShouldBindJSON(&req)
 	if err != nil {
-		context.JSON(http.StatusBadRequest, errorResponse(err))
+		context.Error(statusError(http.StatusBadRequest, err))
 		return
 	}
 
-	fromAccount, valid := server.validAccount(context, req.FromAccountID, req.Currency)
-	// TODO: currency should be same From and To account
+	fromAccount, valid := server.validAccount(context, req.FromAccountID)
 	if !valid {
 		return
 	}
 
+	if fromAccount.Currency != req.Currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", fromAccount.ID, fromAccount.Currency, req.Currency)
+		context.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
 	authPayload := context.MustGet(authorizationPayloadKey).(*token.Payload)
 	if fromAccount.Owner != authPayload.Username {
 		err := errors.New("from account doesn't belong to the authenticated user")
-		context.JSON(http.StatusUnauthorized, errorResponse(err))
+		context.Error(statusError(http.StatusUnauthorized, err))
 		return
 	}
 
-	_, valid = server.validAccount(context, req.ToAccountID, req.Currency)
+	toAccount, valid := server.validAccount(context, req.ToAccountID)
 	if !valid {
 		return
 	}
@@ -52,31 +68,114 @@ func (server *Server) createTransfer(context *gin.Context) {
 		Amount:        req.Amount,
 	}
 
-	transfer, err := server.store.TransferTx(context, args)
+	if toAccount.Currency != fromAccount.Currency {
+		rate, valid := server.exchangeRate(context, fromAccount.Currency, toAccount.Currency)
+		if !valid {
+			return
+		}
+		args.Rate = &rate
+	}
+
+	transfer, err := server.store.TransferTx(db.WithTxOptions(context, transferTxOptions), args)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		context.Error(err)
 		return
 	}
 
 	context.JSON(http.StatusCreated, transfer)
 }
 
-func (server *Server) validAccount(context *gin.Context, accountID int64, currency sqlc.Currency) (sqlc.Account, bool) {
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type reverseTransferRequest struct {
+	// Amount is in the currency the recipient received, leave it out to reverse whatever is left
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// reverseTransfer gives money back to the sender of a transfer, only its recipient or an admin may do it
+func (server *Server) reverseTransfer(context *gin.Context) {
+	var uri getTransferRequest
+	if err := context.ShouldBindUri(&uri); err != nil {
+		context.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	var req reverseTransferRequest
+	if err := context.ShouldBindJSON(&req); err != nil {
+		context.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(context, uri.ID)
+	if err != nil {
+		context.Error(err)
+		return
+	}
+
+	authPayload := context.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !hasRole(authPayload, utils.AdminRole) {
+		recipient, err := server.store.GetAccount(context, transfer.ToAccountID)
+		if err != nil {
+			context.Error(err)
+			return
+		}
+		if recipient.Owner != authPayload.Username {
+			err := errors.New("only the recipient of a transfer can reverse it")
+			context.Error(statusError(http.StatusUnauthorized, err))
+			return
+		}
+	}
+
+	result, err := server.store.ReverseTransferTx(db.WithTxOptions(context, transferTxOptions), db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     req.Amount,
+	})
+	if err != nil {
+		context.Error(err)
+		return
+	}
+
+	context.JSON(http.StatusCreated, result)
+}
+
+func (server *Server) validAccount(context *gin.Context, accountID int64) (sqlc.Account, bool) {
 	// check wheater account is exist or not by id
 	account, err := server.store.GetAccount(context, accountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			context.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
-		}
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		context.Error(err)
 		return account, false
 	}
-	// check currency is same as given
-	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
-		context.JSON(http.StatusBadRequest, errorResponse(err))
+	if account.Status != sqlc.AccountStatusActive {
+		context.Error(&db.AccountNotActiveError{AccountID: accountID, Status: account.Status})
 		return account, false
 	}
 	return account, true
 }
+
+// exchangeRate looks up the rate between two currencies, adapted to amounts in minor units
+func (server *Server) exchangeRate(context *gin.Context, from string, to string) (exchange.Rate, bool) {
+	rate, err := server.lookupRate(context, from, to)
+	if err != nil {
+		context.Error(err)
+		return rate, false
+	}
+	return rate, true
+}
+
+// lookupRate is exchangeRate for callers that report the error themselves
+func (server *Server) lookupRate(context *gin.Context, from string, to string) (exchange.Rate, error) {
+	if !server.currencies.IsSupported(to) {
+		return exchange.Rate{}, statusError(http.StatusUnprocessableEntity, fmt.Errorf("currency %s is not supported", to))
+	}
+
+	rate, err := server.rates.Rate(context, from, to)
+	if err != nil {
+		return rate, err
+	}
+
+	fromCurrency, _ := server.currencies.Get(from)
+	toCurrency, _ := server.currencies.Get(to)
+	return rate.ForMinorUnits(fromCurrency.Exponent, toCurrency.Exponent), nil
+}
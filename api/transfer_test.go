@@ -2,8 +2,10 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -16,9 +18,29 @@ import (
 	"github.com/suryansh74/simplebank/db"
 	"github.com/suryansh74/simplebank/db/mock"
 	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
 	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
 )
 
+// eqTxOptionsMatcher matches a context carrying the transaction options an operation runs with
+type eqTxOptionsMatcher struct {
+	options db.TxOptions
+}
+
+func (e eqTxOptionsMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && db.TxOptionsFrom(ctx) == e.options
+}
+
+func (e eqTxOptionsMatcher) String() string {
+	return fmt.Sprintf("is a context with tx options %+v", e.options)
+}
+
+func EqTxOptions(options db.TxOptions) gomock.Matcher {
+	return eqTxOptionsMatcher{options}
+}
+
 func TestCreateTransferAPI(t *testing.T) {
 	user1, _ := randomUser(t)
 	user2, _ := randomUser(t)
@@ -28,10 +50,16 @@ func TestCreateTransferAPI(t *testing.T) {
 	account1 := randomAccount(user1.Username)
 	account2 := randomAccount(user2.Username)
 	account3 := randomAccount(user3.Username)
+	account4 := randomAccount(user3.Username)
+	account5 := randomAccount(user3.Username)
+	account6 := randomAccount(user3.Username)
 
-	account1.Currency = sqlc.CurrencyUSD
-	account2.Currency = sqlc.CurrencyUSD
-	account3.Currency = sqlc.CurrencyEUR
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+	account3.Currency = utils.EUR
+	account4.Currency = utils.GBP
+	account5.Currency = "JPY"
+	account6.Currency = "VEF"
 
 	testCases := []struct {
 		name          string
@@ -46,10 +74,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -69,7 +97,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				}
 
 				store.EXPECT().
-					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					TransferTx(EqTxOptions(transferTxOptions), gomock.Eq(arg)).
 					Times(1).
 					Return(db.TransferTxResult{}, nil)
 			},
@@ -83,7 +111,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				// Don't add authorization
@@ -107,10 +135,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -136,16 +164,16 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
 					Times(1).
-					Return(sqlc.Account{}, sql.ErrNoRows)
+					Return(sqlc.Account{}, db.ErrNotFound)
 
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
@@ -159,16 +187,75 @@ func TestCreateTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
+		{
+			name: "FromAccountFrozen",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				frozen := account1
+				frozen.Status = sqlc.AccountStatusFrozen
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(frozen, nil)
+
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountClosed",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				closed := account2
+				closed.Status = sqlc.AccountStatusClosed
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(1).
+					Return(closed, nil)
+
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name: "ToAccountNotFound",
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -179,7 +266,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				store.EXPECT().
 					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
 					Times(1).
-					Return(sqlc.Account{}, sql.ErrNoRows)
+					Return(sqlc.Account{}, db.ErrNotFound)
 
 				store.EXPECT().
 					TransferTx(gomock.Any(), gomock.Any()).
@@ -195,10 +282,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account3.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user3.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -219,15 +306,15 @@ func TestCreateTransferAPI(t *testing.T) {
 			},
 		},
 		{
-			name: "ToAccountCurrencyMismatch",
+			name: "CrossCurrency",
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account3.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -240,10 +327,154 @@ func TestCreateTransferAPI(t *testing.T) {
 					Times(1).
 					Return(account3, nil)
 
+				rate, err := newTestRates(t).Rate(context.Background(), utils.USD, utils.EUR)
+				require.NoError(t, err)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account3.ID,
+					Amount:        amount,
+					Rate:          &rate,
+				}
+
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "NoExchangeRate",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account4.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account4.ID)).
+					Times(1).
+					Return(account4, nil)
+
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "CrossCurrencyMinorUnits",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account5.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account5.ID)).
+					Times(1).
+					Return(account5, nil)
+
+				// 150 JPY per USD is 1.5 yen per cent, JPY has no minor units
+				rate, err := exchange.ParseRate(utils.USD, "JPY", "1.5")
+				require.NoError(t, err)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account5.ID,
+					Amount:        amount,
+					Rate:          &rate,
+				}
+
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name: "ToAccountCurrencyDisabled",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account6.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account6.ID)).
+					Times(1).
+					Return(account6, nil)
+
 				store.EXPECT().
 					TransferTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "AmountTooSmallToConvert",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          1,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account3.ID)).
+					Times(1).
+					Return(account3, nil)
+
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, exchange.ErrAmountTooSmall)
+			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
@@ -257,7 +488,7 @@ func TestCreateTransferAPI(t *testing.T) {
 				"currency":        "XYZ",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -278,10 +509,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          -amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -302,10 +533,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          0,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -326,10 +557,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": 0,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -350,10 +581,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   0,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -374,10 +605,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -403,10 +634,10 @@ func TestCreateTransferAPI(t *testing.T) {
 				"from_account_id": account1.ID,
 				"to_account_id":   account2.ID,
 				"amount":          amount,
-				"currency":        sqlc.CurrencyUSD,
+				"currency":        utils.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -428,11 +659,49 @@ func TestCreateTransferAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(account1, nil)
+
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account2.ID)).
+					Times(1).
+					Return(account2, nil)
+
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.InsufficientFundsError{
+						AccountID: account1.ID,
+						Amount:    amount,
+						Available: amount - 1,
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				rsp := requireErrorResponse(t, recorder, "insufficient_funds")
+				require.Equal(t, float64(amount-1), rsp.Details["available_balance"])
+			},
+		},
 		{
 			name: "MissingFields",
 			body: gin.H{},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, utils.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
@@ -486,3 +755,187 @@ func requireBodyMatchTransfer(t *testing.T, body *bytes.Buffer, transfer db.Tran
 	require.NoError(t, err)
 	require.Equal(t, transfer, gotTransfer)
 }
+
+func TestReverseTransferAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	fromAccount := randomAccount(sender.Username)
+	toAccount := randomAccount(recipient.Username)
+	transfer := sqlc.Transfer{
+		ID:            utils.RandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+		ToAmount:      100,
+		Kind:          sqlc.TransferKindTransfer,
+	}
+
+	asUser := func(username string, role string) func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+		return func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			addAuthorization(t, request, tokenMaker, authorizationTypeBearer, username, role, time.Minute)
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "PartialByRecipient",
+			transferID: transfer.ID,
+			body:       gin.H{"amount": 40},
+			setupAuth:  asUser(recipient.Username, utils.DepositorRole),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					ReverseTransferTx(EqTxOptions(transferTxOptions), gomock.Eq(db.ReverseTransferTxParams{TransferID: transfer.ID, Amount: 40})).
+					Times(1).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name:       "FullByAdmin",
+			transferID: transfer.ID,
+			body:       gin.H{},
+			setupAuth:  asUser("admin_user", utils.AdminRole),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					ReverseTransferTx(EqTxOptions(transferTxOptions), gomock.Eq(db.ReverseTransferTxParams{TransferID: transfer.ID})).
+					Times(1).
+					Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+		{
+			name:       "SenderCannotReverse",
+			transferID: transfer.ID,
+			body:       gin.H{},
+			setupAuth:  asUser(sender.Username, utils.DepositorRole),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "ExceedsTransfer",
+			transferID: transfer.ID,
+			body:       gin.H{"amount": 150},
+			setupAuth:  asUser(recipient.Username, utils.DepositorRole),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.ReversalExceedsTransferError{TransferID: transfer.ID, Amount: 150, Reversible: 60})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				rsp := requireErrorResponse(t, recorder, "reversal_exceeds_transfer")
+				require.Equal(t, float64(60), rsp.Details["reversible_amount"])
+			},
+		},
+		{
+			name:       "NotReversible",
+			transferID: transfer.ID,
+			body:       gin.H{},
+			setupAuth:  asUser("admin_user", utils.AdminRole),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, db.ErrTransferNotReversible)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:       "RecipientLacksFunds",
+			transferID: transfer.ID,
+			body:       gin.H{},
+			setupAuth:  asUser(recipient.Username, utils.DepositorRole),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					ReverseTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTxResult{}, &db.InsufficientFundsError{AccountID: toAccount.ID, Amount: 100, Available: 10})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:       "TransferNotFound",
+			transferID: transfer.ID,
+			body:       gin.H{},
+			setupAuth:  asUser(recipient.Username, utils.DepositorRole),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(sqlc.Transfer{}, db.ErrNotFound)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:       "InvalidAmount",
+			transferID: transfer.ID,
+			body:       gin.H{"amount": -5},
+			setupAuth:  asUser(recipient.Username, utils.DepositorRole),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/transfers/%d/reverse", tc.transferID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -2,13 +2,16 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
 	"github.com/suryansh74/simplebank/utils"
 )
 
@@ -25,6 +28,7 @@ type createUserResponse struct {
 	Email             string             `json:"email"`
 	PasswordChangedAt pgtype.Timestamptz `json:"password_changed_at"`
 	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	Role              sqlc.UserRole      `json:"role"`
 }
 
 func (server *Server) createUser(context *gin.Context) {
@@ -32,14 +36,14 @@ func (server *Server) createUser(context *gin.Context) {
 	var req createUserRequest
 	err := context.ShouldBindJSON(&req)
 	if err != nil {
-		context.JSON(http.StatusBadRequest, errorResponse(err))
+		context.Error(statusError(http.StatusBadRequest, err))
 		return
 	}
 
 	// HashedPassword
 	hashedPassword, err := utils.HashedPassword(req.Password)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		context.Error(err)
 		return
 	}
 
@@ -50,16 +54,11 @@ func (server *Server) createUser(context *gin.Context) {
 		Email:          req.Email,
 	}
 
-	user, err := server.store.CreateUser(context, args)
+	// a new user is the actor of their own sign up
+	setAuditActor(context, req.Username)
+	user, err := server.store.CreateUserTx(context, args)
 	if err != nil {
-		if pqErr, ok := err.(*pgconn.PgError); ok {
-			switch pqErr.Code {
-			case "23505": // unique_violation
-				context.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		context.JSON(http.StatusInternalServerError, errorResponse(err))
+		context.Error(err)
 		return
 	}
 
@@ -71,11 +70,17 @@ func (server *Server) createUser(context *gin.Context) {
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
+	// Scopes restrict the tokens of the session, for clients that need less than the role allows
+	Scopes []string `json:"scopes" binding:"omitempty,dive,scope"`
 }
 
 type loginUserResponse struct {
-	AccessToken string             `json:"access_token"`
-	User        createUserResponse `json:"user"`
+	SessionID             uuid.UUID          `json:"session_id"`
+	AccessToken           string             `json:"access_token"`
+	AccessTokenExpiresAt  time.Time          `json:"access_token_expires_at"`
+	RefreshToken          string             `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time          `json:"refresh_token_expires_at"`
+	User                  createUserResponse `json:"user"`
 }
 
 func newUserResponse(user sqlc.User) *createUserResponse {
@@ -85,39 +90,156 @@ func newUserResponse(user sqlc.User) *createUserResponse {
 		Email:             user.Email,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
+		Role:              user.Role,
 	}
 }
 
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		ctx.Error(statusError(http.StatusBadRequest, err))
 		return
 	}
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
 
 	err = utils.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.Error(statusError(http.StatusUnauthorized, err))
+		return
+	}
+
+	// the session is named after the refresh token, the access token belongs to it
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, string(user.Role), token.RefreshToken, uuid.Nil, server.config.RefreshTokenDuration, req.Scopes...)
+	if err != nil {
+		ctx.Error(err)
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(user.Username, server.config.AccessTokenDuration)
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, string(user.Role), token.AccessToken, refreshPayload.ID, server.config.AccessTokenDuration, req.Scopes...)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.Error(err)
 		return
 	}
+
+	// the route is public, the session is audited as created by the user logging in
+	setAuditActor(ctx, user.Username)
+	session, err := server.store.CreateSessionTx(ctx, sqlc.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    pgtype.Timestamptz{Time: refreshPayload.ExpiredAt, Valid: true},
+	})
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
 	rsp := loginUserResponse{
-		AccessToken: accessToken,
-		User:        *newUserResponse(user),
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  *newUserResponse(user),
 	}
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// errLogoutWithAPIKey is returned to API keys calling logout, there is no token to revoke
+var errLogoutWithAPIKey = errors.New("api keys cannot log out, revoke the key with DELETE /api-keys/:id")
+
+// logoutUser revokes the access token used for the call, and when the refresh token
+// is sent along its session is blocked too so it can no longer be renewed
+func (server *Server) logoutUser(ctx *gin.Context) {
+	if ctx.GetString(authorizationTypeKey) == authorizationTypeAPIKey {
+		ctx.Error(statusError(http.StatusBadRequest, errLogoutWithAPIKey))
+		return
+	}
+
+	var req logoutUserRequest
+	// body is optional
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if len(req.RefreshToken) > 0 {
+		refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+		if err != nil {
+			ctx.Error(statusError(http.StatusUnauthorized, err))
+			return
+		}
+		if refreshPayload.Use != token.RefreshToken {
+			ctx.Error(statusError(http.StatusUnauthorized, errNotRefreshToken))
+			return
+		}
+
+		if refreshPayload.Username != authPayload.Username {
+			err := errors.New("refresh token doesn't belong to authenticated user")
+			ctx.Error(statusError(http.StatusUnauthorized, err))
+			return
+		}
+
+		// a blocked session can no longer be renewed, which is all a refresh token is good for
+		_, err = server.store.BlockSessionTx(ctx, refreshPayload.ID)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+	}
+
+	err := server.revoker.Revoke(ctx, authPayload)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type revokeUserSessionsRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type revokeUserSessionsResponse struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+// revokeUserSessions blocks every live session of a user, their refresh tokens can no longer
+// be renewed and the access tokens issued for them are rejected by authMiddleware.
+// Users can do it for themselves, admins for anyone.
+func (server *Server) revokeUserSessions(ctx *gin.Context) {
+	var req revokeUserSessionsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(statusError(http.StatusBadRequest, err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Username != authPayload.Username && !hasRole(authPayload, utils.AdminRole) {
+		err := errors.New("only admins can revoke sessions of another user")
+		ctx.Error(statusError(http.StatusForbidden, err))
+		return
+	}
+
+	sessions, err := server.store.BlockUserSessionsTx(ctx, req.Username)
+	if err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revokeUserSessionsResponse{RevokedSessions: len(sessions)})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -10,13 +11,16 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
 	"github.com/suryansh74/simplebank/db/mock"
 	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
 	"github.com/suryansh74/simplebank/utils"
 )
 
@@ -77,7 +81,7 @@ func TestCreateUserAPI(t *testing.T) {
 				}
 
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).
 					Times(1).
 					Return(user, nil)
 			},
@@ -97,7 +101,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(sqlc.User{}, sql.ErrConnDone)
 			},
@@ -116,7 +120,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -134,7 +138,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -152,7 +156,24 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UsernameWithHyphen",
+			body: gin.H{
+				"username":  "invalid-user",
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -169,15 +190,15 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(sqlc.User{}, &pgconn.PgError{
-						Code:    "23505",
-						Message: "duplicate key value violates unique constraint",
+					Return(sqlc.User{}, &db.Error{
+						Kind:       db.ErrConflict,
+						Constraint: "users_pkey",
 					})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Equal(t, http.StatusConflict, recorder.Code)
 			},
 		},
 		// Empty Password (will fail binding validation before hashing)
@@ -191,7 +212,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -227,6 +248,573 @@ func TestCreateUserAPI(t *testing.T) {
 	}
 }
 
+func TestLoginUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+						// the session is audited as created by the user logging in
+						require.Equal(t, user.Username, db.AuditMetadataFrom(ctx).Actor)
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.RefreshToken)
+						require.False(t, arg.IsBlocked)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt.Time, time.Second)
+						return sqlc.Session{
+							ID:           arg.ID,
+							Username:     arg.Username,
+							RefreshToken: arg.RefreshToken,
+							ExpiresAt:    arg.ExpiresAt,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.SessionID)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+				require.NotEqual(t, rsp.AccessToken, rsp.RefreshToken)
+				require.True(t, rsp.RefreshTokenExpiresAt.After(rsp.AccessTokenExpiresAt))
+				require.Equal(t, user.Username, rsp.User.Username)
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{
+				"username": "notfound",
+				"password": password,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.User{}, db.ErrNotFound)
+
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CreateSessionError",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "UnsupportedScope",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+				"scopes":   []string{"everything"},
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorResponse(t, recorder, "validation_failed")
+			},
+		},
+		{
+			name: "InvalidUsername",
+			body: gin.H{
+				"username": "invalid-user",
+				"password": password,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/login"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUserScopes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, password := randomUser(t)
+	scopes := []string{utils.AccountsReadScope, utils.TransfersWriteScope}
+
+	var session sqlc.Session
+	store := mock.NewMockStore(ctrl)
+	// once to log in, once to renew the access token
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(2).Return(user, nil)
+	store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ any, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+			session = sqlc.Session{
+				ID:           arg.ID,
+				Username:     arg.Username,
+				RefreshToken: arg.RefreshToken,
+				ExpiresAt:    arg.ExpiresAt,
+			}
+			return session, nil
+		})
+	store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ any, _ uuid.UUID) (sqlc.Session, error) {
+			return session, nil
+		})
+
+	server := newTestServer(t, store)
+
+	data, err := json.Marshal(gin.H{"username": user.Username, "password": password, "scopes": scopes})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var login loginUserResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &login))
+	for _, tokenString := range []string{login.AccessToken, login.RefreshToken} {
+		payload, err := server.tokenMaker.VerifyToken(tokenString)
+		require.NoError(t, err)
+		require.Equal(t, scopes, payload.Scopes)
+	}
+
+	// a renewed access token keeps the scopes of the session
+	data, err = json.Marshal(gin.H{"refresh_token": login.RefreshToken})
+	require.NoError(t, err)
+	request, err = http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var renewed renewAccessTokenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &renewed))
+	payload, err := server.tokenMaker.VerifyToken(renewed.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, scopes, payload.Scopes)
+}
+
+func TestLogoutUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name         string
+		refreshToken func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload)
+		buildStubs   func(store *mock.MockStore, refreshPayload *token.Payload)
+		// checkResponse gets the access token used for the call
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, accessPayload *token.Payload, refreshPayload *token.Payload)
+	}{
+		{
+			name: "OK",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return "", nil
+			},
+			buildStubs: func(store *mock.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().
+					BlockSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+				requireRevoked(t, server, accessPayload, true)
+			},
+		},
+		{
+			name: "OKWithRefreshToken",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken(user.Username, utils.DepositorRole, token.RefreshToken, uuid.Nil, time.Hour)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			buildStubs: func(store *mock.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().
+					BlockSessionTx(gomock.Any(), gomock.Eq(refreshPayload.ID)).
+					Times(1).
+					Return(sqlc.Session{ID: refreshPayload.ID, Username: user.Username, IsBlocked: true}, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+				requireRevoked(t, server, accessPayload, true)
+			},
+		},
+		{
+			name: "AccessTokenAsRefreshToken",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				accessToken, payload, err := tokenMaker.CreateToken(user.Username, utils.DepositorRole, token.AccessToken, uuid.Nil, time.Hour)
+				require.NoError(t, err)
+				return accessToken, payload
+			},
+			buildStubs: func(store *mock.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().
+					BlockSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireRevoked(t, server, accessPayload, false)
+			},
+		},
+		{
+			name: "InvalidRefreshToken",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return "invalid", nil
+			},
+			buildStubs: func(store *mock.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().
+					BlockSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireRevoked(t, server, accessPayload, false)
+			},
+		},
+		{
+			name: "RefreshTokenOfAnotherUser",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken("someone_else", utils.DepositorRole, token.RefreshToken, uuid.Nil, time.Hour)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			buildStubs: func(store *mock.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().
+					BlockSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireRevoked(t, server, accessPayload, false)
+				requireRevoked(t, server, refreshPayload, false)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken(user.Username, utils.DepositorRole, token.RefreshToken, uuid.Nil, time.Hour)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			buildStubs: func(store *mock.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().
+					BlockSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.Session{}, db.ErrNotFound)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireRevoked(t, server, accessPayload, false)
+			},
+		},
+		{
+			name: "InternalError",
+			refreshToken: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken(user.Username, utils.DepositorRole, token.RefreshToken, uuid.Nil, time.Hour)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			buildStubs: func(store *mock.MockStore, refreshPayload *token.Payload) {
+				store.EXPECT().
+					BlockSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder, accessPayload *token.Payload, refreshPayload *token.Payload) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				requireRevoked(t, server, accessPayload, false)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			refreshToken, refreshPayload := tc.refreshToken(t, server.tokenMaker)
+			tc.buildStubs(store, refreshPayload)
+
+			var body io.Reader = http.NoBody
+			if len(refreshToken) > 0 {
+				data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/users/logout", body)
+			require.NoError(t, err)
+
+			accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, utils.DepositorRole, token.AccessToken, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder, accessPayload, refreshPayload)
+		})
+	}
+}
+
+func TestRevokeUserSessionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	sessions := make([]sqlc.Session, 3)
+	for i := range sessions {
+		payload, err := token.NewPayload(user.Username, utils.DepositorRole, time.Hour)
+		require.NoError(t, err)
+		sessions[i] = randomSession(user.Username, utils.RandomString(32), payload)
+		sessions[i].IsBlocked = true
+	}
+
+	testCases := []struct {
+		name     string
+		username string
+		// caller and role of the authenticated user, the user themselves as a depositor when empty
+		caller        string
+		role          string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					BlockUserSessionsTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(sessions, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp revokeUserSessionsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, len(sessions), rsp.RevokedSessions)
+			},
+		},
+		{
+			name:     "AnotherUser",
+			username: "someoneelse",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					BlockUserSessionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "AdminRevokesAnotherUser",
+			username: user.Username,
+			role:     utils.AdminRole,
+			caller:   "admin_user",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					BlockUserSessionsTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(sessions, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidUsername",
+			username: "invalid-user",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					BlockUserSessionsTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					BlockUserSessionsTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/users/%s/revoke_sessions", tc.username)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			caller, role := user.Username, utils.DepositorRole
+			if len(tc.caller) > 0 {
+				caller, role = tc.caller, tc.role
+			}
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, caller, role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
+
+// a stolen access token stops working as soon as the sessions of its user are revoked
+func TestRevokeUserSessionsRejectsIssuedAccessTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	store := mock.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, utils.DepositorRole, token.RefreshToken, uuid.Nil, time.Hour)
+	require.NoError(t, err)
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, utils.DepositorRole, token.AccessToken, refreshPayload.ID, time.Minute)
+	require.NoError(t, err)
+
+	session := randomSession(user.Username, refreshToken, refreshPayload)
+	store.EXPECT().
+		GetSession(gomock.Any(), gomock.Eq(session.ID)).
+		Times(2).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID) (sqlc.Session, error) {
+			return session, nil
+		})
+	store.EXPECT().
+		BlockUserSessionsTx(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		DoAndReturn(func(_ context.Context, _ string) ([]sqlc.Session, error) {
+			session.IsBlocked = true
+			return []sqlc.Session{session}, nil
+		})
+
+	url := fmt.Sprintf("/users/%s/revoke_sessions", user.Username)
+	for _, wantCode := range []int{http.StatusOK, http.StatusUnauthorized} {
+		request, err := http.NewRequest(http.MethodPost, url, nil)
+		require.NoError(t, err)
+		request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, wantCode, recorder.Code)
+	}
+}
+
+func requireRevoked(t *testing.T, server *Server, payload *token.Payload, expected bool) {
+	revoked, err := server.revoker.IsRevoked(context.Background(), payload.ID)
+	require.NoError(t, err)
+	require.Equal(t, expected, revoked)
+}
+
 func randomUser(t *testing.T) (user sqlc.User, password string) {
 	password = utils.RandomString(6)
 	hashedPassword, err := utils.HashedPassword(password)
@@ -238,6 +826,7 @@ func randomUser(t *testing.T) (user sqlc.User, password string) {
 		HashedPassword: hashedPassword,
 		FullName:       utils.RandomOwner(),
 		Email:          utils.RandomEmail(),
+		Role:           sqlc.UserRoleDepositor,
 	}
 	return user, password
 }
@@ -255,4 +844,5 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user sqlc.User) {
 	require.Equal(t, user.Email, gotUser.Email)
 	require.WithinDuration(t, user.PasswordChangedAt.Time, gotUser.PasswordChangedAt.Time, 0)
 	require.WithinDuration(t, user.CreatedAt.Time, gotUser.CreatedAt.Time, 0)
+	require.Equal(t, user.Role, gotUser.Role)
 }
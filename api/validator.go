@@ -1,14 +1,143 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/suryansh74/simplebank/currency"
 	"github.com/suryansh74/simplebank/utils"
 )
 
-var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
-	if currency, ok := fieldLevel.Field().Interface().(string); ok {
-		return utils.IsSupportedCurrency(currency)
+var (
+	// validationTranslator writes the messages of validation errors, it is registered once
+	// with the validator gin shares between every server
+	validationTranslator, _ = ut.New(en.New()).GetTranslator("en")
+	registerTranslations    sync.Once
+)
+
+// fieldError is one rule a request field broke, named the way the client sent it
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// validCurrency accepts codes the registry knows and has enabled
+func validCurrency(registry *currency.Registry) validator.Func {
+	return func(fieldLevel validator.FieldLevel) bool {
+		if code, ok := fieldLevel.Field().Interface().(string); ok {
+			return registry.IsSupported(code)
+		}
+
+		return false
+	}
+}
+
+// validScope accepts the scopes a token can be restricted to
+func validScope(fieldLevel validator.FieldLevel) bool {
+	if scope, ok := fieldLevel.Field().Interface().(string); ok {
+		return utils.IsSupportedScope(scope)
 	}
 
 	return false
 }
+
+// registerValidations adds the custom rules to v and makes it report fields by the json,
+// uri or form name of the request struct rather than the Go name
+func registerValidations(v *validator.Validate, registry *currency.Registry) error {
+	err := v.RegisterValidation("currency", validCurrency(registry))
+	if err != nil {
+		return err
+	}
+	err = v.RegisterValidation("scope", validScope)
+	if err != nil {
+		return err
+	}
+
+	v.RegisterTagNameFunc(requestFieldName)
+
+	registerTranslations.Do(func() {
+		err = en_translations.RegisterDefaultTranslations(v, validationTranslator)
+		if err != nil {
+			return
+		}
+		err = v.RegisterTranslation("currency", validationTranslator,
+			func(translator ut.Translator) error {
+				return translator.Add("currency", "{0} is not a supported currency", false)
+			},
+			func(translator ut.Translator, fe validator.FieldError) string {
+				message, _ := translator.T("currency", fe.Field())
+				return message
+			},
+		)
+		if err != nil {
+			return
+		}
+		err = v.RegisterTranslation("scope", validationTranslator,
+			func(translator ut.Translator) error {
+				return translator.Add("scope", "{0} is not a supported scope", false)
+			},
+			func(translator ut.Translator, fe validator.FieldError) string {
+				message, _ := translator.T("scope", fe.Field())
+				return message
+			},
+		)
+	})
+	return err
+}
+
+func requestFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "uri", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return ""
+}
+
+// fieldErrors lists the fields a request failed on, nil when err is not about the fields
+func fieldErrors(err error) []fieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]fieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			fields[i] = fieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: fe.Translate(validationTranslator),
+			}
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []fieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: typeErr.Field + " must be of type " + typeErr.Type.String(),
+		}}
+	}
+
+	return nil
+}
+
+// fieldPath drops the request struct from a namespace such as batchTransferRequest.transfers[1].amount
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+	return path
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/suryansh74/simplebank/token"
+)
+
+// wellKnownMaxAge is how long clients may cache the published keys. A new signing key
+// should be in the keys directory this long before it becomes the current one.
+const wellKnownMaxAge = 5 * time.Minute
+
+var (
+	// errKeysNotPublished answers for symmetric token types, their keys must stay secret
+	errKeysNotPublished = errors.New("the token keys are not published for symmetric tokens")
+	// errNoIssuer answers discovery when TOKEN_ISSUER is not set. The issuer is never taken from
+	// the Host header, a cached document would then point clients at whatever host was asked for.
+	errNoIssuer = errors.New("discovery is not available without a configured token issuer")
+)
+
+// openIDConfiguration is the subset of the OpenID Connect discovery document
+// other services need to find the keys tokens are verified with
+type openIDConfiguration struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+func (server *Server) getJWKS(ctx *gin.Context) {
+	maker, ok := server.tokenMaker.(token.AsymmetricMaker)
+	if !ok {
+		ctx.Error(statusError(http.StatusNotFound, errKeysNotPublished))
+		return
+	}
+
+	setCacheControl(ctx)
+	ctx.JSON(http.StatusOK, token.NewJWKS(maker))
+}
+
+func (server *Server) getOpenIDConfiguration(ctx *gin.Context) {
+	maker, ok := server.tokenMaker.(token.AsymmetricMaker)
+	if !ok {
+		ctx.Error(statusError(http.StatusNotFound, errKeysNotPublished))
+		return
+	}
+
+	issuer := server.config.TokenIssuer
+	if issuer == "" {
+		ctx.Error(statusError(http.StatusNotFound, errNoIssuer))
+		return
+	}
+	rsp := openIDConfiguration{
+		Issuer:        issuer,
+		JWKSURI:       issuer + "/.well-known/jwks.json",
+		TokenEndpoint: issuer + "/users/login",
+	}
+	if _, ok := maker.(*token.EdDSAJWTMaker); ok {
+		rsp.IDTokenSigningAlgValuesSupported = []string{"EdDSA"}
+	}
+
+	setCacheControl(ctx)
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func setCacheControl(ctx *gin.Context) {
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(wellKnownMaxAge.Seconds())))
+}
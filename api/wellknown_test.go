@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+)
+
+func TestJWKSVerifiesLoginTokens(t *testing.T) {
+	testCases := []struct {
+		name      string
+		tokenType string
+		// verify checks accessToken the way another service would, with only the published keys
+		// and a stock parser that checks the registered times. It returns the username and expiry.
+		verify func(t *testing.T, jwks token.JWKS, accessToken string) (string, time.Time)
+	}{
+		{
+			name:      "JWTEdDSA",
+			tokenType: token.TypeJWTEdDSA,
+			verify: func(t *testing.T, jwks token.JWKS, accessToken string) (string, time.Time) {
+				claims := struct {
+					jwt.RegisteredClaims
+					Username string `json:"username"`
+				}{}
+				_, err := jwt.ParseWithClaims(accessToken, &claims, func(jwtToken *jwt.Token) (interface{}, error) {
+					for _, jwk := range jwks.Keys {
+						if jwk.KeyID == jwtToken.Header["kid"] && jwk.Algorithm == jwtToken.Method.Alg() {
+							return decodeJWK(t, jwk), nil
+						}
+					}
+					return nil, token.ErrInvalidToken
+				})
+				require.NoError(t, err)
+				// RegisteredClaims only checks the times that are present
+				require.NotNil(t, claims.ExpiresAt)
+				require.NotNil(t, claims.NotBefore)
+				return claims.Username, claims.ExpiresAt.Time
+			},
+		},
+		{
+			name:      "PasetoV4Public",
+			tokenType: token.TypePasetoV4Public,
+			verify: func(t *testing.T, jwks token.JWKS, accessToken string) (string, time.Time) {
+				// ValidAt requires iat, nbf and exp and checks them
+				parser := paseto.NewParser()
+				parser.AddRule(paseto.ValidAt(time.Now()))
+				footer, err := parser.UnsafeParseFooter(paseto.V4Public, accessToken)
+				require.NoError(t, err)
+
+				var keyFooter struct {
+					KeyID string `json:"kid"`
+				}
+				require.NoError(t, json.Unmarshal(footer, &keyFooter))
+
+				for _, jwk := range jwks.Keys {
+					if jwk.KeyID != keyFooter.KeyID {
+						continue
+					}
+					publicKey, err := paseto.NewV4AsymmetricPublicKeyFromEd25519(decodeJWK(t, jwk))
+					require.NoError(t, err)
+					parsed, err := parser.ParseV4Public(publicKey, accessToken, nil)
+					require.NoError(t, err)
+					username, err := parsed.GetString("username")
+					require.NoError(t, err)
+					expiresAt, err := parsed.GetExpiration()
+					require.NoError(t, err)
+					return username, expiresAt
+				}
+				require.Fail(t, "no published key for the token", keyFooter.KeyID)
+				return "", time.Time{}
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			user, password := randomUser(t)
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(1).
+				DoAndReturn(func(_ any, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+					return sqlc.Session{ID: arg.ID, Username: arg.Username}, nil
+				})
+
+			// the retired key is still published so its tokens keep verifying elsewhere
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "old.key"), []byte(randomSeed(t)), 0o600))
+
+			config := testConfig()
+			config.TokenType = tc.tokenType
+			config.TokenPrivateKey = randomSeed(t)
+			config.TokenKeyID = "new"
+			config.TokenKeysDir = dir
+			server := newTestServerWithConfig(t, store, config)
+
+			data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var login loginUserResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &login))
+
+			request, err = http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+			require.NoError(t, err)
+			recorder = httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Equal(t, "public, max-age=300", recorder.Header().Get("Cache-Control"))
+
+			var jwks token.JWKS
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jwks))
+			require.Len(t, jwks.Keys, 2)
+
+			username, expiresAt := tc.verify(t, jwks, login.AccessToken)
+			require.Equal(t, user.Username, username)
+			require.WithinDuration(t, login.AccessTokenExpiresAt, expiresAt, time.Second)
+		})
+	}
+}
+
+func TestOpenIDConfiguration(t *testing.T) {
+	config := testConfig()
+	config.TokenType = token.TypeJWTEdDSA
+	config.TokenPrivateKey = randomSeed(t)
+	config.TokenIssuer = "https://bank.example"
+	server := newTestServerWithConfig(t, nil, config)
+
+	// the Host header has no say in the document
+	request, err := http.NewRequest(http.MethodGet, "http://attacker.example/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "public, max-age=300", recorder.Header().Get("Cache-Control"))
+
+	var rsp openIDConfiguration
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, openIDConfiguration{
+		Issuer:                           "https://bank.example",
+		JWKSURI:                          "https://bank.example/.well-known/jwks.json",
+		TokenEndpoint:                    "https://bank.example/users/login",
+		IDTokenSigningAlgValuesSupported: []string{"EdDSA"},
+	}, rsp)
+}
+
+func TestOpenIDConfigurationNoIssuer(t *testing.T) {
+	config := testConfig()
+	config.TokenType = token.TypeJWTEdDSA
+	config.TokenPrivateKey = randomSeed(t)
+	config.TokenIssuer = ""
+	server := newTestServerWithConfig(t, nil, config)
+
+	request, err := http.NewRequest(http.MethodGet, "http://bank.example/.well-known/openid-configuration", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	require.Empty(t, recorder.Header().Get("Cache-Control"))
+	requireErrorResponse(t, recorder, "not_found")
+}
+
+func TestWellKnownSymmetricToken(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	for _, path := range []string{"/.well-known/jwks.json", "/.well-known/openid-configuration"} {
+		request, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+		requireErrorResponse(t, recorder, "not_found")
+	}
+}
+
+func randomSeed(t *testing.T) string {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	return hex.EncodeToString(privateKey.Seed())
+}
+
+func decodeJWK(t *testing.T, jwk token.JWK) ed25519.PublicKey {
+	require.Equal(t, "OKP", jwk.KeyType)
+	require.Equal(t, "Ed25519", jwk.Curve)
+	key, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	require.NoError(t, err)
+	return ed25519.PublicKey(key)
+}
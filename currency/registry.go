@@ -0,0 +1,66 @@
+// Package currency keeps the currencies the bank supports, as configured in the currencies table
+package currency
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// Lister is the part of the store the registry needs
+type Lister interface {
+	ListCurrencies(ctx context.Context) ([]sqlc.Currency, error)
+}
+
+// Registry is an in-memory copy of the currencies table.
+// A currency added by a migration shows up after Reload, which NewRegistry does once at startup.
+type Registry struct {
+	lister     Lister
+	mutex      sync.RWMutex
+	currencies map[string]sqlc.Currency
+}
+
+func NewRegistry(ctx context.Context, lister Lister) (*Registry, error) {
+	registry := &Registry{lister: lister}
+
+	err := registry.Reload(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Reload replaces the cached currencies with the current content of the table
+func (registry *Registry) Reload(ctx context.Context) error {
+	currencies, err := registry.lister.ListCurrencies(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot load currencies: %w", err)
+	}
+
+	byCode := make(map[string]sqlc.Currency, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+
+	registry.mutex.Lock()
+	registry.currencies = byCode
+	registry.mutex.Unlock()
+	return nil
+}
+
+// Get returns the currency whether it is enabled or not
+func (registry *Registry) Get(code string) (sqlc.Currency, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	currency, ok := registry.currencies[code]
+	return currency, ok
+}
+
+// IsSupported reports whether new accounts and transfers may use the currency
+func (registry *Registry) IsSupported(code string) bool {
+	currency, ok := registry.Get(code)
+	return ok && currency.Enabled
+}
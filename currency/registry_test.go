@@ -0,0 +1,58 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+type staticLister struct {
+	currencies []sqlc.Currency
+	err        error
+}
+
+func (lister *staticLister) ListCurrencies(_ context.Context) ([]sqlc.Currency, error) {
+	return lister.currencies, lister.err
+}
+
+func TestRegistry(t *testing.T) {
+	lister := &staticLister{
+		currencies: []sqlc.Currency{
+			{Code: "EUR", Exponent: 2, Enabled: true},
+			{Code: "USD", Exponent: 2, Enabled: true},
+			{Code: "VEF", Exponent: 2, Enabled: false},
+		},
+	}
+
+	registry, err := NewRegistry(context.Background(), lister)
+	require.NoError(t, err)
+
+	require.True(t, registry.IsSupported("USD"))
+	require.True(t, registry.IsSupported("EUR"))
+	require.False(t, registry.IsSupported("VEF"))
+	require.False(t, registry.IsSupported("JPY"))
+
+	currency, ok := registry.Get("VEF")
+	require.True(t, ok)
+	require.False(t, currency.Enabled)
+
+	// currencies added to the table show up after a reload
+	lister.currencies = append(lister.currencies, sqlc.Currency{Code: "JPY", Exponent: 0, Enabled: true})
+	require.False(t, registry.IsSupported("JPY"))
+
+	err = registry.Reload(context.Background())
+	require.NoError(t, err)
+	require.True(t, registry.IsSupported("JPY"))
+
+	currency, ok = registry.Get("JPY")
+	require.True(t, ok)
+	require.Zero(t, currency.Exponent)
+}
+
+func TestRegistryLoadError(t *testing.T) {
+	_, err := NewRegistry(context.Background(), &staticLister{err: errors.New("connection refused")})
+	require.Error(t, err)
+}
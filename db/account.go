@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// CreateAccountTx opens an account and records who opened it
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg sqlc.CreateAccountParams) (sqlc.Account, error) {
+	var account sqlc.Account
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		account, err = q.CreateAccount(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionCreateAccount,
+			TargetType: "account",
+			TargetID:   auditID(account.ID),
+			After:      account,
+		}, nil
+	})
+	return account, err
+}
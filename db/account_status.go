@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+type ChangeAccountStatusTxParams struct {
+	AccountID int64              `json:"account_id"`
+	Status    sqlc.AccountStatus `json:"status"`
+	Reason    string             `json:"reason"`
+	// ChangedBy is the username of the owner or admin making the change
+	ChangedBy string `json:"changed_by"`
+}
+
+type ChangeAccountStatusTxResult struct {
+	Account sqlc.Account             `json:"account"`
+	Change  sqlc.AccountStatusChange `json:"change"`
+}
+
+// statusTransitions lists the statuses each status can move to, closed is final
+var statusTransitions = map[sqlc.AccountStatus][]sqlc.AccountStatus{
+	sqlc.AccountStatusActive: {sqlc.AccountStatusFrozen, sqlc.AccountStatusClosed},
+	sqlc.AccountStatusFrozen: {sqlc.AccountStatusActive},
+}
+
+// ChangeAccountStatusTx moves an account to a new status and records why.
+// The account row is locked first so the balance check for closing cannot race a transfer.
+func (store *SQLStore) ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (ChangeAccountStatusTxResult, error) {
+	var result ChangeAccountStatusTxResult
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkStatusTransition(account, arg.Status)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, sqlc.UpdateAccountStatusParams{
+			ID:     arg.AccountID,
+			Status: arg.Status,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Change, err = q.CreateAccountStatusChange(ctx, sqlc.CreateAccountStatusChangeParams{
+			AccountID:  arg.AccountID,
+			FromStatus: account.Status,
+			ToStatus:   arg.Status,
+			Reason:     arg.Reason,
+			ChangedBy:  arg.ChangedBy,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionChangeAccountStatus,
+			TargetType: "account",
+			TargetID:   auditID(account.ID),
+			Before:     account,
+			After:      result,
+		}, nil
+	})
+	return result, err
+}
+
+func checkStatusTransition(account sqlc.Account, status sqlc.AccountStatus) error {
+	allowed := false
+	for _, next := range statusTransitions[account.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: account [%d] cannot go from %s to %s", ErrInvalidStatusTransition, account.ID, account.Status, status)
+	}
+
+	if status == sqlc.AccountStatusClosed && account.Balance != 0 {
+		return fmt.Errorf("%w: account [%d] has a balance of %d", ErrAccountNotEmpty, account.ID, account.Balance)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// CreateAPIKeyTx stores a new API key, the audit snapshots leave the key hash out
+func (store *SQLStore) CreateAPIKeyTx(ctx context.Context, arg sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error) {
+	var apiKey sqlc.ApiKey
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		apiKey, err = q.CreateAPIKey(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionCreateAPIKey,
+			TargetType: "api_key",
+			TargetID:   auditID(apiKey.ID),
+			After:      apiKeySnapshot(apiKey),
+		}, nil
+	})
+	return apiKey, err
+}
+
+// RevokeAPIKeyTx revokes an API key, ErrNotFound is returned when it was already revoked
+func (store *SQLStore) RevokeAPIKeyTx(ctx context.Context, id int64) (sqlc.ApiKey, error) {
+	var apiKey sqlc.ApiKey
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		before, err := q.GetAPIKey(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		apiKey, err = q.RevokeAPIKey(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionRevokeAPIKey,
+			TargetType: "api_key",
+			TargetID:   auditID(apiKey.ID),
+			Before:     apiKeySnapshot(before),
+			After:      apiKeySnapshot(apiKey),
+		}, nil
+	})
+	return apiKey, err
+}
+
+func apiKeySnapshot(apiKey sqlc.ApiKey) sqlc.ApiKey {
+	apiKey.HashedKey = ""
+	return apiKey
+}
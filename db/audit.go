@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// actions recorded in audit_events
+const (
+	AuditActionCreateUser              = "user.create"
+	AuditActionCreateSession           = "session.create"
+	AuditActionLogout                  = "session.logout"
+	AuditActionRevokeSessions          = "session.revoke_all"
+	AuditActionRevokeToken             = "token.revoke"
+	AuditActionCreateAccount           = "account.create"
+	AuditActionChangeAccountStatus     = "account.status_change"
+	AuditActionCreateTransfer          = "transfer.create"
+	AuditActionReverseTransfer         = "transfer.reverse"
+	AuditActionCreateDeposit           = "deposit.create"
+	AuditActionCreateWithdrawal        = "withdrawal.create"
+	AuditActionCreateScheduledTransfer = "scheduled_transfer.create"
+	AuditActionUpdateScheduledTransfer = "scheduled_transfer.update"
+	AuditActionCancelScheduledTransfer = "scheduled_transfer.cancel"
+	AuditActionPlaceHold               = "hold.place"
+	AuditActionCaptureHold             = "hold.capture"
+	AuditActionReleaseHold             = "hold.release"
+	AuditActionExpireHold              = "hold.expire"
+	AuditActionCreateAPIKey            = "api_key.create"
+	AuditActionRevokeAPIKey            = "api_key.revoke"
+	AuditActionStartReconciliation     = "reconciliation.start"
+	AuditActionRecordDiscrepancy       = "reconciliation.discrepancy"
+	AuditActionFinishReconciliation    = "reconciliation.finish"
+)
+
+// auditSystemActor is recorded when a change is not made on behalf of a user
+const auditSystemActor = "system"
+
+// AuditEvent describes a change made inside a transaction, execTo writes it along with the change
+type AuditEvent struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	// Before and After are stored as JSON, Before is nil when the target was created
+	Before any
+	After  any
+}
+
+// AuditMetadata tells who made a change and from where. The API puts it in the request
+// context, the store reads it from the ctx passed to a transaction.
+type AuditMetadata struct {
+	Actor     string
+	RequestID string
+	ClientIP  string
+	UserAgent string
+}
+
+type auditMetadataKey struct{}
+
+func WithAuditMetadata(ctx context.Context, metadata AuditMetadata) context.Context {
+	return context.WithValue(ctx, auditMetadataKey{}, metadata)
+}
+
+func AuditMetadataFrom(ctx context.Context) AuditMetadata {
+	metadata, _ := ctx.Value(auditMetadataKey{}).(AuditMetadata)
+	return metadata
+}
+
+func writeAuditEvent(ctx context.Context, q *sqlc.Queries, event *AuditEvent) error {
+	before, err := auditSnapshot(event.Before)
+	if err != nil {
+		return err
+	}
+
+	after, err := auditSnapshot(event.After)
+	if err != nil {
+		return err
+	}
+
+	metadata := AuditMetadataFrom(ctx)
+	if metadata.Actor == "" {
+		metadata.Actor = auditSystemActor
+	}
+
+	_, err = q.CreateAuditEvent(ctx, sqlc.CreateAuditEventParams{
+		Actor:      metadata.Actor,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Before:     before,
+		After:      after,
+		RequestID:  metadata.RequestID,
+		ClientIp:   metadata.ClientIP,
+		UserAgent:  metadata.UserAgent,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot write audit event: %w", err)
+	}
+	return nil
+}
+
+func auditSnapshot(value any) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// auditID formats a numeric primary key for AuditEvent.TargetID
+func auditID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
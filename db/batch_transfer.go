@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+type BatchTransferTxParams struct {
+	Transfers []TransferTxParams `json:"transfers"`
+}
+
+type BatchTransferTxResult struct {
+	Transfers []TransferTxResult `json:"transfers"`
+}
+
+// BatchTransferTx makes every transfer of the batch or none of them. All the accounts involved
+// are locked in id order before the first transfer, so a batch cannot deadlock with another batch
+// or a single transfer whatever order its transfers come in.
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		accountIDs := make([]int64, 0, 2*len(arg.Transfers))
+		for _, transfer := range arg.Transfers {
+			accountIDs = append(accountIDs, transfer.FromAccountID, transfer.ToAccountID)
+		}
+
+		err := lockAccounts(ctx, q, accountIDs...)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Transfers = make([]TransferTxResult, len(arg.Transfers))
+		for i, transfer := range arg.Transfers {
+			result.Transfers[i], err = transferMoney(ctx, q, transfer)
+			if err != nil {
+				return nil, &BatchTransferError{Index: i, Err: err}
+			}
+
+			// every transfer is audited as if it was made on its own
+			err = writeAuditEvent(ctx, q, transferAuditEvent(AuditActionCreateTransfer, result.Transfers[i]))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return result, err
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
+)
+
+// ClearingAccountOwner owns one clearing account per currency. Deposits are paid out of it and
+// withdrawals paid into it, so its balance is always minus the money held by customers.
+const ClearingAccountOwner = "system_clearing"
+
+type DepositTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+	// ExternalRef identifies the deposit in the system the money came from, a reference can only be used once
+	ExternalRef string `json:"external_ref"`
+}
+
+type WithdrawTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+	// ExternalRef identifies the payout in the system the money goes to, a reference can only be used once
+	ExternalRef string `json:"external_ref"`
+}
+
+// DepositTx credits an account from the clearing account of its currency
+func (store *SQLStore) DepositTx(ctx context.Context, arg DepositTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		clearingAccount, err := clearingAccountFor(ctx, q, arg.AccountID)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err = moveMoney(ctx, q, sqlc.CreateTransferParams{
+			FromAccountID: clearingAccount.ID,
+			ToAccountID:   arg.AccountID,
+			Amount:        arg.Amount,
+			ToAmount:      arg.Amount,
+			ExchangeRate:  numericRate(exchange.RateUnit),
+			Kind:          sqlc.TransferKindDeposit,
+			ExternalRef:   externalRef(arg.ExternalRef),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return transferAuditEvent(AuditActionCreateDeposit, result), nil
+	})
+	return result, err
+}
+
+// WithdrawTx debits an account into the clearing account of its currency,
+// the account's overdraft policy applies as for any other debit
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg WithdrawTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		clearingAccount, err := clearingAccountFor(ctx, q, arg.AccountID)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err = moveMoney(ctx, q, sqlc.CreateTransferParams{
+			FromAccountID: arg.AccountID,
+			ToAccountID:   clearingAccount.ID,
+			Amount:        arg.Amount,
+			ToAmount:      arg.Amount,
+			ExchangeRate:  numericRate(exchange.RateUnit),
+			Kind:          sqlc.TransferKindWithdrawal,
+			ExternalRef:   externalRef(arg.ExternalRef),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return transferAuditEvent(AuditActionCreateWithdrawal, result), nil
+	})
+	return result, err
+}
+
+func clearingAccountFor(ctx context.Context, q *sqlc.Queries, accountID int64) (sqlc.Account, error) {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	clearingAccount, err := q.GetClearingAccount(ctx, account.Currency)
+	if err != nil {
+		// not a missing row from the caller's point of view, the currency was added without its clearing account
+		return clearingAccount, fmt.Errorf("cannot find clearing account for %s: %v", account.Currency, err)
+	}
+	return clearingAccount, nil
+}
+
+func externalRef(ref string) pgtype.Text {
+	return pgtype.Text{String: ref, Valid: ref != ""}
+}
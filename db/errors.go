@@ -0,0 +1,129 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// ErrInsufficientFunds is returned when a debit would take an account past its overdraft policy
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// InsufficientFundsError carries the details of a rejected debit, it matches ErrInsufficientFunds with errors.Is
+type InsufficientFundsError struct {
+	AccountID int64
+	Amount    int64
+	Available int64
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("account [%d] has insufficient funds: requested %d, available %d", e.AccountID, e.Amount, e.Available)
+}
+
+func (e *InsufficientFundsError) Unwrap() error {
+	return ErrInsufficientFunds
+}
+
+// ErrAccountNotActive is returned when money would move in or out of a frozen or closed account
+var ErrAccountNotActive = errors.New("account is not active")
+
+// AccountNotActiveError names the account that blocked a transfer, it matches ErrAccountNotActive with errors.Is
+type AccountNotActiveError struct {
+	AccountID int64
+	Status    sqlc.AccountStatus
+}
+
+func (e *AccountNotActiveError) Error() string {
+	return fmt.Sprintf("account [%d] is %s", e.AccountID, e.Status)
+}
+
+func (e *AccountNotActiveError) Unwrap() error {
+	return ErrAccountNotActive
+}
+
+// ErrInvalidStatusTransition is returned for status changes outside active <-> frozen and active -> closed
+var ErrInvalidStatusTransition = errors.New("invalid account status transition")
+
+// ErrAccountNotEmpty is returned when closing an account whose balance is not zero
+var ErrAccountNotEmpty = errors.New("account balance must be zero to close it")
+
+// ErrScheduledTransferEnded is returned when changing a scheduled transfer that completed, failed or was cancelled
+var ErrScheduledTransferEnded = errors.New("scheduled transfer has ended")
+
+// ErrScheduledTransferRunStale is returned when running an attempt that was already run,
+// or a scheduled transfer that is no longer active
+var ErrScheduledTransferRunStale = errors.New("scheduled transfer attempt has already run")
+
+// ErrTransferNotReversible is returned when reversing a deposit, a withdrawal or another reversal
+var ErrTransferNotReversible = errors.New("only transfers between accounts can be reversed")
+
+// ErrReversalExceedsTransfer is returned when a reversal would give back more than the transfer moved
+var ErrReversalExceedsTransfer = errors.New("reversal exceeds the transfer amount")
+
+// ReversalExceedsTransferError carries what is left to reverse, it matches ErrReversalExceedsTransfer with errors.Is
+type ReversalExceedsTransferError struct {
+	TransferID int64
+	Amount     int64
+	Reversible int64
+}
+
+func (e *ReversalExceedsTransferError) Error() string {
+	return fmt.Sprintf("transfer [%d] cannot be reversed by %d, reversible %d", e.TransferID, e.Amount, e.Reversible)
+}
+
+func (e *ReversalExceedsTransferError) Unwrap() error {
+	return ErrReversalExceedsTransfer
+}
+
+// ErrHoldNotActive is returned when capturing or releasing a hold that was already captured, released or expired
+var ErrHoldNotActive = errors.New("hold is not active")
+
+// ErrHoldExpired is returned when capturing a hold past its expiry that the worker has not released yet
+var ErrHoldExpired = errors.New("hold has expired")
+
+// ErrCaptureExceedsHold is returned when a capture asks for more than the hold reserved
+var ErrCaptureExceedsHold = errors.New("capture exceeds the held amount")
+
+// BatchTransferError tells which transfer of a batch failed, the whole batch was rolled back
+type BatchTransferError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchTransferError) Error() string {
+	return fmt.Sprintf("transfer %d of the batch failed: %v", e.Index, e.Err)
+}
+
+func (e *BatchTransferError) Unwrap() error {
+	return e.Err
+}
+
+// ErrNotFound is returned when a query finds no row, it replaces pgx.ErrNoRows which still matches with errors.Is
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict is returned when an insert or update breaks a unique constraint
+var ErrConflict = errors.New("record already exists")
+
+// ErrForeignKey is returned when a row references another row that does not exist
+var ErrForeignKey = errors.New("referenced record does not exist")
+
+// Error is a driver error translated into one of ErrNotFound, ErrConflict or ErrForeignKey,
+// it matches both its kind and the driver error with errors.Is and errors.As
+type Error struct {
+	Kind error
+	// Constraint is the name of the constraint that was violated, empty for ErrNotFound
+	Constraint string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Constraint == "" {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%v: %s", e.Kind, e.Constraint)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
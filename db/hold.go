@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
+)
+
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	// Amount is taken in the currency of the held account, 0 captures the whole hold
+	Amount int64 `json:"amount"`
+	// Rate converts Amount into the currency of the to account, nil when both share a currency
+	Rate *exchange.Rate `json:"rate,omitempty"`
+}
+
+type CaptureHoldTxResult struct {
+	Hold sqlc.Hold `json:"hold"`
+	TransferTxResult
+}
+
+// PlaceHoldTx reserves money on an account for a later capture. The hold lowers the
+// available balance right away but leaves the balance alone until it is captured.
+func (store *SQLStore) PlaceHoldTx(ctx context.Context, arg sqlc.CreateHoldParams) (sqlc.Hold, error) {
+	var hold sqlc.Hold
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		account, err := q.AddAccountHeldBalance(ctx, sqlc.AddAccountHeldBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkActive(account)
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkOverdraft(account, arg.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		hold, err = q.CreateHold(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionPlaceHold,
+			TargetType: "hold",
+			TargetID:   auditID(hold.ID),
+			After:      hold,
+		}, nil
+	})
+	return hold, err
+}
+
+// CaptureHoldTx turns an active hold into a transfer to the account it was placed for.
+// Capturing less than the hold releases the rest, a hold is only ever captured once.
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		before, err := q.GetHoldForUpdate(ctx, arg.HoldID)
+		if err != nil {
+			return nil, err
+		}
+
+		if before.Status != sqlc.HoldStatusActive {
+			return nil, ErrHoldNotActive
+		}
+		if !before.ExpiresAt.Time.After(time.Now()) {
+			return nil, ErrHoldExpired
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = before.Amount
+		}
+		if amount > before.Amount {
+			return nil, ErrCaptureExceedsHold
+		}
+
+		// releasing the hold locks the held account first, lock both in id order
+		// beforehand so the capture keeps the same lock order as moveMoney
+		err = lockAccounts(ctx, q, before.AccountID, before.ToAccountID)
+		if err != nil {
+			return nil, err
+		}
+
+		// the held amount goes back to the available balance first, so the debit below can spend it
+		err = releaseHeldAmount(ctx, q, before)
+		if err != nil {
+			return nil, err
+		}
+
+		result.TransferTxResult, err = transferMoney(ctx, q, TransferTxParams{
+			FromAccountID: before.AccountID,
+			ToAccountID:   before.ToAccountID,
+			Amount:        amount,
+			Rate:          arg.Rate,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result.Hold, err = q.UpdateHoldStatus(ctx, sqlc.UpdateHoldStatusParams{
+			ID:         before.ID,
+			Status:     sqlc.HoldStatusCaptured,
+			TransferID: pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionCaptureHold,
+			TargetType: "hold",
+			TargetID:   auditID(result.Hold.ID),
+			Before:     before,
+			After:      result,
+		}, nil
+	})
+	return result, err
+}
+
+// ReleaseHoldTx gives the held money back to the available balance without moving it
+func (store *SQLStore) ReleaseHoldTx(ctx context.Context, holdID int64) (sqlc.Hold, error) {
+	var hold sqlc.Hold
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		before, err := q.GetHoldForUpdate(ctx, holdID)
+		if err != nil {
+			return nil, err
+		}
+
+		if before.Status != sqlc.HoldStatusActive {
+			return nil, ErrHoldNotActive
+		}
+
+		err = releaseHeldAmount(ctx, q, before)
+		if err != nil {
+			return nil, err
+		}
+
+		hold, err = q.UpdateHoldStatus(ctx, sqlc.UpdateHoldStatusParams{
+			ID:     before.ID,
+			Status: sqlc.HoldStatusReleased,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionReleaseHold,
+			TargetType: "hold",
+			TargetID:   auditID(hold.ID),
+			Before:     before,
+			After:      hold,
+		}, nil
+	})
+	return hold, err
+}
+
+// ExpireHoldTx releases the oldest active hold past its expiry, it returns ErrNotFound
+// when there is none. Holds are skipped while another worker is expiring them.
+func (store *SQLStore) ExpireHoldTx(ctx context.Context) (sqlc.Hold, error) {
+	var hold sqlc.Hold
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		before, err := q.GetExpiredHoldForUpdate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		err = releaseHeldAmount(ctx, q, before)
+		if err != nil {
+			return nil, err
+		}
+
+		hold, err = q.UpdateHoldStatus(ctx, sqlc.UpdateHoldStatusParams{
+			ID:     before.ID,
+			Status: sqlc.HoldStatusExpired,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionExpireHold,
+			TargetType: "hold",
+			TargetID:   auditID(hold.ID),
+			Before:     before,
+			After:      hold,
+		}, nil
+	})
+	return hold, err
+}
+
+// releaseHeldAmount takes the hold off the held balance of its account
+func releaseHeldAmount(ctx context.Context, q *sqlc.Queries, hold sqlc.Hold) error {
+	_, err := q.AddAccountHeldBalance(ctx, sqlc.AddAccountHeldBalanceParams{
+		ID:     hold.AccountID,
+		Amount: -hold.Amount,
+	})
+	return err
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// chainPageSize is how many entries are read at a time while verifying a chain
+const chainPageSize = 1000
+
+// EntryHash chains an entry to the previous entry of its account: sha256 of prevHash followed by
+// accountID, amount and createdAt in microseconds, each as a big endian int64. Migration
+// 000014 backfilled existing entries with the same layout, keep them in sync.
+func EntryHash(prevHash []byte, accountID int64, amount int64, createdAt time.Time) []byte {
+	var buf [24]byte
+	binary.BigEndian.PutUint64(buf[0:8], uint64(accountID))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(amount))
+	binary.BigEndian.PutUint64(buf[16:24], uint64(createdAt.UnixMicro()))
+
+	h := sha256.New()
+	h.Write(prevHash)
+	h.Write(buf[:])
+	return h.Sum(nil)
+}
+
+// appendEntry adds an entry at the end of its account's chain. It must run while the account
+// row is locked, otherwise two transfers could both chain onto the same previous entry.
+func appendEntry(ctx context.Context, q *sqlc.Queries, accountID int64, amount int64) (sqlc.Entry, error) {
+	var prevHash []byte
+	last, err := q.GetLastEntry(ctx, accountID)
+	if err == nil {
+		prevHash = last.Hash
+	} else if !errors.Is(err, ErrNotFound) {
+		return sqlc.Entry{}, err
+	}
+
+	// postgres keeps microseconds, the hash must be computed on what is stored
+	createdAt := time.Now().Truncate(time.Microsecond)
+	entry, err := q.CreateEntry(ctx, sqlc.CreateEntryParams{
+		AccountID: accountID,
+		Amount:    amount,
+		CreatedAt: pgtype.Timestamptz{Time: createdAt, Valid: true},
+		PrevHash:  prevHash,
+		Hash:      EntryHash(prevHash, accountID, amount, createdAt),
+	})
+	if err != nil {
+		return sqlc.Entry{}, err
+	}
+
+	// the account remembers where its chain ends, so deleting the newest entries shows
+	err = q.SetAccountLastEntryHash(ctx, sqlc.SetAccountLastEntryHashParams{
+		ID:            accountID,
+		LastEntryHash: entry.Hash,
+	})
+	return entry, err
+}
+
+// ChainReport is the outcome of walking the hash chain of an account
+type ChainReport struct {
+	AccountID int64 `json:"account_id"`
+	// Entries is the number of entries checked, up to and including the broken one
+	Entries int64 `json:"entries"`
+	// BrokenEntryID is the first entry that doesn't match, or the last one left when the newest
+	// entries are missing, zero when the chain is intact or no entry is left at all
+	BrokenEntryID int64 `json:"broken_entry_id,omitempty"`
+	// Reason is empty when the chain is intact
+	Reason string `json:"reason,omitempty"`
+}
+
+func (report ChainReport) Intact() bool {
+	return report.Reason == ""
+}
+
+// VerifyAccountChain walks the entries of an account in order and reports the first one whose
+// link to the previous entry or whose own hash doesn't match. An edited entry breaks its own
+// hash, a deleted or inserted one breaks the link of the entry after it, and the chain must
+// end on the last entry hash of the account, or the newest entries were deleted.
+func VerifyAccountChain(ctx context.Context, q sqlc.Querier, accountID int64) (ChainReport, error) {
+	report := ChainReport{AccountID: accountID}
+
+	// entries appended after the account is read chain onto its last entry hash, the walk
+	// stops there so they are left for the next run
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return report, err
+	}
+	if account.LastEntryHash == nil {
+		first, err := q.ListAccountChain(ctx, sqlc.ListAccountChainParams{
+			AccountID: accountID,
+			PageSize:  1,
+		})
+		if err != nil || len(first) == 0 {
+			return report, err
+		}
+
+		// an entry committed since the account was read set the hash along with it
+		account, err = q.GetAccount(ctx, accountID)
+		if err != nil {
+			return report, err
+		}
+		if account.LastEntryHash == nil {
+			report.BrokenEntryID = first[0].ID
+			report.Reason = "account has entries but no last entry hash"
+			return report, nil
+		}
+	}
+
+	var prevHash []byte
+	var afterID int64
+
+	for {
+		entries, err := q.ListAccountChain(ctx, sqlc.ListAccountChainParams{
+			AccountID: accountID,
+			AfterID:   afterID,
+			PageSize:  chainPageSize,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		for _, entry := range entries {
+			report.Entries++
+			if !bytes.Equal(entry.PrevHash, prevHash) {
+				report.BrokenEntryID = entry.ID
+				report.Reason = "prev_hash doesn't match the hash of the previous entry"
+				return report, nil
+			}
+			if !bytes.Equal(entry.Hash, EntryHash(entry.PrevHash, entry.AccountID, entry.Amount, entry.CreatedAt.Time)) {
+				report.BrokenEntryID = entry.ID
+				report.Reason = "hash doesn't match the content of the entry"
+				return report, nil
+			}
+			if bytes.Equal(entry.Hash, account.LastEntryHash) {
+				return report, nil
+			}
+			prevHash = entry.Hash
+			afterID = entry.ID
+		}
+
+		if len(entries) < chainPageSize {
+			report.BrokenEntryID = afterID
+			report.Reason = "chain doesn't end on the last entry hash of the account"
+			return report, nil
+		}
+	}
+}
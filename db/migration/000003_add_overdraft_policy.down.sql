@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "overdraft_limit_non_negative";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "overdraft_limit";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "overdraft_policy";
+
+DROP TYPE IF EXISTS "OverdraftPolicy";
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TYPE "OverdraftPolicy" AS ENUM (
+  'none',
+  'limit',
+  'unlimited'
+);
+
+ALTER TABLE "accounts" ADD COLUMN "overdraft_policy" "OverdraftPolicy" NOT NULL DEFAULT 'none';
+
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "overdraft_limit_non_negative" CHECK ("overdraft_limit" >= 0);
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'only used by the limit policy, balance may not go below -overdraft_limit';
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE "idempotency_keys" (
+  "key" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response_code" integer NOT NULL DEFAULT 0,
+  "response_body" json,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()',
+  "expired_at" timestamptz NOT NULL,
+  PRIMARY KEY ("username", "key")
+);
+
+CREATE INDEX ON "idempotency_keys" ("expired_at");
+
+COMMENT ON COLUMN "idempotency_keys"."response_code" IS '0 while the original request is still in progress';
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+COMMIT;
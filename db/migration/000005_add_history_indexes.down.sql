@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS "transfers_to_account_id_id_idx";
+
+DROP INDEX IF EXISTS "transfers_from_account_id_id_idx";
+
+DROP INDEX IF EXISTS "entries_account_id_id_idx";
+
+COMMIT;
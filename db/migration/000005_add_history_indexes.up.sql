@@ -0,0 +1,10 @@
+BEGIN;
+
+-- keyset pagination walks an account's history newest first by id
+CREATE INDEX "entries_account_id_id_idx" ON "entries" ("account_id", "id" DESC);
+
+CREATE INDEX "transfers_from_account_id_id_idx" ON "transfers" ("from_account_id", "id" DESC);
+
+CREATE INDEX "transfers_to_account_id_id_idx" ON "transfers" ("to_account_id", "id" DESC);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE "revoked_tokens" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "revoked_tokens"."id" IS 'token payload ID';
+
+COMMENT ON COLUMN "revoked_tokens"."expires_at" IS 'rows past this time can be purged, the token is rejected as expired anyway';
+
+ALTER TABLE "revoked_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "is_frozen";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "role";
+
+DROP TYPE IF EXISTS "UserRole";
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TYPE "UserRole" AS ENUM (
+  'depositor',
+  'banker',
+  'admin'
+);
+
+ALTER TABLE "users" ADD COLUMN "role" "UserRole" NOT NULL DEFAULT 'depositor';
+
+ALTER TABLE "accounts" ADD COLUMN "is_frozen" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "accounts"."is_frozen" IS 'set by admins, a frozen account can neither send nor receive transfers';
+
+COMMIT;
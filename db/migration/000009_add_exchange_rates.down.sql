@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "exchange_rate";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_amount";
+
+COMMENT ON COLUMN "transfers"."amount" IS 'only positive';
+
+-- enum values cannot be dropped, so rebuild the type without GBP
+ALTER TYPE "Currency" RENAME TO "Currency_old";
+
+CREATE TYPE "Currency" AS ENUM (
+  'USD',
+  'EUR'
+);
+
+ALTER TABLE "accounts" ALTER COLUMN "currency" TYPE "Currency" USING "currency"::text::"Currency";
+
+DROP TYPE "Currency_old";
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+ALTER TYPE "Currency" ADD VALUE 'GBP';
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" numeric(20, 8) NOT NULL DEFAULT 1;
+
+UPDATE "transfers" SET "to_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+COMMENT ON COLUMN "transfers"."amount" IS 'only positive, in the currency of from_account';
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'only positive, in the currency of to_account, equals amount unless the currencies differ';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'to_account currency units per from_account currency unit, to_amount = floor(amount * exchange_rate)';
+
+COMMIT;
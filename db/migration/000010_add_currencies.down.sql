@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TYPE "Currency" AS ENUM (
+  'USD',
+  'EUR',
+  'GBP'
+);
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_currency_fkey";
+
+ALTER TABLE "accounts" ALTER COLUMN "currency" TYPE "Currency" USING "currency"::"Currency";
+
+DROP TABLE IF EXISTS "currencies";
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE "currencies" (
+  "code" varchar(3) PRIMARY KEY,
+  "exponent" smallint NOT NULL DEFAULT 2,
+  "enabled" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 code';
+
+COMMENT ON COLUMN "currencies"."exponent" IS 'number of minor units digits, amounts are stored in minor units';
+
+COMMENT ON COLUMN "currencies"."enabled" IS 'disabled currencies keep their accounts but new accounts and transfers are refused';
+
+ALTER TABLE "currencies" ADD CONSTRAINT "exponent_range" CHECK ("exponent" BETWEEN 0 AND 4);
+
+INSERT INTO "currencies" ("code", "exponent") VALUES
+  ('USD', 2),
+  ('EUR', 2),
+  ('GBP', 2);
+
+ALTER TABLE "accounts" ALTER COLUMN "currency" TYPE varchar(3) USING "currency"::text;
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+DROP TYPE "Currency";
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+-- deposits and withdrawals moved real money through entries and balances, dropping them would
+-- leave the ledger out of balance, so they have to be dealt with by hand first
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM "transfers" WHERE "kind" <> 'transfer') THEN
+    RAISE EXCEPTION 'cannot drop deposits and withdrawals while transfers of those kinds exist';
+  END IF;
+END;
+$$;
+
+DELETE FROM "accounts" WHERE "owner" = 'system_clearing';
+
+DELETE FROM "users" WHERE "username" = 'system_clearing';
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "external_ref";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "kind";
+
+DROP TYPE IF EXISTS "TransferKind";
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE TYPE "TransferKind" AS ENUM (
+  'transfer',
+  'deposit',
+  'withdrawal'
+);
+
+ALTER TABLE "transfers" ADD COLUMN "kind" "TransferKind" NOT NULL DEFAULT 'transfer';
+
+ALTER TABLE "transfers" ADD COLUMN "external_ref" varchar;
+
+COMMENT ON COLUMN "transfers"."external_ref" IS 'reference of the deposit or withdrawal in the external system, unique per kind';
+
+CREATE UNIQUE INDEX ON "transfers" ("kind", "external_ref") WHERE "external_ref" IS NOT NULL;
+
+-- deposits and withdrawals move money against a clearing account per currency, owned by a
+-- user that cannot log in and whose name cannot be registered (usernames are alphanumeric)
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('system_clearing', '!', 'Clearing', 'system_clearing@simplebank.invalid');
+
+-- a migration adding a currency must add its clearing account as well
+INSERT INTO "accounts" ("owner", "balance", "currency", "overdraft_policy")
+SELECT 'system_clearing', 0, "code", 'unlimited' FROM "currencies";
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "account_status_changes";
+
+ALTER TABLE IF EXISTS "accounts" ADD COLUMN "is_frozen" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "accounts"."is_frozen" IS 'set by admins, a frozen account can neither send nor receive transfers';
+
+-- closed accounts have no equivalent, they come back frozen
+UPDATE "accounts" SET "is_frozen" = true WHERE "status" <> 'active';
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "status";
+
+DROP TYPE IF EXISTS "AccountStatus";
+
+COMMIT;
@@ -0,0 +1,35 @@
+BEGIN;
+
+CREATE TYPE "AccountStatus" AS ENUM (
+  'active',
+  'frozen',
+  'closed'
+);
+
+ALTER TABLE "accounts" ADD COLUMN "status" "AccountStatus" NOT NULL DEFAULT 'active';
+
+COMMENT ON COLUMN "accounts"."status" IS 'only active accounts can send or receive transfers, closed is final';
+
+UPDATE "accounts" SET "status" = 'frozen' WHERE "is_frozen";
+
+ALTER TABLE "accounts" DROP COLUMN "is_frozen";
+
+CREATE TABLE "account_status_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "from_status" "AccountStatus" NOT NULL,
+  "to_status" "AccountStatus" NOT NULL,
+  "reason" varchar NOT NULL,
+  "changed_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "account_status_changes"."changed_by" IS 'username of the owner or admin who made the change';
+
+CREATE INDEX ON "account_status_changes" ("account_id");
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("changed_by") REFERENCES "users" ("username");
+
+COMMIT;
@@ -0,0 +1,44 @@
+BEGIN;
+
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "target_type" varchar NOT NULL,
+  "target_id" varchar NOT NULL,
+  "before" jsonb,
+  "after" jsonb,
+  "request_id" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "audit_events"."actor" IS 'username that made the change, system when it did not come from a user';
+
+COMMENT ON COLUMN "audit_events"."before" IS 'snapshot of the target before the change, null when it was created';
+
+COMMENT ON COLUMN "audit_events"."request_id" IS 'X-Request-ID of the API call, empty outside of one';
+
+CREATE INDEX ON "audit_events" ("actor");
+
+CREATE INDEX ON "audit_events" ("target_type", "target_id");
+
+CREATE INDEX ON "audit_events" ("created_at");
+
+-- rows are written in the same transaction as the change they record and never touched again
+CREATE FUNCTION "audit_events_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_no_update_or_delete"
+BEFORE UPDATE OR DELETE ON "audit_events"
+FOR EACH ROW EXECUTE FUNCTION "audit_events_append_only"();
+
+CREATE TRIGGER "audit_events_no_truncate"
+BEFORE TRUNCATE ON "audit_events"
+FOR EACH STATEMENT EXECUTE FUNCTION "audit_events_append_only"();
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "hash";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "prev_hash";
+
+COMMIT;
@@ -0,0 +1,39 @@
+BEGIN;
+
+ALTER TABLE "entries" ADD COLUMN "prev_hash" bytea;
+
+ALTER TABLE "entries" ADD COLUMN "hash" bytea;
+
+COMMENT ON COLUMN "entries"."prev_hash" IS 'hash of the previous entry of the same account, null for the first one';
+
+COMMENT ON COLUMN "entries"."hash" IS 'sha256 of prev_hash, account_id, amount and created_at in microseconds, as big endian int64s';
+
+-- chain the existing entries with the same layout the store uses for new ones
+DO $$
+DECLARE
+  e RECORD;
+  prev bytea;
+  last_account_id bigint;
+BEGIN
+  FOR e IN SELECT "id", "account_id", "amount", "created_at" FROM "entries" ORDER BY "account_id", "id" LOOP
+    IF last_account_id IS DISTINCT FROM e.account_id THEN
+      prev := NULL;
+      last_account_id := e.account_id;
+    END IF;
+
+    UPDATE "entries"
+    SET "prev_hash" = prev,
+        "hash" = sha256(
+          coalesce(prev, ''::bytea)
+          || int8send(e.account_id)
+          || int8send(e.amount)
+          || int8send((extract(epoch FROM e.created_at) * 1000000)::bigint)
+        )
+    WHERE "id" = e.id
+    RETURNING "hash" INTO prev;
+  END LOOP;
+END $$;
+
+ALTER TABLE "entries" ALTER COLUMN "hash" SET NOT NULL;
+
+COMMIT;
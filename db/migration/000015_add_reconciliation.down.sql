@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "reconciliation_discrepancies";
+
+DROP TABLE IF EXISTS "reconciliation_runs";
+
+COMMIT;
@@ -0,0 +1,34 @@
+BEGIN;
+
+CREATE TABLE "reconciliation_runs" (
+  "id" bigserial PRIMARY KEY,
+  "triggered_by" varchar NOT NULL,
+  "accounts_checked" bigint NOT NULL DEFAULT 0,
+  "discrepancies" bigint NOT NULL DEFAULT 0,
+  "started_at" timestamptz NOT NULL DEFAULT 'now()',
+  "finished_at" timestamptz
+);
+
+COMMENT ON COLUMN "reconciliation_runs"."triggered_by" IS 'scheduler, cli or the username of the admin who started it';
+
+COMMENT ON COLUMN "reconciliation_runs"."finished_at" IS 'null while running, or when the run failed';
+
+CREATE TABLE "reconciliation_discrepancies" (
+  "id" bigserial PRIMARY KEY,
+  "run_id" bigint NOT NULL,
+  "account_id" bigint NOT NULL,
+  "balance" bigint NOT NULL,
+  "entries_sum" bigint NOT NULL,
+  "difference" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."difference" IS 'balance - entries_sum';
+
+CREATE INDEX ON "reconciliation_discrepancies" ("run_id");
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("run_id") REFERENCES "reconciliation_runs" ("id");
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "scheduled_transfer_runs";
+
+DROP TABLE IF EXISTS "scheduled_transfers";
+
+DROP TYPE IF EXISTS "ScheduledTransferStatus";
+
+COMMIT;
@@ -0,0 +1,74 @@
+BEGIN;
+
+CREATE TYPE "ScheduledTransferStatus" AS ENUM (
+  'active',
+  'paused',
+  'completed',
+  'failed',
+  'cancelled'
+);
+
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "start_at" timestamptz NOT NULL,
+  "cron" varchar,
+  "interval_seconds" bigint,
+  "status" "ScheduledTransferStatus" NOT NULL DEFAULT 'active',
+  "next_run_at" timestamptz NOT NULL,
+  "next_attempt_at" timestamptz NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()',
+  "updated_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'both accounts hold the same currency, the rate of a future transfer is not known';
+
+COMMENT ON COLUMN "scheduled_transfers"."cron" IS 'five field cron expression in UTC, null unless it recurs on a calendar';
+
+COMMENT ON COLUMN "scheduled_transfers"."interval_seconds" IS 'runs every interval from start_at, null unless it recurs on an interval';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_run_at" IS 'occurrence being run, it only moves once the occurrence succeeded or ran out of attempts';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_attempt_at" IS 'when a worker may pick the row next, pushed back by the worker lease and by retries';
+
+COMMENT ON COLUMN "scheduled_transfers"."attempts" IS 'failed attempts of the current occurrence';
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "single_recurrence" CHECK ("cron" IS NULL OR "interval_seconds" IS NULL);
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("next_attempt_at") WHERE "status" = 'active';
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+CREATE TABLE "scheduled_transfer_runs" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "occurrence" timestamptz NOT NULL,
+  "attempt" int NOT NULL,
+  "transfer_id" bigint,
+  "error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."transfer_id" IS 'set when the attempt succeeded';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."error" IS 'set when the attempt failed';
+
+CREATE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMIT;
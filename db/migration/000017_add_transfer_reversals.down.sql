@@ -0,0 +1,32 @@
+BEGIN;
+
+-- reversals moved money back through entries and balances, dropping them would leave the
+-- ledger out of balance, so they have to be dealt with by hand first
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM "transfers" WHERE "kind" = 'reversal') THEN
+    RAISE EXCEPTION 'cannot drop transfer reversals while reversals exist';
+  END IF;
+END;
+$$;
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "reversed_transfer_id";
+
+-- enum values cannot be dropped, the type is rebuilt without it
+ALTER TYPE "TransferKind" RENAME TO "TransferKind_old";
+
+CREATE TYPE "TransferKind" AS ENUM (
+  'transfer',
+  'deposit',
+  'withdrawal'
+);
+
+ALTER TABLE "transfers" ALTER COLUMN "kind" DROP DEFAULT;
+
+ALTER TABLE "transfers" ALTER COLUMN "kind" TYPE "TransferKind" USING "kind"::text::"TransferKind";
+
+ALTER TABLE "transfers" ALTER COLUMN "kind" SET DEFAULT 'transfer';
+
+DROP TYPE "TransferKind_old";
+
+COMMIT;
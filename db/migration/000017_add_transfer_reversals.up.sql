@@ -0,0 +1,13 @@
+BEGIN;
+
+ALTER TYPE "TransferKind" ADD VALUE 'reversal';
+
+ALTER TABLE "transfers" ADD COLUMN "reversed_transfer_id" bigint;
+
+COMMENT ON COLUMN "transfers"."reversed_transfer_id" IS 'transfer this reversal gives back, the reversals of a transfer never add up to more than its to_amount';
+
+CREATE INDEX ON "transfers" ("reversed_transfer_id") WHERE "reversed_transfer_id" IS NOT NULL;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversed_transfer_id") REFERENCES "transfers" ("id");
+
+COMMIT;
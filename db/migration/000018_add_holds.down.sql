@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TABLE IF EXISTS "holds";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "available_balance";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "held_balance";
+
+DROP TYPE IF EXISTS "HoldStatus";
+
+COMMIT;
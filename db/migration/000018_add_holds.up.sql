@@ -0,0 +1,50 @@
+BEGIN;
+
+CREATE TYPE "HoldStatus" AS ENUM (
+  'active',
+  'captured',
+  'released',
+  'expired'
+);
+
+ALTER TABLE "accounts" ADD COLUMN "held_balance" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "accounts"."held_balance" IS 'sum of the active holds on the account';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "held_balance_non_negative" CHECK ("held_balance" >= 0);
+
+ALTER TABLE "accounts" ADD COLUMN "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held_balance") STORED;
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance minus held_balance, debits and new holds are checked against it';
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" "HoldStatus" NOT NULL DEFAULT 'active',
+  "expires_at" timestamptz NOT NULL,
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()',
+  "updated_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+COMMENT ON COLUMN "holds"."amount" IS 'in the currency of account_id, only positive';
+
+COMMENT ON COLUMN "holds"."expires_at" IS 'an active hold past this time is released by the worker and can no longer be captured';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'transfer made by the capture';
+
+ALTER TABLE "holds" ADD CONSTRAINT "amount_positive" CHECK ("amount" > 0);
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("expires_at") WHERE "status" = 'active';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+CREATE TABLE "api_keys" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "name" varchar NOT NULL,
+  "prefix" varchar UNIQUE NOT NULL,
+  "hashed_key" varchar NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "expires_at" timestamptz,
+  "last_used_at" timestamptz,
+  "revoked_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT 'now()'
+);
+
+CREATE INDEX ON "api_keys" ("username");
+
+COMMENT ON COLUMN "api_keys"."prefix" IS 'public part of the key, shown in listings and used to look it up';
+
+COMMENT ON COLUMN "api_keys"."hashed_key" IS 'SHA-256 of the whole key, the key itself is only shown when it is created';
+
+COMMENT ON COLUMN "api_keys"."expires_at" IS 'NULL for a key that does not expire';
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "api_keys" ADD CONSTRAINT "api_keys_scopes_not_empty" CHECK (cardinality("scopes") > 0);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "last_entry_hash";
+
+COMMIT;
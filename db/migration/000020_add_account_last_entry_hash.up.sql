@@ -0,0 +1,15 @@
+BEGIN;
+
+ALTER TABLE "accounts" ADD COLUMN "last_entry_hash" bytea;
+
+COMMENT ON COLUMN "accounts"."last_entry_hash" IS 'hash of the newest entry of the account, null before the first one, a chain that does not end on it lost entries';
+
+UPDATE "accounts" a
+SET "last_entry_hash" = (
+  SELECT e."hash" FROM "entries" e
+  WHERE e."account_id" = a."id"
+  ORDER BY e."id" DESC
+  LIMIT 1
+);
+
+COMMIT;
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	db "github.com/suryansh74/simplebank/db"
 	sqlc "github.com/suryansh74/simplebank/db/sqlc"
 )
@@ -51,183 +52,1111 @@ func (mr *MockStoreMockRecorder) AddAccountBalance(ctx, arg interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), ctx, arg)
 }
 
+// AddAccountHeldBalance mocks base method.
+func (m *MockStore) AddAccountHeldBalance(ctx context.Context, arg sqlc.AddAccountHeldBalanceParams) (sqlc.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountHeldBalance", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountHeldBalance indicates an expected call of AddAccountHeldBalance.
+func (mr *MockStoreMockRecorder) AddAccountHeldBalance(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountHeldBalance", reflect.TypeOf((*MockStore)(nil).AddAccountHeldBalance), ctx, arg)
+}
+
+// BatchTransferTx mocks base method.
+func (m *MockStore) BatchTransferTx(ctx context.Context, arg db.BatchTransferTxParams) (db.BatchTransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchTransferTx", ctx, arg)
+	ret0, _ := ret[0].(db.BatchTransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchTransferTx indicates an expected call of BatchTransferTx.
+func (mr *MockStoreMockRecorder) BatchTransferTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchTransferTx", reflect.TypeOf((*MockStore)(nil).BatchTransferTx), ctx, arg)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(ctx context.Context, id uuid.UUID) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", ctx, id)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), ctx, id)
+}
+
+// BlockSessionTx mocks base method.
+func (m *MockStore) BlockSessionTx(ctx context.Context, id uuid.UUID) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSessionTx", ctx, id)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSessionTx indicates an expected call of BlockSessionTx.
+func (mr *MockStoreMockRecorder) BlockSessionTx(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSessionTx", reflect.TypeOf((*MockStore)(nil).BlockSessionTx), ctx, id)
+}
+
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(ctx context.Context, username string) ([]sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", ctx, username)
+	ret0, _ := ret[0].([]sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), ctx, username)
+}
+
+// BlockUserSessionsTx mocks base method.
+func (m *MockStore) BlockUserSessionsTx(ctx context.Context, username string) ([]sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessionsTx", ctx, username)
+	ret0, _ := ret[0].([]sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockUserSessionsTx indicates an expected call of BlockUserSessionsTx.
+func (mr *MockStoreMockRecorder) BlockUserSessionsTx(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessionsTx", reflect.TypeOf((*MockStore)(nil).BlockUserSessionsTx), ctx, username)
+}
+
+// CaptureHoldTx mocks base method.
+func (m *MockStore) CaptureHoldTx(ctx context.Context, arg db.CaptureHoldTxParams) (db.CaptureHoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHoldTx", ctx, arg)
+	ret0, _ := ret[0].(db.CaptureHoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHoldTx indicates an expected call of CaptureHoldTx.
+func (mr *MockStoreMockRecorder) CaptureHoldTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHoldTx", reflect.TypeOf((*MockStore)(nil).CaptureHoldTx), ctx, arg)
+}
+
+// ChangeAccountStatusTx mocks base method.
+func (m *MockStore) ChangeAccountStatusTx(ctx context.Context, arg db.ChangeAccountStatusTxParams) (db.ChangeAccountStatusTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeAccountStatusTx", ctx, arg)
+	ret0, _ := ret[0].(db.ChangeAccountStatusTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeAccountStatusTx indicates an expected call of ChangeAccountStatusTx.
+func (mr *MockStoreMockRecorder) ChangeAccountStatusTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeAccountStatusTx", reflect.TypeOf((*MockStore)(nil).ChangeAccountStatusTx), ctx, arg)
+}
+
+// ClaimDueScheduledTransfers mocks base method.
+func (m *MockStore) ClaimDueScheduledTransfers(ctx context.Context, arg sqlc.ClaimDueScheduledTransfersParams) ([]sqlc.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDueScheduledTransfers", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDueScheduledTransfers indicates an expected call of ClaimDueScheduledTransfers.
+func (mr *MockStoreMockRecorder) ClaimDueScheduledTransfers(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueScheduledTransfers", reflect.TypeOf((*MockStore)(nil).ClaimDueScheduledTransfers), ctx, arg)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockStore) CreateAPIKey(ctx context.Context, arg sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockStoreMockRecorder) CreateAPIKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockStore)(nil).CreateAPIKey), ctx, arg)
+}
+
+// CreateAPIKeyTx mocks base method.
+func (m *MockStore) CreateAPIKeyTx(ctx context.Context, arg sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKeyTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKeyTx indicates an expected call of CreateAPIKeyTx.
+func (mr *MockStoreMockRecorder) CreateAPIKeyTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKeyTx", reflect.TypeOf((*MockStore)(nil).CreateAPIKeyTx), ctx, arg)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(ctx context.Context, arg sqlc.CreateAccountParams) (sqlc.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", ctx, arg)
-	ret0, _ := ret[0].(sqlc.Account)
+	ret := m.ctrl.Call(m, "CreateAccount", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockStoreMockRecorder) CreateAccount(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), ctx, arg)
+}
+
+// CreateAccountStatusChange mocks base method.
+func (m *MockStore) CreateAccountStatusChange(ctx context.Context, arg sqlc.CreateAccountStatusChangeParams) (sqlc.AccountStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountStatusChange", ctx, arg)
+	ret0, _ := ret[0].(sqlc.AccountStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountStatusChange indicates an expected call of CreateAccountStatusChange.
+func (mr *MockStoreMockRecorder) CreateAccountStatusChange(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountStatusChange", reflect.TypeOf((*MockStore)(nil).CreateAccountStatusChange), ctx, arg)
+}
+
+// CreateAccountTx mocks base method.
+func (m *MockStore) CreateAccountTx(ctx context.Context, arg sqlc.CreateAccountParams) (sqlc.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccountTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccountTx indicates an expected call of CreateAccountTx.
+func (mr *MockStoreMockRecorder) CreateAccountTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountTx", reflect.TypeOf((*MockStore)(nil).CreateAccountTx), ctx, arg)
+}
+
+// CreateAuditEvent mocks base method.
+func (m *MockStore) CreateAuditEvent(ctx context.Context, arg sqlc.CreateAuditEventParams) (sqlc.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEvent", ctx, arg)
+	ret0, _ := ret[0].(sqlc.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditEvent indicates an expected call of CreateAuditEvent.
+func (mr *MockStoreMockRecorder) CreateAuditEvent(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEvent", reflect.TypeOf((*MockStore)(nil).CreateAuditEvent), ctx, arg)
+}
+
+// CreateEntry mocks base method.
+func (m *MockStore) CreateEntry(ctx context.Context, arg sqlc.CreateEntryParams) (sqlc.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEntry", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEntry indicates an expected call of CreateEntry.
+func (mr *MockStoreMockRecorder) CreateEntry(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), ctx, arg)
+}
+
+// CreateHold mocks base method.
+func (m *MockStore) CreateHold(ctx context.Context, arg sqlc.CreateHoldParams) (sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockStoreMockRecorder) CreateHold(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockStore)(nil).CreateHold), ctx, arg)
+}
+
+// CreateIdempotencyKey mocks base method.
+func (m *MockStore) CreateIdempotencyKey(ctx context.Context, arg sqlc.CreateIdempotencyKeyParams) (sqlc.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(sqlc.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockStoreMockRecorder) CreateIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockStore)(nil).CreateIdempotencyKey), ctx, arg)
+}
+
+// CreateReconciliationDiscrepancy mocks base method.
+func (m *MockStore) CreateReconciliationDiscrepancy(ctx context.Context, arg sqlc.CreateReconciliationDiscrepancyParams) (sqlc.ReconciliationDiscrepancy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReconciliationDiscrepancy", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ReconciliationDiscrepancy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReconciliationDiscrepancy indicates an expected call of CreateReconciliationDiscrepancy.
+func (mr *MockStoreMockRecorder) CreateReconciliationDiscrepancy(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReconciliationDiscrepancy", reflect.TypeOf((*MockStore)(nil).CreateReconciliationDiscrepancy), ctx, arg)
+}
+
+// CreateReconciliationDiscrepancyTx mocks base method.
+func (m *MockStore) CreateReconciliationDiscrepancyTx(ctx context.Context, arg sqlc.CreateReconciliationDiscrepancyParams) (sqlc.ReconciliationDiscrepancy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReconciliationDiscrepancyTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ReconciliationDiscrepancy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReconciliationDiscrepancyTx indicates an expected call of CreateReconciliationDiscrepancyTx.
+func (mr *MockStoreMockRecorder) CreateReconciliationDiscrepancyTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReconciliationDiscrepancyTx", reflect.TypeOf((*MockStore)(nil).CreateReconciliationDiscrepancyTx), ctx, arg)
+}
+
+// CreateReconciliationRun mocks base method.
+func (m *MockStore) CreateReconciliationRun(ctx context.Context, triggeredBy string) (sqlc.ReconciliationRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReconciliationRun", ctx, triggeredBy)
+	ret0, _ := ret[0].(sqlc.ReconciliationRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReconciliationRun indicates an expected call of CreateReconciliationRun.
+func (mr *MockStoreMockRecorder) CreateReconciliationRun(ctx, triggeredBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReconciliationRun", reflect.TypeOf((*MockStore)(nil).CreateReconciliationRun), ctx, triggeredBy)
+}
+
+// CreateReconciliationRunTx mocks base method.
+func (m *MockStore) CreateReconciliationRunTx(ctx context.Context, triggeredBy string) (sqlc.ReconciliationRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReconciliationRunTx", ctx, triggeredBy)
+	ret0, _ := ret[0].(sqlc.ReconciliationRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReconciliationRunTx indicates an expected call of CreateReconciliationRunTx.
+func (mr *MockStoreMockRecorder) CreateReconciliationRunTx(ctx, triggeredBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReconciliationRunTx", reflect.TypeOf((*MockStore)(nil).CreateReconciliationRunTx), ctx, triggeredBy)
+}
+
+// CreateRevokedToken mocks base method.
+func (m *MockStore) CreateRevokedToken(ctx context.Context, arg sqlc.CreateRevokedTokenParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRevokedToken", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRevokedToken indicates an expected call of CreateRevokedToken.
+func (mr *MockStoreMockRecorder) CreateRevokedToken(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRevokedToken", reflect.TypeOf((*MockStore)(nil).CreateRevokedToken), ctx, arg)
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockStore) CreateScheduledTransfer(ctx context.Context, arg sqlc.CreateScheduledTransferParams) (sqlc.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockStoreMockRecorder) CreateScheduledTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransfer), ctx, arg)
+}
+
+// CreateScheduledTransferRun mocks base method.
+func (m *MockStore) CreateScheduledTransferRun(ctx context.Context, arg sqlc.CreateScheduledTransferRunParams) (sqlc.ScheduledTransferRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransferRun", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ScheduledTransferRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransferRun indicates an expected call of CreateScheduledTransferRun.
+func (mr *MockStoreMockRecorder) CreateScheduledTransferRun(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransferRun", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransferRun), ctx, arg)
+}
+
+// CreateScheduledTransferTx mocks base method.
+func (m *MockStore) CreateScheduledTransferTx(ctx context.Context, arg sqlc.CreateScheduledTransferParams) (sqlc.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransferTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransferTx indicates an expected call of CreateScheduledTransferTx.
+func (mr *MockStoreMockRecorder) CreateScheduledTransferTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransferTx", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransferTx), ctx, arg)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), ctx, arg)
+}
+
+// CreateSessionTx mocks base method.
+func (m *MockStore) CreateSessionTx(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSessionTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSessionTx indicates an expected call of CreateSessionTx.
+func (mr *MockStoreMockRecorder) CreateSessionTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSessionTx", reflect.TypeOf((*MockStore)(nil).CreateSessionTx), ctx, arg)
+}
+
+// CreateTransfer mocks base method.
+func (m *MockStore) CreateTransfer(ctx context.Context, arg sqlc.CreateTransferParams) (sqlc.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransfer", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransfer indicates an expected call of CreateTransfer.
+func (mr *MockStoreMockRecorder) CreateTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), ctx, arg)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, arg)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), ctx, arg)
+}
+
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), ctx, arg)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockStore) DeleteAccount(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockStoreMockRecorder) DeleteAccount(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), ctx, id)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockStore) DeleteIdempotencyKey(ctx context.Context, arg sqlc.DeleteIdempotencyKeyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockStoreMockRecorder) DeleteIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockStore)(nil).DeleteIdempotencyKey), ctx, arg)
+}
+
+// DepositTx mocks base method.
+func (m *MockStore) DepositTx(ctx context.Context, arg db.DepositTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DepositTx", ctx, arg)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DepositTx indicates an expected call of DepositTx.
+func (mr *MockStoreMockRecorder) DepositTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepositTx", reflect.TypeOf((*MockStore)(nil).DepositTx), ctx, arg)
+}
+
+// ExpireHoldTx mocks base method.
+func (m *MockStore) ExpireHoldTx(ctx context.Context) (sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHoldTx", ctx)
+	ret0, _ := ret[0].(sqlc.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHoldTx indicates an expected call of ExpireHoldTx.
+func (mr *MockStoreMockRecorder) ExpireHoldTx(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHoldTx", reflect.TypeOf((*MockStore)(nil).ExpireHoldTx), ctx)
+}
+
+// FinishReconciliationRun mocks base method.
+func (m *MockStore) FinishReconciliationRun(ctx context.Context, arg sqlc.FinishReconciliationRunParams) (sqlc.ReconciliationRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishReconciliationRun", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ReconciliationRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishReconciliationRun indicates an expected call of FinishReconciliationRun.
+func (mr *MockStoreMockRecorder) FinishReconciliationRun(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishReconciliationRun", reflect.TypeOf((*MockStore)(nil).FinishReconciliationRun), ctx, arg)
+}
+
+// FinishReconciliationRunTx mocks base method.
+func (m *MockStore) FinishReconciliationRunTx(ctx context.Context, arg sqlc.FinishReconciliationRunParams) (sqlc.ReconciliationRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishReconciliationRunTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ReconciliationRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishReconciliationRunTx indicates an expected call of FinishReconciliationRunTx.
+func (mr *MockStoreMockRecorder) FinishReconciliationRunTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishReconciliationRunTx", reflect.TypeOf((*MockStore)(nil).FinishReconciliationRunTx), ctx, arg)
+}
+
+// GetAPIKey mocks base method.
+func (m *MockStore) GetAPIKey(ctx context.Context, id int64) (sqlc.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", ctx, id)
+	ret0, _ := ret[0].(sqlc.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockStoreMockRecorder) GetAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockStore)(nil).GetAPIKey), ctx, id)
+}
+
+// GetAPIKeyByPrefix mocks base method.
+func (m *MockStore) GetAPIKeyByPrefix(ctx context.Context, prefix string) (sqlc.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByPrefix", ctx, prefix)
+	ret0, _ := ret[0].(sqlc.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByPrefix indicates an expected call of GetAPIKeyByPrefix.
+func (mr *MockStoreMockRecorder) GetAPIKeyByPrefix(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByPrefix", reflect.TypeOf((*MockStore)(nil).GetAPIKeyByPrefix), ctx, prefix)
+}
+
+// GetAccount mocks base method.
+func (m *MockStore) GetAccount(ctx context.Context, id int64) (sqlc.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", ctx, id)
+	ret0, _ := ret[0].(sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockStoreMockRecorder) GetAccount(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), ctx, id)
+}
+
+// GetAccountForUpdate mocks base method.
+func (m *MockStore) GetAccountForUpdate(ctx context.Context, id int64) (sqlc.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountForUpdate", ctx, id)
+	ret0, _ := ret[0].(sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountForUpdate indicates an expected call of GetAccountForUpdate.
+func (mr *MockStoreMockRecorder) GetAccountForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), ctx, id)
+}
+
+// GetClearingAccount mocks base method.
+func (m *MockStore) GetClearingAccount(ctx context.Context, currency string) (sqlc.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClearingAccount", ctx, currency)
+	ret0, _ := ret[0].(sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClearingAccount indicates an expected call of GetClearingAccount.
+func (mr *MockStoreMockRecorder) GetClearingAccount(ctx, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClearingAccount", reflect.TypeOf((*MockStore)(nil).GetClearingAccount), ctx, currency)
+}
+
+// GetCurrency mocks base method.
+func (m *MockStore) GetCurrency(ctx context.Context, code string) (sqlc.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrency", ctx, code)
+	ret0, _ := ret[0].(sqlc.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrency indicates an expected call of GetCurrency.
+func (mr *MockStoreMockRecorder) GetCurrency(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrency", reflect.TypeOf((*MockStore)(nil).GetCurrency), ctx, code)
+}
+
+// GetEntry mocks base method.
+func (m *MockStore) GetEntry(ctx context.Context, id int64) (sqlc.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntry", ctx, id)
+	ret0, _ := ret[0].(sqlc.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntry indicates an expected call of GetEntry.
+func (mr *MockStoreMockRecorder) GetEntry(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), ctx, id)
+}
+
+// GetExpiredHoldForUpdate mocks base method.
+func (m *MockStore) GetExpiredHoldForUpdate(ctx context.Context) (sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredHoldForUpdate", ctx)
+	ret0, _ := ret[0].(sqlc.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredHoldForUpdate indicates an expected call of GetExpiredHoldForUpdate.
+func (mr *MockStoreMockRecorder) GetExpiredHoldForUpdate(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredHoldForUpdate", reflect.TypeOf((*MockStore)(nil).GetExpiredHoldForUpdate), ctx)
+}
+
+// GetHold mocks base method.
+func (m *MockStore) GetHold(ctx context.Context, id int64) (sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, id)
+	ret0, _ := ret[0].(sqlc.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockStoreMockRecorder) GetHold(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockStore)(nil).GetHold), ctx, id)
+}
+
+// GetHoldForUpdate mocks base method.
+func (m *MockStore) GetHoldForUpdate(ctx context.Context, id int64) (sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHoldForUpdate", ctx, id)
+	ret0, _ := ret[0].(sqlc.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHoldForUpdate indicates an expected call of GetHoldForUpdate.
+func (mr *MockStoreMockRecorder) GetHoldForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHoldForUpdate", reflect.TypeOf((*MockStore)(nil).GetHoldForUpdate), ctx, id)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockStore) GetIdempotencyKey(ctx context.Context, arg sqlc.GetIdempotencyKeyParams) (sqlc.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, arg)
+	ret0, _ := ret[0].(sqlc.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStoreMockRecorder) GetIdempotencyKey(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStore)(nil).GetIdempotencyKey), ctx, arg)
+}
+
+// GetLastEntry mocks base method.
+func (m *MockStore) GetLastEntry(ctx context.Context, accountID int64) (sqlc.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastEntry", ctx, accountID)
+	ret0, _ := ret[0].(sqlc.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastEntry indicates an expected call of GetLastEntry.
+func (mr *MockStoreMockRecorder) GetLastEntry(ctx, accountID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEntry", reflect.TypeOf((*MockStore)(nil).GetLastEntry), ctx, accountID)
+}
+
+// GetReconciliationRun mocks base method.
+func (m *MockStore) GetReconciliationRun(ctx context.Context, id int64) (sqlc.ReconciliationRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReconciliationRun", ctx, id)
+	ret0, _ := ret[0].(sqlc.ReconciliationRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReconciliationRun indicates an expected call of GetReconciliationRun.
+func (mr *MockStoreMockRecorder) GetReconciliationRun(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReconciliationRun", reflect.TypeOf((*MockStore)(nil).GetReconciliationRun), ctx, id)
+}
+
+// GetReversedAmount mocks base method.
+func (m *MockStore) GetReversedAmount(ctx context.Context, transferID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReversedAmount", ctx, transferID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReversedAmount indicates an expected call of GetReversedAmount.
+func (mr *MockStoreMockRecorder) GetReversedAmount(ctx, transferID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReversedAmount", reflect.TypeOf((*MockStore)(nil).GetReversedAmount), ctx, transferID)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockStore) GetScheduledTransfer(ctx context.Context, id int64) (sqlc.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", ctx, id)
+	ret0, _ := ret[0].(sqlc.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockStoreMockRecorder) GetScheduledTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockStore)(nil).GetScheduledTransfer), ctx, id)
+}
+
+// GetScheduledTransferForUpdate mocks base method.
+func (m *MockStore) GetScheduledTransferForUpdate(ctx context.Context, id int64) (sqlc.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransferForUpdate", ctx, id)
+	ret0, _ := ret[0].(sqlc.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransferForUpdate indicates an expected call of GetScheduledTransferForUpdate.
+func (mr *MockStoreMockRecorder) GetScheduledTransferForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransferForUpdate", reflect.TypeOf((*MockStore)(nil).GetScheduledTransferForUpdate), ctx, id)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(ctx context.Context, id uuid.UUID) (sqlc.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, id)
+	ret0, _ := ret[0].(sqlc.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), ctx, id)
+}
+
+// GetTransfer mocks base method.
+func (m *MockStore) GetTransfer(ctx context.Context, id int64) (sqlc.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", ctx, id)
+	ret0, _ := ret[0].(sqlc.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockStoreMockRecorder) GetTransfer(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), ctx, id)
+}
+
+// GetTransferForUpdate mocks base method.
+func (m *MockStore) GetTransferForUpdate(ctx context.Context, id int64) (sqlc.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferForUpdate", ctx, id)
+	ret0, _ := ret[0].(sqlc.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferForUpdate indicates an expected call of GetTransferForUpdate.
+func (mr *MockStoreMockRecorder) GetTransferForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferForUpdate", reflect.TypeOf((*MockStore)(nil).GetTransferForUpdate), ctx, id)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(ctx context.Context, username string) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), ctx, username)
+}
+
+// IsTokenRevoked mocks base method.
+func (m *MockStore) IsTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsTokenRevoked", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockStoreMockRecorder) IsTokenRevoked(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockStore)(nil).IsTokenRevoked), ctx, id)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockStore) ListAPIKeys(ctx context.Context, username string) ([]sqlc.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx, username)
+	ret0, _ := ret[0].([]sqlc.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockStoreMockRecorder) ListAPIKeys(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockStore)(nil).ListAPIKeys), ctx, username)
+}
+
+// ListAccountBalanceSums mocks base method.
+func (m *MockStore) ListAccountBalanceSums(ctx context.Context, arg sqlc.ListAccountBalanceSumsParams) ([]sqlc.ListAccountBalanceSumsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountBalanceSums", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ListAccountBalanceSumsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountBalanceSums indicates an expected call of ListAccountBalanceSums.
+func (mr *MockStoreMockRecorder) ListAccountBalanceSums(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountBalanceSums", reflect.TypeOf((*MockStore)(nil).ListAccountBalanceSums), ctx, arg)
+}
+
+// ListAccountChain mocks base method.
+func (m *MockStore) ListAccountChain(ctx context.Context, arg sqlc.ListAccountChainParams) ([]sqlc.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountChain", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountChain indicates an expected call of ListAccountChain.
+func (mr *MockStoreMockRecorder) ListAccountChain(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountChain", reflect.TypeOf((*MockStore)(nil).ListAccountChain), ctx, arg)
+}
+
+// ListAccountEntries mocks base method.
+func (m *MockStore) ListAccountEntries(ctx context.Context, arg sqlc.ListAccountEntriesParams) ([]sqlc.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntries", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntries indicates an expected call of ListAccountEntries.
+func (mr *MockStoreMockRecorder) ListAccountEntries(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntries", reflect.TypeOf((*MockStore)(nil).ListAccountEntries), ctx, arg)
+}
+
+// ListAccountHolds mocks base method.
+func (m *MockStore) ListAccountHolds(ctx context.Context, arg sqlc.ListAccountHoldsParams) ([]sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountHolds", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.Hold)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockStoreMockRecorder) CreateAccount(ctx, arg interface{}) *gomock.Call {
+// ListAccountHolds indicates an expected call of ListAccountHolds.
+func (mr *MockStoreMockRecorder) ListAccountHolds(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountHolds", reflect.TypeOf((*MockStore)(nil).ListAccountHolds), ctx, arg)
 }
 
-// CreateEntry mocks base method.
-func (m *MockStore) CreateEntry(ctx context.Context, arg sqlc.CreateEntryParams) (sqlc.Entry, error) {
+// ListAccountIDs mocks base method.
+func (m *MockStore) ListAccountIDs(ctx context.Context, arg sqlc.ListAccountIDsParams) ([]int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateEntry", ctx, arg)
-	ret0, _ := ret[0].(sqlc.Entry)
+	ret := m.ctrl.Call(m, "ListAccountIDs", ctx, arg)
+	ret0, _ := ret[0].([]int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateEntry indicates an expected call of CreateEntry.
-func (mr *MockStoreMockRecorder) CreateEntry(ctx, arg interface{}) *gomock.Call {
+// ListAccountIDs indicates an expected call of ListAccountIDs.
+func (mr *MockStoreMockRecorder) ListAccountIDs(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountIDs", reflect.TypeOf((*MockStore)(nil).ListAccountIDs), ctx, arg)
 }
 
-// CreateTransfer mocks base method.
-func (m *MockStore) CreateTransfer(ctx context.Context, arg sqlc.CreateTransferParams) (sqlc.Transfer, error) {
+// ListAccountStatusChanges mocks base method.
+func (m *MockStore) ListAccountStatusChanges(ctx context.Context, accountID int64) ([]sqlc.AccountStatusChange, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTransfer", ctx, arg)
-	ret0, _ := ret[0].(sqlc.Transfer)
+	ret := m.ctrl.Call(m, "ListAccountStatusChanges", ctx, accountID)
+	ret0, _ := ret[0].([]sqlc.AccountStatusChange)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateTransfer indicates an expected call of CreateTransfer.
-func (mr *MockStoreMockRecorder) CreateTransfer(ctx, arg interface{}) *gomock.Call {
+// ListAccountStatusChanges indicates an expected call of ListAccountStatusChanges.
+func (mr *MockStoreMockRecorder) ListAccountStatusChanges(ctx, accountID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountStatusChanges", reflect.TypeOf((*MockStore)(nil).ListAccountStatusChanges), ctx, accountID)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error) {
+// ListAccountTransfers mocks base method.
+func (m *MockStore) ListAccountTransfers(ctx context.Context, arg sqlc.ListAccountTransfersParams) ([]sqlc.Transfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", ctx, arg)
-	ret0, _ := ret[0].(sqlc.User)
+	ret := m.ctrl.Call(m, "ListAccountTransfers", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.Transfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(ctx, arg interface{}) *gomock.Call {
+// ListAccountTransfers indicates an expected call of ListAccountTransfers.
+func (mr *MockStoreMockRecorder) ListAccountTransfers(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountTransfers", reflect.TypeOf((*MockStore)(nil).ListAccountTransfers), ctx, arg)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockStore) DeleteAccount(ctx context.Context, id int64) error {
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(ctx context.Context, arg sqlc.ListAccountsParams) ([]sqlc.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ListAccounts", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockStoreMockRecorder) DeleteAccount(ctx, id interface{}) *gomock.Call {
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), ctx, arg)
 }
 
-// GetAccount mocks base method.
-func (m *MockStore) GetAccount(ctx context.Context, id int64) (sqlc.Account, error) {
+// ListAuditEvents mocks base method.
+func (m *MockStore) ListAuditEvents(ctx context.Context, arg sqlc.ListAuditEventsParams) ([]sqlc.AuditEvent, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", ctx, id)
-	ret0, _ := ret[0].(sqlc.Account)
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.AuditEvent)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockStoreMockRecorder) GetAccount(ctx, id interface{}) *gomock.Call {
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockStoreMockRecorder) ListAuditEvents(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockStore)(nil).ListAuditEvents), ctx, arg)
 }
 
-// GetAccountForUpdate mocks base method.
-func (m *MockStore) GetAccountForUpdate(ctx context.Context, id int64) (sqlc.Account, error) {
+// ListCurrencies mocks base method.
+func (m *MockStore) ListCurrencies(ctx context.Context) ([]sqlc.Currency, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountForUpdate", ctx, id)
-	ret0, _ := ret[0].(sqlc.Account)
+	ret := m.ctrl.Call(m, "ListCurrencies", ctx)
+	ret0, _ := ret[0].([]sqlc.Currency)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountForUpdate indicates an expected call of GetAccountForUpdate.
-func (mr *MockStoreMockRecorder) GetAccountForUpdate(ctx, id interface{}) *gomock.Call {
+// ListCurrencies indicates an expected call of ListCurrencies.
+func (mr *MockStoreMockRecorder) ListCurrencies(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForUpdate", reflect.TypeOf((*MockStore)(nil).GetAccountForUpdate), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCurrencies", reflect.TypeOf((*MockStore)(nil).ListCurrencies), ctx)
 }
 
-// GetEntry mocks base method.
-func (m *MockStore) GetEntry(ctx context.Context, id int64) (sqlc.Entry, error) {
+// ListEntrys mocks base method.
+func (m *MockStore) ListEntrys(ctx context.Context, arg sqlc.ListEntrysParams) ([]sqlc.Entry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEntry", ctx, id)
-	ret0, _ := ret[0].(sqlc.Entry)
+	ret := m.ctrl.Call(m, "ListEntrys", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.Entry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetEntry indicates an expected call of GetEntry.
-func (mr *MockStoreMockRecorder) GetEntry(ctx, id interface{}) *gomock.Call {
+// ListEntrys indicates an expected call of ListEntrys.
+func (mr *MockStoreMockRecorder) ListEntrys(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntrys", reflect.TypeOf((*MockStore)(nil).ListEntrys), ctx, arg)
 }
 
-// GetTransfer mocks base method.
-func (m *MockStore) GetTransfer(ctx context.Context, id int64) (sqlc.Transfer, error) {
+// ListReconciliationDiscrepancies mocks base method.
+func (m *MockStore) ListReconciliationDiscrepancies(ctx context.Context, runID int64) ([]sqlc.ReconciliationDiscrepancy, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfer", ctx, id)
-	ret0, _ := ret[0].(sqlc.Transfer)
+	ret := m.ctrl.Call(m, "ListReconciliationDiscrepancies", ctx, runID)
+	ret0, _ := ret[0].([]sqlc.ReconciliationDiscrepancy)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfer indicates an expected call of GetTransfer.
-func (mr *MockStoreMockRecorder) GetTransfer(ctx, id interface{}) *gomock.Call {
+// ListReconciliationDiscrepancies indicates an expected call of ListReconciliationDiscrepancies.
+func (mr *MockStoreMockRecorder) ListReconciliationDiscrepancies(ctx, runID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReconciliationDiscrepancies", reflect.TypeOf((*MockStore)(nil).ListReconciliationDiscrepancies), ctx, runID)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(ctx context.Context, username string) (sqlc.User, error) {
+// ListReconciliationRuns mocks base method.
+func (m *MockStore) ListReconciliationRuns(ctx context.Context, arg sqlc.ListReconciliationRunsParams) ([]sqlc.ReconciliationRun, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", ctx, username)
-	ret0, _ := ret[0].(sqlc.User)
+	ret := m.ctrl.Call(m, "ListReconciliationRuns", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ReconciliationRun)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+// ListReconciliationRuns indicates an expected call of ListReconciliationRuns.
+func (mr *MockStoreMockRecorder) ListReconciliationRuns(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReconciliationRuns", reflect.TypeOf((*MockStore)(nil).ListReconciliationRuns), ctx, arg)
 }
 
-// ListAccounts mocks base method.
-func (m *MockStore) ListAccounts(ctx context.Context, arg sqlc.ListAccountsParams) ([]sqlc.Account, error) {
+// ListScheduledTransferRuns mocks base method.
+func (m *MockStore) ListScheduledTransferRuns(ctx context.Context, arg sqlc.ListScheduledTransferRunsParams) ([]sqlc.ScheduledTransferRun, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", ctx, arg)
-	ret0, _ := ret[0].([]sqlc.Account)
+	ret := m.ctrl.Call(m, "ListScheduledTransferRuns", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ScheduledTransferRun)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockStoreMockRecorder) ListAccounts(ctx, arg interface{}) *gomock.Call {
+// ListScheduledTransferRuns indicates an expected call of ListScheduledTransferRuns.
+func (mr *MockStoreMockRecorder) ListScheduledTransferRuns(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransferRuns", reflect.TypeOf((*MockStore)(nil).ListScheduledTransferRuns), ctx, arg)
 }
 
-// ListEntrys mocks base method.
-func (m *MockStore) ListEntrys(ctx context.Context, arg sqlc.ListEntrysParams) ([]sqlc.Entry, error) {
+// ListScheduledTransfers mocks base method.
+func (m *MockStore) ListScheduledTransfers(ctx context.Context, arg sqlc.ListScheduledTransfersParams) ([]sqlc.ScheduledTransfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEntrys", ctx, arg)
-	ret0, _ := ret[0].([]sqlc.Entry)
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", ctx, arg)
+	ret0, _ := ret[0].([]sqlc.ScheduledTransfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListEntrys indicates an expected call of ListEntrys.
-func (mr *MockStoreMockRecorder) ListEntrys(ctx, arg interface{}) *gomock.Call {
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockStoreMockRecorder) ListScheduledTransfers(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntrys", reflect.TypeOf((*MockStore)(nil).ListEntrys), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockStore)(nil).ListScheduledTransfers), ctx, arg)
 }
 
 // ListTransfers mocks base method.
@@ -245,6 +1174,168 @@ func (mr *MockStoreMockRecorder) ListTransfers(ctx, arg interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), ctx, arg)
 }
 
+// PlaceHoldTx mocks base method.
+func (m *MockStore) PlaceHoldTx(ctx context.Context, arg sqlc.CreateHoldParams) (sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlaceHoldTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlaceHoldTx indicates an expected call of PlaceHoldTx.
+func (mr *MockStoreMockRecorder) PlaceHoldTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlaceHoldTx", reflect.TypeOf((*MockStore)(nil).PlaceHoldTx), ctx, arg)
+}
+
+// ReleaseHoldTx mocks base method.
+func (m *MockStore) ReleaseHoldTx(ctx context.Context, holdID int64) (sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHoldTx", ctx, holdID)
+	ret0, _ := ret[0].(sqlc.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseHoldTx indicates an expected call of ReleaseHoldTx.
+func (mr *MockStoreMockRecorder) ReleaseHoldTx(ctx, holdID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHoldTx", reflect.TypeOf((*MockStore)(nil).ReleaseHoldTx), ctx, holdID)
+}
+
+// RescheduleScheduledTransfer mocks base method.
+func (m *MockStore) RescheduleScheduledTransfer(ctx context.Context, arg sqlc.RescheduleScheduledTransferParams) (sqlc.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RescheduleScheduledTransfer indicates an expected call of RescheduleScheduledTransfer.
+func (mr *MockStoreMockRecorder) RescheduleScheduledTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleScheduledTransfer", reflect.TypeOf((*MockStore)(nil).RescheduleScheduledTransfer), ctx, arg)
+}
+
+// ReverseTransferTx mocks base method.
+func (m *MockStore) ReverseTransferTx(ctx context.Context, arg db.ReverseTransferTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransferTx", ctx, arg)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransferTx indicates an expected call of ReverseTransferTx.
+func (mr *MockStoreMockRecorder) ReverseTransferTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransferTx", reflect.TypeOf((*MockStore)(nil).ReverseTransferTx), ctx, arg)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockStore) RevokeAPIKey(ctx context.Context, id int64) (sqlc.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(sqlc.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockStoreMockRecorder) RevokeAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockStore)(nil).RevokeAPIKey), ctx, id)
+}
+
+// RevokeAPIKeyTx mocks base method.
+func (m *MockStore) RevokeAPIKeyTx(ctx context.Context, id int64) (sqlc.ApiKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKeyTx", ctx, id)
+	ret0, _ := ret[0].(sqlc.ApiKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKeyTx indicates an expected call of RevokeAPIKeyTx.
+func (mr *MockStoreMockRecorder) RevokeAPIKeyTx(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKeyTx", reflect.TypeOf((*MockStore)(nil).RevokeAPIKeyTx), ctx, id)
+}
+
+// RevokeTokenTx mocks base method.
+func (m *MockStore) RevokeTokenTx(ctx context.Context, arg sqlc.CreateRevokedTokenParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeTokenTx", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeTokenTx indicates an expected call of RevokeTokenTx.
+func (mr *MockStoreMockRecorder) RevokeTokenTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeTokenTx", reflect.TypeOf((*MockStore)(nil).RevokeTokenTx), ctx, arg)
+}
+
+// RunScheduledTransferTx mocks base method.
+func (m *MockStore) RunScheduledTransferTx(ctx context.Context, arg db.RunScheduledTransferTxParams) (sqlc.ScheduledTransferRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunScheduledTransferTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ScheduledTransferRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunScheduledTransferTx indicates an expected call of RunScheduledTransferTx.
+func (mr *MockStoreMockRecorder) RunScheduledTransferTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunScheduledTransferTx", reflect.TypeOf((*MockStore)(nil).RunScheduledTransferTx), ctx, arg)
+}
+
+// SetAccountLastEntryHash mocks base method.
+func (m *MockStore) SetAccountLastEntryHash(ctx context.Context, arg sqlc.SetAccountLastEntryHashParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountLastEntryHash", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAccountLastEntryHash indicates an expected call of SetAccountLastEntryHash.
+func (mr *MockStoreMockRecorder) SetAccountLastEntryHash(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountLastEntryHash", reflect.TypeOf((*MockStore)(nil).SetAccountLastEntryHash), ctx, arg)
+}
+
+// SetAccountOverdraft mocks base method.
+func (m *MockStore) SetAccountOverdraft(ctx context.Context, arg sqlc.SetAccountOverdraftParams) (sqlc.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccountOverdraft", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetAccountOverdraft indicates an expected call of SetAccountOverdraft.
+func (mr *MockStoreMockRecorder) SetAccountOverdraft(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountOverdraft", reflect.TypeOf((*MockStore)(nil).SetAccountOverdraft), ctx, arg)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockStore) TouchAPIKey(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockStoreMockRecorder) TouchAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockStore)(nil).TouchAPIKey), ctx, id)
+}
+
 // TransferTx mocks base method.
 func (m *MockStore) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
 	m.ctrl.T.Helper()
@@ -274,3 +1365,108 @@ func (mr *MockStoreMockRecorder) UpdateAccount(ctx, arg interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), ctx, arg)
 }
+
+// UpdateAccountStatus mocks base method.
+func (m *MockStore) UpdateAccountStatus(ctx context.Context, arg sqlc.UpdateAccountStatusParams) (sqlc.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus.
+func (mr *MockStoreMockRecorder) UpdateAccountStatus(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockStore)(nil).UpdateAccountStatus), ctx, arg)
+}
+
+// UpdateHoldStatus mocks base method.
+func (m *MockStore) UpdateHoldStatus(ctx context.Context, arg sqlc.UpdateHoldStatusParams) (sqlc.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHoldStatus", ctx, arg)
+	ret0, _ := ret[0].(sqlc.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateHoldStatus indicates an expected call of UpdateHoldStatus.
+func (mr *MockStoreMockRecorder) UpdateHoldStatus(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHoldStatus", reflect.TypeOf((*MockStore)(nil).UpdateHoldStatus), ctx, arg)
+}
+
+// UpdateIdempotencyKeyResponse mocks base method.
+func (m *MockStore) UpdateIdempotencyKeyResponse(ctx context.Context, arg sqlc.UpdateIdempotencyKeyResponseParams) (sqlc.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateIdempotencyKeyResponse", ctx, arg)
+	ret0, _ := ret[0].(sqlc.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateIdempotencyKeyResponse indicates an expected call of UpdateIdempotencyKeyResponse.
+func (mr *MockStoreMockRecorder) UpdateIdempotencyKeyResponse(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIdempotencyKeyResponse", reflect.TypeOf((*MockStore)(nil).UpdateIdempotencyKeyResponse), ctx, arg)
+}
+
+// UpdateScheduledTransfer mocks base method.
+func (m *MockStore) UpdateScheduledTransfer(ctx context.Context, arg sqlc.UpdateScheduledTransferParams) (sqlc.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransfer", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduledTransfer indicates an expected call of UpdateScheduledTransfer.
+func (mr *MockStoreMockRecorder) UpdateScheduledTransfer(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).UpdateScheduledTransfer), ctx, arg)
+}
+
+// UpdateScheduledTransferTx mocks base method.
+func (m *MockStore) UpdateScheduledTransferTx(ctx context.Context, arg db.UpdateScheduledTransferTxParams) (sqlc.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateScheduledTransferTx", ctx, arg)
+	ret0, _ := ret[0].(sqlc.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateScheduledTransferTx indicates an expected call of UpdateScheduledTransferTx.
+func (mr *MockStoreMockRecorder) UpdateScheduledTransferTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateScheduledTransferTx", reflect.TypeOf((*MockStore)(nil).UpdateScheduledTransferTx), ctx, arg)
+}
+
+// UpdateUserRole mocks base method.
+func (m *MockStore) UpdateUserRole(ctx context.Context, arg sqlc.UpdateUserRoleParams) (sqlc.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", ctx, arg)
+	ret0, _ := ret[0].(sqlc.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockStoreMockRecorder) UpdateUserRole(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockStore)(nil).UpdateUserRole), ctx, arg)
+}
+
+// WithdrawTx mocks base method.
+func (m *MockStore) WithdrawTx(ctx context.Context, arg db.WithdrawTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawTx", ctx, arg)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawTx indicates an expected call of WithdrawTx.
+func (mr *MockStoreMockRecorder) WithdrawTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawTx", reflect.TypeOf((*MockStore)(nil).WithdrawTx), ctx, arg)
+}
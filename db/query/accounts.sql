@@ -13,6 +13,18 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+-- name: AddAccountHeldBalance :one
+UPDATE accounts
+SET held_balance = held_balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ListAccountIDs :many
+SELECT id FROM accounts
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 WHERE owner = $1
@@ -37,3 +49,25 @@ RETURNING *;
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1;
+
+-- name: SetAccountLastEntryHash :exec
+UPDATE accounts
+SET last_entry_hash = sqlc.arg(last_entry_hash)
+WHERE id = sqlc.arg(id);
+
+-- name: SetAccountOverdraft :one
+UPDATE accounts
+SET overdraft_policy = $2,
+    overdraft_limit = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: GetClearingAccount :one
+SELECT * FROM accounts
+WHERE owner = 'system_clearing' AND currency = $1 LIMIT 1;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING *;
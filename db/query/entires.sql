@@ -9,8 +9,34 @@ LIMIT $1 OFFSET $2;
 
 -- name: CreateEntry :one
 INSERT INTO entries (
-account_id, amount
+account_id, amount, created_at, prev_hash, hash
 ) VALUES (
-$1, $2
+$1, $2, $3, $4, $5
 )
 RETURNING *;
+
+-- name: GetLastEntry :one
+SELECT * FROM entries
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListAccountChain :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(page_size);
+
+-- name: ListAccountEntries :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(cursor)::bigint IS NULL OR id < sqlc.narg(cursor))
+  AND (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time))
+  AND (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+  AND (sqlc.narg(direction)::text IS NULL
+    OR (sqlc.narg(direction) = 'in' AND amount > 0)
+    OR (sqlc.narg(direction) = 'out' AND amount < 0))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(amount) >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(amount) <= sqlc.narg(max_amount))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,38 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  account_id, to_account_id, amount, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: ListAccountHolds :many
+SELECT * FROM holds
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: UpdateHoldStatus :one
+UPDATE holds
+SET status = $2,
+    transfer_id = $3,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetExpiredHoldForUpdate :one
+-- skips holds another worker is already expiring
+SELECT * FROM holds
+WHERE status = 'active' AND expires_at <= now()
+ORDER BY expires_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
@@ -0,0 +1,30 @@
+-- name: CreateIdempotencyKey :one
+-- an expired key is taken over, a live one is left alone and no row is returned
+INSERT INTO idempotency_keys (
+  key, username, request_hash, expired_at
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (username, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    response_code = 0,
+    response_body = NULL,
+    created_at = now(),
+    expired_at = EXCLUDED.expired_at
+WHERE idempotency_keys.expired_at < now()
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1;
+
+-- name: UpdateIdempotencyKeyResponse :one
+UPDATE idempotency_keys
+SET response_code = $3,
+    response_body = $4
+WHERE username = $1 AND key = $2
+RETURNING *;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE username = $1 AND key = $2;
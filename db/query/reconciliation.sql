@@ -0,0 +1,47 @@
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (
+  triggered_by
+) VALUES (
+  $1
+)
+RETURNING *;
+
+-- name: FinishReconciliationRun :one
+UPDATE reconciliation_runs
+SET accounts_checked = $2,
+    discrepancies = $3,
+    finished_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetReconciliationRun :one
+SELECT * FROM reconciliation_runs
+WHERE id = $1 LIMIT 1;
+
+-- name: ListReconciliationRuns :many
+SELECT * FROM reconciliation_runs
+ORDER BY id DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CreateReconciliationDiscrepancy :one
+INSERT INTO reconciliation_discrepancies (
+  run_id, account_id, balance, entries_sum, difference
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListReconciliationDiscrepancies :many
+SELECT * FROM reconciliation_discrepancies
+WHERE run_id = $1
+ORDER BY account_id;
+
+-- name: ListAccountBalanceSums :many
+-- balance and sum come from the same statement, so they are read from one snapshot
+SELECT a.id AS account_id, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_sum
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > sqlc.arg(after_id)
+GROUP BY a.id
+ORDER BY a.id
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,68 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  owner, from_account_id, to_account_id, amount, start_at, cron, interval_seconds, next_run_at, next_attempt_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $8
+)
+RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetScheduledTransferForUpdate :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id
+LIMIT $2 OFFSET $3;
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET amount = $2,
+    status = $3,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: ClaimDueScheduledTransfers :many
+-- pushes next_attempt_at past the lease, so other workers skip the rows while they run
+UPDATE scheduled_transfers
+SET next_attempt_at = now() + sqlc.arg(lease_seconds)::bigint * interval '1 second'
+WHERE id IN (
+  SELECT id FROM scheduled_transfers
+  WHERE status = 'active' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg(batch_size)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: RescheduleScheduledTransfer :one
+-- a scheduled transfer paused or cancelled while it ran keeps its status
+UPDATE scheduled_transfers
+SET status = CASE WHEN status = 'active' THEN sqlc.arg(status)::"ScheduledTransferStatus" ELSE status END,
+    next_run_at = sqlc.arg(next_run_at),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    attempts = sqlc.arg(attempts),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+  scheduled_transfer_id, occurrence, attempt, transfer_id, error
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListScheduledTransferRuns :many
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3;
@@ -7,10 +7,49 @@ SELECT * FROM transfers
 ORDER BY id
 LIMIT $1 OFFSET $2;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR UPDATE;
+
 -- name: CreateTransfer :one
 INSERT INTO transfers (
-  from_account_id, to_account_id, amount
+  from_account_id, to_account_id, amount, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING *;
+
+-- name: GetReversedAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount FROM transfers
+WHERE reversed_transfer_id = sqlc.arg(transfer_id)::bigint;
+
+-- name: ListAccountTransfers :many
+-- each half walks its own (account_id, id) index newest first, amounts are compared
+-- in the currency of the account, amount when it sent and to_amount when it received
+SELECT * FROM (
+  (SELECT * FROM transfers
+  WHERE from_account_id = sqlc.arg(account_id)
+    AND (sqlc.narg(cursor)::bigint IS NULL OR id < sqlc.narg(cursor))
+    AND (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time))
+    AND (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+    AND (sqlc.narg(direction)::text IS NULL OR sqlc.narg(direction) = 'out')
+    AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+    AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  ORDER BY id DESC
+  LIMIT sqlc.arg(page_size))
+  UNION ALL
+  (SELECT * FROM transfers
+  WHERE to_account_id = sqlc.arg(account_id)
+    AND from_account_id <> sqlc.arg(account_id)
+    AND (sqlc.narg(cursor)::bigint IS NULL OR id < sqlc.narg(cursor))
+    AND (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time))
+    AND (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+    AND (sqlc.narg(direction)::text IS NULL OR sqlc.narg(direction) = 'in')
+    AND (sqlc.narg(min_amount)::bigint IS NULL OR to_amount >= sqlc.narg(min_amount))
+    AND (sqlc.narg(max_amount)::bigint IS NULL OR to_amount <= sqlc.narg(max_amount))
+  ORDER BY id DESC
+  LIMIT sqlc.arg(page_size))
+) AS account_transfers
+ORDER BY id DESC
+LIMIT sqlc.arg(page_size);
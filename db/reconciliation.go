@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// CreateReconciliationRunTx starts a reconciliation run, the actor of the audit event is the
+// admin who asked for it or the system for scheduled and CLI runs
+func (store *SQLStore) CreateReconciliationRunTx(ctx context.Context, triggeredBy string) (sqlc.ReconciliationRun, error) {
+	var run sqlc.ReconciliationRun
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		run, err = q.CreateReconciliationRun(ctx, triggeredBy)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionStartReconciliation,
+			TargetType: "reconciliation_run",
+			TargetID:   auditID(run.ID),
+			After:      run,
+		}, nil
+	})
+	return run, err
+}
+
+// CreateReconciliationDiscrepancyTx records an account whose balance drifted from its entries
+func (store *SQLStore) CreateReconciliationDiscrepancyTx(ctx context.Context, arg sqlc.CreateReconciliationDiscrepancyParams) (sqlc.ReconciliationDiscrepancy, error) {
+	var discrepancy sqlc.ReconciliationDiscrepancy
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		discrepancy, err = q.CreateReconciliationDiscrepancy(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionRecordDiscrepancy,
+			TargetType: "reconciliation_discrepancy",
+			TargetID:   auditID(discrepancy.ID),
+			After:      discrepancy,
+		}, nil
+	})
+	return discrepancy, err
+}
+
+// FinishReconciliationRunTx records the totals of a run that went through every account
+func (store *SQLStore) FinishReconciliationRunTx(ctx context.Context, arg sqlc.FinishReconciliationRunParams) (sqlc.ReconciliationRun, error) {
+	var run sqlc.ReconciliationRun
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		before, err := q.GetReconciliationRun(ctx, arg.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		run, err = q.FinishReconciliationRun(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionFinishReconciliation,
+			TargetType: "reconciliation_run",
+			TargetID:   auditID(run.ID),
+			Before:     before,
+			After:      run,
+		}, nil
+	})
+	return run, err
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATEs that only mean the transaction lost a race, running it again can succeed
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+const (
+	// DefaultMaxAttempts is how many times a transaction is run when TxOptions doesn't say
+	DefaultMaxAttempts = 5
+	// retryBaseDelay is the longest wait before the first retry, it doubles with each retry up to retryMaxDelay
+	retryBaseDelay = 10 * time.Millisecond
+	retryMaxDelay  = 500 * time.Millisecond
+)
+
+// txRetries counts retried transactions by SQLSTATE, and under "exhausted" the ones that
+// still failed after their last attempt. It is published with expvar as db_tx_retries.
+var txRetries = expvar.NewMap("db_tx_retries")
+
+// TxOptions sets how the transactions of one store call run
+type TxOptions struct {
+	// IsoLevel defaults to read committed, which the row locks taken by every Tx method rely on
+	IsoLevel pgx.TxIsoLevel
+	// MaxAttempts bounds the runs of a transaction failing with a serialization failure or a deadlock,
+	// zero means DefaultMaxAttempts
+	MaxAttempts int
+}
+
+type txOptionsKey struct{}
+
+// WithTxOptions makes the store calls made with ctx run their transactions with options,
+// so the caller of an operation picks its isolation level
+func WithTxOptions(ctx context.Context, options TxOptions) context.Context {
+	return context.WithValue(ctx, txOptionsKey{}, options)
+}
+
+// TxOptionsFrom returns the options WithTxOptions put in ctx, with the defaults filled in
+func TxOptionsFrom(ctx context.Context) TxOptions {
+	options, _ := ctx.Value(txOptionsKey{}).(TxOptions)
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+	return options
+}
+
+// retryableCode returns the SQLSTATE of a failure worth retrying, or "" for any other error
+func retryableCode(err error) string {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return ""
+	}
+	switch pgErr.Code {
+	case serializationFailure, deadlockDetected:
+		return pgErr.Code
+	default:
+		return ""
+	}
+}
+
+// retryDelay picks a random wait up to a ceiling that doubles with each attempt, the jitter keeps
+// transactions that failed together from running into each other again
+func retryDelay(attempt int) time.Duration {
+	ceiling := retryBaseDelay
+	for i := 1; i < attempt && ceiling < retryMaxDelay; i++ {
+		ceiling *= 2
+	}
+	return rand.N(min(ceiling, retryMaxDelay))
+}
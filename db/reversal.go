@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount is taken back from the recipient in the currency it received, 0 reverses whatever is left
+	Amount int64 `json:"amount"`
+}
+
+// ReverseTransferTx moves money back from the recipient of a transfer to its sender, in full or in part.
+// The original transfer row is locked so concurrent reversals cannot add up to more than it moved.
+// The sender gets back its share of the original amount, so reversing everything returns exactly
+// what was sent even when the transfer was converted between currencies.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return nil, err
+		}
+
+		if original.Kind != sqlc.TransferKindTransfer {
+			return nil, ErrTransferNotReversible
+		}
+
+		reversed, err := q.GetReversedAmount(ctx, original.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		reversible := original.ToAmount - reversed
+		amount := arg.Amount
+		if amount == 0 {
+			amount = reversible
+		}
+		if amount <= 0 || amount > reversible {
+			return nil, &ReversalExceedsTransferError{
+				TransferID: original.ID,
+				Amount:     amount,
+				Reversible: reversible,
+			}
+		}
+
+		result, err = moveMoney(ctx, q, sqlc.CreateTransferParams{
+			FromAccountID:      original.ToAccountID,
+			ToAccountID:        original.FromAccountID,
+			Amount:             amount,
+			ToAmount:           share(original, reversed+amount) - share(original, reversed),
+			ExchangeRate:       numericRate(share(original, exchange.RateUnit)),
+			Kind:               sqlc.TransferKindReversal,
+			ReversedTransferID: pgtype.Int8{Int64: original.ID, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return transferAuditEvent(AuditActionReverseTransfer, result), nil
+	})
+	return result, err
+}
+
+// share converts part of a transfer's to_amount back into its amount, rounding down
+func share(transfer sqlc.Transfer, toAmount int64) int64 {
+	value := new(big.Int).Mul(big.NewInt(toAmount), big.NewInt(transfer.Amount))
+	return value.Quo(value, big.NewInt(transfer.ToAmount)).Int64()
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+)
+
+// SQLRevoker keeps revoked token IDs in the revoked_tokens table so every instance sees them
+type SQLRevoker struct {
+	store Store
+}
+
+func NewRevoker(store Store) token.Revoker {
+	return &SQLRevoker{store: store}
+}
+
+func (revoker *SQLRevoker) Revoke(ctx context.Context, payload *token.Payload) error {
+	return revoker.store.RevokeTokenTx(ctx, sqlc.CreateRevokedTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: pgtype.Timestamptz{Time: payload.ExpiredAt, Valid: true},
+	})
+}
+
+func (revoker *SQLRevoker) IsRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	return revoker.store.IsTokenRevoked(ctx, id)
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+type UpdateScheduledTransferTxParams struct {
+	ID int64 `json:"id"`
+	// Amount and Status are left unchanged when zero
+	Amount int64                        `json:"amount"`
+	Status sqlc.ScheduledTransferStatus `json:"status"`
+}
+
+// CreateScheduledTransferTx schedules a transfer and records who scheduled it
+func (store *SQLStore) CreateScheduledTransferTx(ctx context.Context, arg sqlc.CreateScheduledTransferParams) (sqlc.ScheduledTransfer, error) {
+	var transfer sqlc.ScheduledTransfer
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		transfer, err = q.CreateScheduledTransfer(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionCreateScheduledTransfer,
+			TargetType: "scheduled_transfer",
+			TargetID:   auditID(transfer.ID),
+			After:      transfer,
+		}, nil
+	})
+	return transfer, err
+}
+
+// UpdateScheduledTransferTx changes the amount or status of a scheduled transfer that has not ended
+func (store *SQLStore) UpdateScheduledTransferTx(ctx context.Context, arg UpdateScheduledTransferTxParams) (sqlc.ScheduledTransfer, error) {
+	var transfer sqlc.ScheduledTransfer
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		before, err := q.GetScheduledTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if scheduledTransferEnded(before.Status) {
+			return nil, ErrScheduledTransferEnded
+		}
+
+		update := sqlc.UpdateScheduledTransferParams{
+			ID:     arg.ID,
+			Amount: before.Amount,
+			Status: before.Status,
+		}
+		if arg.Amount != 0 {
+			update.Amount = arg.Amount
+		}
+		if arg.Status != "" {
+			update.Status = arg.Status
+		}
+
+		transfer, err = q.UpdateScheduledTransfer(ctx, update)
+		if err != nil {
+			return nil, err
+		}
+
+		action := AuditActionUpdateScheduledTransfer
+		if transfer.Status == sqlc.ScheduledTransferStatusCancelled {
+			action = AuditActionCancelScheduledTransfer
+		}
+		return &AuditEvent{
+			Action:     action,
+			TargetType: "scheduled_transfer",
+			TargetID:   auditID(transfer.ID),
+			Before:     before,
+			After:      transfer,
+		}, nil
+	})
+	return transfer, err
+}
+
+// RunScheduledTransferTxParams is one attempt at an occurrence of a scheduled transfer
+type RunScheduledTransferTxParams struct {
+	// Transfer is nil when the attempt failed before any money could move, only the run is recorded then
+	Transfer *TransferTxParams `json:"transfer,omitempty"`
+	// Run.TransferID is filled in with the transfer made
+	Run  sqlc.CreateScheduledTransferRunParams  `json:"run"`
+	Next sqlc.RescheduleScheduledTransferParams `json:"next"`
+}
+
+// RunScheduledTransferTx makes the transfer of a run, records the run and moves the scheduled
+// transfer to its next attempt in one transaction, so an occurrence is never paid twice.
+// The scheduled transfer is locked first, ErrScheduledTransferRunStale is returned when another
+// worker already ran this attempt after the lease of the caller ran out, or when the scheduled
+// transfer was paused or cancelled since it was claimed.
+func (store *SQLStore) RunScheduledTransferTx(ctx context.Context, arg RunScheduledTransferTxParams) (sqlc.ScheduledTransferRun, error) {
+	var run sqlc.ScheduledTransferRun
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		current, err := q.GetScheduledTransferForUpdate(ctx, arg.Run.ScheduledTransferID)
+		if err != nil {
+			return nil, err
+		}
+		if current.Status != sqlc.ScheduledTransferStatusActive ||
+			!current.NextRunAt.Time.Equal(arg.Run.Occurrence.Time) || current.Attempts+1 != arg.Run.Attempt {
+			return nil, ErrScheduledTransferRunStale
+		}
+
+		var event *AuditEvent
+		runArg := arg.Run
+		if arg.Transfer != nil {
+			result, err := transferMoney(ctx, q, *arg.Transfer)
+			if err != nil {
+				return nil, err
+			}
+			runArg.TransferID = pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+			event = transferAuditEvent(AuditActionCreateTransfer, result)
+		}
+
+		run, err = q.CreateScheduledTransferRun(ctx, runArg)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = q.RescheduleScheduledTransfer(ctx, arg.Next)
+		if err != nil {
+			return nil, err
+		}
+		return event, nil
+	})
+	return run, err
+}
+
+func scheduledTransferEnded(status sqlc.ScheduledTransferStatus) bool {
+	switch status {
+	case sqlc.ScheduledTransferStatusCompleted, sqlc.ScheduledTransferStatusFailed, sqlc.ScheduledTransferStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
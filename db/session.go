@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// CreateSessionTx records the session of a login
+func (store *SQLStore) CreateSessionTx(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error) {
+	var session sqlc.Session
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		session, err = q.CreateSession(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionCreateSession,
+			TargetType: "session",
+			TargetID:   session.ID.String(),
+			After:      sessionSnapshot(session),
+		}, nil
+	})
+	return session, err
+}
+
+// BlockSessionTx blocks the session of a logout so its refresh token can no longer be renewed
+func (store *SQLStore) BlockSessionTx(ctx context.Context, id uuid.UUID) (sqlc.Session, error) {
+	var session sqlc.Session
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		session, err = q.BlockSession(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		before := session
+		before.IsBlocked = false
+		return &AuditEvent{
+			Action:     AuditActionLogout,
+			TargetType: "session",
+			TargetID:   session.ID.String(),
+			Before:     sessionSnapshot(before),
+			After:      sessionSnapshot(session),
+		}, nil
+	})
+	return session, err
+}
+
+// BlockUserSessionsTx blocks every live session of a user, one audit event lists them all
+func (store *SQLStore) BlockUserSessionsTx(ctx context.Context, username string) ([]sqlc.Session, error) {
+	var sessions []sqlc.Session
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		sessions, err = q.BlockUserSessions(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+
+		// only sessions that were not blocked are returned
+		before := make([]sqlc.Session, len(sessions))
+		after := make([]sqlc.Session, len(sessions))
+		for i, session := range sessions {
+			after[i] = sessionSnapshot(session)
+			before[i] = after[i]
+			before[i].IsBlocked = false
+		}
+		return &AuditEvent{
+			Action:     AuditActionRevokeSessions,
+			TargetType: "user",
+			TargetID:   username,
+			Before:     before,
+			After:      after,
+		}, nil
+	})
+	return sessions, err
+}
+
+// RevokeTokenTx records a revoked token ID, a logout without a refresh token only revokes
+// the access token it was made with
+func (store *SQLStore) RevokeTokenTx(ctx context.Context, arg sqlc.CreateRevokedTokenParams) error {
+	return store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		err := q.CreateRevokedToken(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return &AuditEvent{
+			Action:     AuditActionRevokeToken,
+			TargetType: "token",
+			TargetID:   arg.ID.String(),
+			After:      arg,
+		}, nil
+	})
+}
+
+// sessionSnapshot leaves the refresh token out of the audit trail
+func sessionSnapshot(session sqlc.Session) sqlc.Session {
+	session.RefreshToken = ""
+	return session
+}
@@ -13,7 +13,7 @@ const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash
 `
 
 type AddAccountBalanceParams struct {
@@ -30,6 +30,43 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
+	)
+	return i, err
+}
+
+const addAccountHeldBalance = `-- name: AddAccountHeldBalance :one
+UPDATE accounts
+SET held_balance = held_balance + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash
+`
+
+type AddAccountHeldBalanceParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddAccountHeldBalance(ctx context.Context, arg AddAccountHeldBalanceParams) (Account, error) {
+	row := q.db.QueryRow(ctx, addAccountHeldBalance, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
 	)
 	return i, err
 }
@@ -40,13 +77,13 @@ INSERT INTO accounts (
 ) VALUES (
   $1, $2, $3
 )
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash
 `
 
 type CreateAccountParams struct {
-	Owner    string   `json:"owner"`
-	Balance  int64    `json:"balance"`
-	Currency Currency `json:"currency"`
+	Owner    string `json:"owner"`
+	Balance  int64  `json:"balance"`
+	Currency string `json:"currency"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
@@ -58,6 +95,12 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
 	)
 	return i, err
 }
@@ -73,7 +116,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -86,12 +129,18 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash FROM accounts
 WHERE id = $1 LIMIT 1
 FOR UPDATE
 `
@@ -105,12 +154,74 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
 	)
 	return i, err
 }
 
+const getClearingAccount = `-- name: GetClearingAccount :one
+SELECT id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash FROM accounts
+WHERE owner = 'system_clearing' AND currency = $1 LIMIT 1
+`
+
+func (q *Queries) GetClearingAccount(ctx context.Context, currency string) (Account, error) {
+	row := q.db.QueryRow(ctx, getClearingAccount, currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
+	)
+	return i, err
+}
+
+const listAccountIDs = `-- name: ListAccountIDs :many
+SELECT id FROM accounts
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListAccountIDsParams struct {
+	AfterID  int64 `json:"after_id"`
+	PageSize int32 `json:"page_size"`
+}
+
+func (q *Queries) ListAccountIDs(ctx context.Context, arg ListAccountIDsParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listAccountIDs, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash FROM accounts
 WHERE owner = $1
 ORDER BY id
 LIMIT $2 OFFSET $3
@@ -137,6 +248,12 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftPolicy,
+			&i.OverdraftLimit,
+			&i.Status,
+			&i.HeldBalance,
+			&i.AvailableBalance,
+			&i.LastEntryHash,
 		); err != nil {
 			return nil, err
 		}
@@ -148,11 +265,60 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const setAccountLastEntryHash = `-- name: SetAccountLastEntryHash :exec
+UPDATE accounts
+SET last_entry_hash = $1
+WHERE id = $2
+`
+
+type SetAccountLastEntryHashParams struct {
+	LastEntryHash []byte `json:"last_entry_hash"`
+	ID            int64  `json:"id"`
+}
+
+func (q *Queries) SetAccountLastEntryHash(ctx context.Context, arg SetAccountLastEntryHashParams) error {
+	_, err := q.db.Exec(ctx, setAccountLastEntryHash, arg.LastEntryHash, arg.ID)
+	return err
+}
+
+const setAccountOverdraft = `-- name: SetAccountOverdraft :one
+UPDATE accounts
+SET overdraft_policy = $2,
+    overdraft_limit = $3
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash
+`
+
+type SetAccountOverdraftParams struct {
+	ID              int64           `json:"id"`
+	OverdraftPolicy OverdraftPolicy `json:"overdraft_policy"`
+	OverdraftLimit  int64           `json:"overdraft_limit"`
+}
+
+func (q *Queries) SetAccountOverdraft(ctx context.Context, arg SetAccountOverdraftParams) (Account, error) {
+	row := q.db.QueryRow(ctx, setAccountOverdraft, arg.ID, arg.OverdraftPolicy, arg.OverdraftLimit)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
   set balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash
 `
 
 type UpdateAccountParams struct {
@@ -169,6 +335,43 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_policy, overdraft_limit, status, held_balance, available_balance, last_entry_hash
+`
+
+type UpdateAccountStatusParams struct {
+	ID     int64         `json:"id"`
+	Status AccountStatus `json:"status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountStatus, arg.ID, arg.Status)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftPolicy,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.HeldBalance,
+		&i.AvailableBalance,
+		&i.LastEntryHash,
 	)
 	return i, err
 }
@@ -7,36 +7,49 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
-account_id, amount
+account_id, amount, created_at, prev_hash, hash
 ) VALUES (
-$1, $2
+$1, $2, $3, $4, $5
 )
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, prev_hash, hash
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID int64              `json:"account_id"`
+	Amount    int64              `json:"amount"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	PrevHash  []byte             `json:"prev_hash"`
+	Hash      []byte             `json:"hash"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRow(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.CreatedAt,
+		arg.PrevHash,
+		arg.Hash,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, prev_hash, hash FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -48,12 +61,137 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.PrevHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const getLastEntry = `-- name: GetLastEntry :one
+SELECT id, account_id, amount, created_at, prev_hash, hash FROM entries
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastEntry(ctx context.Context, accountID int64) (Entry, error) {
+	row := q.db.QueryRow(ctx, getLastEntry, accountID)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
 
+const listAccountChain = `-- name: ListAccountChain :many
+SELECT id, account_id, amount, created_at, prev_hash, hash FROM entries
+WHERE account_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountChainParams struct {
+	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
+	PageSize  int32 `json:"page_size"`
+}
+
+func (q *Queries) ListAccountChain(ctx context.Context, arg ListAccountChainParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listAccountChain, arg.AccountID, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, created_at, prev_hash, hash FROM entries
+WHERE account_id = $1
+  AND ($2::bigint IS NULL OR id < $2)
+  AND ($3::timestamptz IS NULL OR created_at >= $3)
+  AND ($4::timestamptz IS NULL OR created_at < $4)
+  AND ($5::text IS NULL
+    OR ($5 = 'in' AND amount > 0)
+    OR ($5 = 'out' AND amount < 0))
+  AND ($6::bigint IS NULL OR abs(amount) >= $6)
+  AND ($7::bigint IS NULL OR abs(amount) <= $7)
+ORDER BY id DESC
+LIMIT $8
+`
+
+type ListAccountEntriesParams struct {
+	AccountID int64              `json:"account_id"`
+	Cursor    pgtype.Int8        `json:"cursor"`
+	StartTime pgtype.Timestamptz `json:"start_time"`
+	EndTime   pgtype.Timestamptz `json:"end_time"`
+	Direction pgtype.Text        `json:"direction"`
+	MinAmount pgtype.Int8        `json:"min_amount"`
+	MaxAmount pgtype.Int8        `json:"max_amount"`
+	PageSize  int32              `json:"page_size"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.Cursor,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Direction,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntrys = `-- name: ListEntrys :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, prev_hash, hash FROM entries
 ORDER BY id
 LIMIT $1 OFFSET $2
 `
@@ -77,6 +215,8 @@ func (q *Queries) ListEntrys(ctx context.Context, arg ListEntrysParams) ([]Entry
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.PrevHash,
+			&i.Hash,
 		); err != nil {
 			return nil, err
 		}
@@ -8,56 +8,342 @@ import (
 	"database/sql/driver"
 	"fmt"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Currency string
+type AccountStatus string
 
 const (
-	CurrencyUSD Currency = "USD"
-	CurrencyEUR Currency = "EUR"
+	AccountStatusActive AccountStatus = "active"
+	AccountStatusFrozen AccountStatus = "frozen"
+	AccountStatusClosed AccountStatus = "closed"
 )
 
-func (e *Currency) Scan(src interface{}) error {
+func (e *AccountStatus) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []byte:
-		*e = Currency(s)
+		*e = AccountStatus(s)
 	case string:
-		*e = Currency(s)
+		*e = AccountStatus(s)
 	default:
-		return fmt.Errorf("unsupported scan type for Currency: %T", src)
+		return fmt.Errorf("unsupported scan type for AccountStatus: %T", src)
 	}
 	return nil
 }
 
-type NullCurrency struct {
-	Currency Currency `json:"Currency"`
-	Valid    bool     `json:"valid"` // Valid is true if Currency is not NULL
+type NullAccountStatus struct {
+	AccountStatus AccountStatus `json:"AccountStatus"`
+	Valid         bool          `json:"valid"` // Valid is true if AccountStatus is not NULL
 }
 
 // Scan implements the Scanner interface.
-func (ns *NullCurrency) Scan(value interface{}) error {
+func (ns *NullAccountStatus) Scan(value interface{}) error {
 	if value == nil {
-		ns.Currency, ns.Valid = "", false
+		ns.AccountStatus, ns.Valid = "", false
 		return nil
 	}
 	ns.Valid = true
-	return ns.Currency.Scan(value)
+	return ns.AccountStatus.Scan(value)
 }
 
 // Value implements the driver Valuer interface.
-func (ns NullCurrency) Value() (driver.Value, error) {
+func (ns NullAccountStatus) Value() (driver.Value, error) {
 	if !ns.Valid {
 		return nil, nil
 	}
-	return string(ns.Currency), nil
+	return string(ns.AccountStatus), nil
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "active"
+	HoldStatusCaptured HoldStatus = "captured"
+	HoldStatusReleased HoldStatus = "released"
+	HoldStatusExpired  HoldStatus = "expired"
+)
+
+func (e *HoldStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = HoldStatus(s)
+	case string:
+		*e = HoldStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for HoldStatus: %T", src)
+	}
+	return nil
+}
+
+type NullHoldStatus struct {
+	HoldStatus HoldStatus `json:"HoldStatus"`
+	Valid      bool       `json:"valid"` // Valid is true if HoldStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullHoldStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.HoldStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.HoldStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullHoldStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.HoldStatus), nil
+}
+
+type OverdraftPolicy string
+
+const (
+	OverdraftPolicyNone      OverdraftPolicy = "none"
+	OverdraftPolicyLimit     OverdraftPolicy = "limit"
+	OverdraftPolicyUnlimited OverdraftPolicy = "unlimited"
+)
+
+func (e *OverdraftPolicy) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OverdraftPolicy(s)
+	case string:
+		*e = OverdraftPolicy(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OverdraftPolicy: %T", src)
+	}
+	return nil
+}
+
+type NullOverdraftPolicy struct {
+	OverdraftPolicy OverdraftPolicy `json:"OverdraftPolicy"`
+	Valid           bool            `json:"valid"` // Valid is true if OverdraftPolicy is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOverdraftPolicy) Scan(value interface{}) error {
+	if value == nil {
+		ns.OverdraftPolicy, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OverdraftPolicy.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOverdraftPolicy) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OverdraftPolicy), nil
+}
+
+type ScheduledTransferStatus string
+
+const (
+	ScheduledTransferStatusActive    ScheduledTransferStatus = "active"
+	ScheduledTransferStatusPaused    ScheduledTransferStatus = "paused"
+	ScheduledTransferStatusCompleted ScheduledTransferStatus = "completed"
+	ScheduledTransferStatusFailed    ScheduledTransferStatus = "failed"
+	ScheduledTransferStatusCancelled ScheduledTransferStatus = "cancelled"
+)
+
+func (e *ScheduledTransferStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ScheduledTransferStatus(s)
+	case string:
+		*e = ScheduledTransferStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ScheduledTransferStatus: %T", src)
+	}
+	return nil
+}
+
+type NullScheduledTransferStatus struct {
+	ScheduledTransferStatus ScheduledTransferStatus `json:"ScheduledTransferStatus"`
+	Valid                   bool                    `json:"valid"` // Valid is true if ScheduledTransferStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullScheduledTransferStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ScheduledTransferStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ScheduledTransferStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullScheduledTransferStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ScheduledTransferStatus), nil
+}
+
+type TransferKind string
+
+const (
+	TransferKindTransfer   TransferKind = "transfer"
+	TransferKindDeposit    TransferKind = "deposit"
+	TransferKindWithdrawal TransferKind = "withdrawal"
+	TransferKindReversal   TransferKind = "reversal"
+)
+
+func (e *TransferKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TransferKind(s)
+	case string:
+		*e = TransferKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TransferKind: %T", src)
+	}
+	return nil
+}
+
+type NullTransferKind struct {
+	TransferKind TransferKind `json:"TransferKind"`
+	Valid        bool         `json:"valid"` // Valid is true if TransferKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTransferKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.TransferKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TransferKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTransferKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TransferKind), nil
+}
+
+type UserRole string
+
+const (
+	UserRoleDepositor UserRole = "depositor"
+	UserRoleBanker    UserRole = "banker"
+	UserRoleAdmin     UserRole = "admin"
+)
+
+func (e *UserRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UserRole(s)
+	case string:
+		*e = UserRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UserRole: %T", src)
+	}
+	return nil
+}
+
+type NullUserRole struct {
+	UserRole UserRole `json:"UserRole"`
+	Valid    bool     `json:"valid"` // Valid is true if UserRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUserRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.UserRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UserRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUserRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UserRole), nil
 }
 
 type Account struct {
-	ID        int64              `json:"id"`
-	Owner     string             `json:"owner"`
-	Balance   int64              `json:"balance"`
-	Currency  Currency           `json:"currency"`
+	ID              int64              `json:"id"`
+	Owner           string             `json:"owner"`
+	Balance         int64              `json:"balance"`
+	Currency        string             `json:"currency"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	OverdraftPolicy OverdraftPolicy    `json:"overdraft_policy"`
+	// only used by the limit policy, balance may not go below -overdraft_limit
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// only active accounts can send or receive transfers, closed is final
+	Status AccountStatus `json:"status"`
+	// sum of the active holds on the account
+	HeldBalance int64 `json:"held_balance"`
+	// balance minus held_balance, debits and new holds are checked against it
+	AvailableBalance int64 `json:"available_balance"`
+	// hash of the newest entry of the account, null before the first one, a chain that does not end on it lost entries
+	LastEntryHash []byte `json:"last_entry_hash"`
+}
+
+type AccountStatusChange struct {
+	ID         int64         `json:"id"`
+	AccountID  int64         `json:"account_id"`
+	FromStatus AccountStatus `json:"from_status"`
+	ToStatus   AccountStatus `json:"to_status"`
+	Reason     string        `json:"reason"`
+	// username of the owner or admin who made the change
+	ChangedBy string             `json:"changed_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type ApiKey struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	// public part of the key, shown in listings and used to look it up
+	Prefix string `json:"prefix"`
+	// SHA-256 of the whole key, the key itself is only shown when it is created
+	HashedKey string   `json:"hashed_key"`
+	Scopes    []string `json:"scopes"`
+	// NULL for a key that does not expire
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type AuditEvent struct {
+	ID int64 `json:"id"`
+	// username that made the change, system when it did not come from a user
+	Actor      string `json:"actor"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	// snapshot of the target before the change, null when it was created
+	Before []byte `json:"before"`
+	After  []byte `json:"after"`
+	// X-Request-ID of the API call, empty outside of one
+	RequestID string             `json:"request_id"`
+	ClientIp  string             `json:"client_ip"`
+	UserAgent string             `json:"user_agent"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type Currency struct {
+	// ISO 4217 code
+	Code string `json:"code"`
+	// number of minor units digits, amounts are stored in minor units
+	Exponent int16 `json:"exponent"`
+	// disabled currencies keep their accounts but new accounts and transfers are refused
+	Enabled   bool               `json:"enabled"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
@@ -67,15 +353,132 @@ type Entry struct {
 	// can be positive and negetive
 	Amount    int64              `json:"amount"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	// hash of the previous entry of the same account, null for the first one
+	PrevHash []byte `json:"prev_hash"`
+	// sha256 of prev_hash, account_id, amount and created_at in microseconds, as big endian int64s
+	Hash []byte `json:"hash"`
+}
+
+type Hold struct {
+	ID          int64 `json:"id"`
+	AccountID   int64 `json:"account_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// in the currency of account_id, only positive
+	Amount int64      `json:"amount"`
+	Status HoldStatus `json:"status"`
+	// an active hold past this time is released by the worker and can no longer be captured
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	// transfer made by the capture
+	TransferID pgtype.Int8        `json:"transfer_id"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Key         string `json:"key"`
+	Username    string `json:"username"`
+	RequestHash string `json:"request_hash"`
+	// 0 while the original request is still in progress
+	ResponseCode int32              `json:"response_code"`
+	ResponseBody []byte             `json:"response_body"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	ExpiredAt    pgtype.Timestamptz `json:"expired_at"`
+}
+
+type ReconciliationDiscrepancy struct {
+	ID         int64 `json:"id"`
+	RunID      int64 `json:"run_id"`
+	AccountID  int64 `json:"account_id"`
+	Balance    int64 `json:"balance"`
+	EntriesSum int64 `json:"entries_sum"`
+	// balance - entries_sum
+	Difference int64              `json:"difference"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type ReconciliationRun struct {
+	ID int64 `json:"id"`
+	// scheduler, cli or the username of the admin who started it
+	TriggeredBy     string             `json:"triggered_by"`
+	AccountsChecked int64              `json:"accounts_checked"`
+	Discrepancies   int64              `json:"discrepancies"`
+	StartedAt       pgtype.Timestamptz `json:"started_at"`
+	// null while running, or when the run failed
+	FinishedAt pgtype.Timestamptz `json:"finished_at"`
+}
+
+type RevokedToken struct {
+	// token payload ID
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// rows past this time can be purged, the token is rejected as expired anyway
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// both accounts hold the same currency, the rate of a future transfer is not known
+	Amount  int64              `json:"amount"`
+	StartAt pgtype.Timestamptz `json:"start_at"`
+	// five field cron expression in UTC, null unless it recurs on a calendar
+	Cron pgtype.Text `json:"cron"`
+	// runs every interval from start_at, null unless it recurs on an interval
+	IntervalSeconds pgtype.Int8             `json:"interval_seconds"`
+	Status          ScheduledTransferStatus `json:"status"`
+	// occurrence being run, it only moves once the occurrence succeeded or ran out of attempts
+	NextRunAt pgtype.Timestamptz `json:"next_run_at"`
+	// when a worker may pick the row next, pushed back by the worker lease and by retries
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	// failed attempts of the current occurrence
+	Attempts  int32              `json:"attempts"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64              `json:"id"`
+	ScheduledTransferID int64              `json:"scheduled_transfer_id"`
+	Occurrence          pgtype.Timestamptz `json:"occurrence"`
+	Attempt             int32              `json:"attempt"`
+	// set when the attempt succeeded
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	// set when the attempt failed
+	Error     pgtype.Text        `json:"error"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type Session struct {
+	// same as the refresh token payload ID
+	ID           uuid.UUID          `json:"id"`
+	Username     string             `json:"username"`
+	RefreshToken string             `json:"refresh_token"`
+	UserAgent    string             `json:"user_agent"`
+	ClientIp     string             `json:"client_ip"`
+	IsBlocked    bool               `json:"is_blocked"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 }
 
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	// only positive
+	// only positive, in the currency of from_account
 	Amount    int64              `json:"amount"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	// only positive, in the currency of to_account, equals amount unless the currencies differ
+	ToAmount int64 `json:"to_amount"`
+	// to_account currency units per from_account currency unit, to_amount = floor(amount * exchange_rate)
+	ExchangeRate pgtype.Numeric `json:"exchange_rate"`
+	Kind         TransferKind   `json:"kind"`
+	// reference of the deposit or withdrawal in the external system, unique per kind
+	ExternalRef pgtype.Text `json:"external_ref"`
+	// transfer this reversal gives back, the reversals of a transfer never add up to more than its to_amount
+	ReversedTransferID pgtype.Int8 `json:"reversed_transfer_id"`
 }
 
 type User struct {
@@ -85,4 +488,5 @@ type User struct {
 	Email             string             `json:"email"`
 	PasswordChangedAt pgtype.Timestamptz `json:"password_changed_at"`
 	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	Role              UserRole           `json:"role"`
 }
@@ -6,24 +6,90 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountHeldBalance(ctx context.Context, arg AddAccountHeldBalanceParams) (Account, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Session, error)
+	BlockUserSessions(ctx context.Context, username string) ([]Session, error)
+	// pushes next_attempt_at past the lease, so other workers skip the rows while they run
+	ClaimDueScheduledTransfers(ctx context.Context, arg ClaimDueScheduledTransfersParams) ([]ScheduledTransfer, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountStatusChange(ctx context.Context, arg CreateAccountStatusChangeParams) (AccountStatusChange, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	// an expired key is taken over, a live one is left alone and no row is returned
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateReconciliationDiscrepancy(ctx context.Context, arg CreateReconciliationDiscrepancyParams) (ReconciliationDiscrepancy, error)
+	CreateReconciliationRun(ctx context.Context, triggeredBy string) (ReconciliationRun, error)
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	FinishReconciliationRun(ctx context.Context, arg FinishReconciliationRunParams) (ReconciliationRun, error)
+	GetAPIKey(ctx context.Context, id int64) (ApiKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetClearingAccount(ctx context.Context, currency string) (Account, error)
+	GetCurrency(ctx context.Context, code string) (Currency, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	// skips holds another worker is already expiring
+	GetExpiredHoldForUpdate(ctx context.Context) (Hold, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLastEntry(ctx context.Context, accountID int64) (Entry, error)
+	GetReconciliationRun(ctx context.Context, id int64) (ReconciliationRun, error)
+	GetReversedAmount(ctx context.Context, transferID int64) (int64, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetScheduledTransferForUpdate(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	IsTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+	ListAPIKeys(ctx context.Context, username string) ([]ApiKey, error)
+	// balance and sum come from the same statement, so they are read from one snapshot
+	ListAccountBalanceSums(ctx context.Context, arg ListAccountBalanceSumsParams) ([]ListAccountBalanceSumsRow, error)
+	ListAccountChain(ctx context.Context, arg ListAccountChainParams) ([]Entry, error)
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error)
+	ListAccountHolds(ctx context.Context, arg ListAccountHoldsParams) ([]Hold, error)
+	ListAccountIDs(ctx context.Context, arg ListAccountIDsParams) ([]int64, error)
+	ListAccountStatusChanges(ctx context.Context, accountID int64) ([]AccountStatusChange, error)
+	// each half walks its own (account_id, id) index newest first, amounts are compared
+	// in the currency of the account, amount when it sent and to_amount when it received
+	ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
 	ListEntrys(ctx context.Context, arg ListEntrysParams) ([]Entry, error)
+	ListReconciliationDiscrepancies(ctx context.Context, runID int64) ([]ReconciliationDiscrepancy, error)
+	ListReconciliationRuns(ctx context.Context, arg ListReconciliationRunsParams) ([]ReconciliationRun, error)
+	ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRun, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	// a scheduled transfer paused or cancelled while it ran keeps its status
+	RescheduleScheduledTransfer(ctx context.Context, arg RescheduleScheduledTransferParams) (ScheduledTransfer, error)
+	RevokeAPIKey(ctx context.Context, id int64) (ApiKey, error)
+	SetAccountLastEntryHash(ctx context.Context, arg SetAccountLastEntryHashParams) error
+	SetAccountOverdraft(ctx context.Context, arg SetAccountOverdraftParams) (Account, error)
+	TouchAPIKey(ctx context.Context, id int64) error
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (Hold, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error)
+	UpdateScheduledTransfer(ctx context.Context, arg UpdateScheduledTransferParams) (ScheduledTransfer, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -7,25 +7,41 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
-  from_account_id, to_account_id, amount
+  from_account_id, to_account_id, amount, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID      int64          `json:"from_account_id"`
+	ToAccountID        int64          `json:"to_account_id"`
+	Amount             int64          `json:"amount"`
+	ToAmount           int64          `json:"to_amount"`
+	ExchangeRate       pgtype.Numeric `json:"exchange_rate"`
+	Kind               TransferKind   `json:"kind"`
+	ExternalRef        pgtype.Text    `json:"external_ref"`
+	ReversedTransferID pgtype.Int8    `json:"reversed_transfer_id"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRow(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.Kind,
+		arg.ExternalRef,
+		arg.ReversedTransferID,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -33,12 +49,29 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Kind,
+		&i.ExternalRef,
+		&i.ReversedTransferID,
 	)
 	return i, err
 }
 
+const getReversedAmount = `-- name: GetReversedAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount FROM transfers
+WHERE reversed_transfer_id = $1::bigint
+`
+
+func (q *Queries) GetReversedAmount(ctx context.Context, transferID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, getReversedAmount, transferID)
+	var reversed_amount int64
+	err := row.Scan(&reversed_amount)
+	return reversed_amount, err
+}
+
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -51,12 +84,123 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Kind,
+		&i.ExternalRef,
+		&i.ReversedTransferID,
 	)
 	return i, err
 }
 
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id FROM transfers
+WHERE id = $1 LIMIT 1
+FOR UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRow(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Kind,
+		&i.ExternalRef,
+		&i.ReversedTransferID,
+	)
+	return i, err
+}
+
+const listAccountTransfers = `-- name: ListAccountTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id FROM (
+  (SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id FROM transfers
+  WHERE from_account_id = $1
+    AND ($2::bigint IS NULL OR id < $2)
+    AND ($3::timestamptz IS NULL OR created_at >= $3)
+    AND ($4::timestamptz IS NULL OR created_at < $4)
+    AND ($5::text IS NULL OR $5 = 'out')
+    AND ($6::bigint IS NULL OR amount >= $6)
+    AND ($7::bigint IS NULL OR amount <= $7)
+  ORDER BY id DESC
+  LIMIT $8)
+  UNION ALL
+  (SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id FROM transfers
+  WHERE to_account_id = $1
+    AND from_account_id <> $1
+    AND ($2::bigint IS NULL OR id < $2)
+    AND ($3::timestamptz IS NULL OR created_at >= $3)
+    AND ($4::timestamptz IS NULL OR created_at < $4)
+    AND ($5::text IS NULL OR $5 = 'in')
+    AND ($6::bigint IS NULL OR to_amount >= $6)
+    AND ($7::bigint IS NULL OR to_amount <= $7)
+  ORDER BY id DESC
+  LIMIT $8)
+) AS account_transfers
+ORDER BY id DESC
+LIMIT $8
+`
+
+type ListAccountTransfersParams struct {
+	AccountID int64              `json:"account_id"`
+	Cursor    pgtype.Int8        `json:"cursor"`
+	StartTime pgtype.Timestamptz `json:"start_time"`
+	EndTime   pgtype.Timestamptz `json:"end_time"`
+	Direction pgtype.Text        `json:"direction"`
+	MinAmount pgtype.Int8        `json:"min_amount"`
+	MaxAmount pgtype.Int8        `json:"max_amount"`
+	PageSize  int32              `json:"page_size"`
+}
+
+// each half walks its own (account_id, id) index newest first, amounts are compared
+// in the currency of the account, amount when it sent and to_amount when it received
+func (q *Queries) ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listAccountTransfers,
+		arg.AccountID,
+		arg.Cursor,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Direction,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Kind,
+			&i.ExternalRef,
+			&i.ReversedTransferID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, kind, external_ref, reversed_transfer_id FROM transfers
 ORDER BY id
 LIMIT $1 OFFSET $2
 `
@@ -81,6 +225,11 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Kind,
+			&i.ExternalRef,
+			&i.ReversedTransferID,
 		); err != nil {
 			return nil, err
 		}
@@ -3,16 +3,45 @@ package db
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"slices"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
 )
 
 // Store provides all functions to execute db queries and transactions
 type Store interface {
 	sqlc.Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	DepositTx(ctx context.Context, arg DepositTxParams) (TransferTxResult, error)
+	WithdrawTx(ctx context.Context, arg WithdrawTxParams) (TransferTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (ChangeAccountStatusTxResult, error)
+	CreateAccountTx(ctx context.Context, arg sqlc.CreateAccountParams) (sqlc.Account, error)
+	CreateUserTx(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error)
+	CreateSessionTx(ctx context.Context, arg sqlc.CreateSessionParams) (sqlc.Session, error)
+	BlockSessionTx(ctx context.Context, id uuid.UUID) (sqlc.Session, error)
+	BlockUserSessionsTx(ctx context.Context, username string) ([]sqlc.Session, error)
+	RevokeTokenTx(ctx context.Context, arg sqlc.CreateRevokedTokenParams) error
+	CreateScheduledTransferTx(ctx context.Context, arg sqlc.CreateScheduledTransferParams) (sqlc.ScheduledTransfer, error)
+	UpdateScheduledTransferTx(ctx context.Context, arg UpdateScheduledTransferTxParams) (sqlc.ScheduledTransfer, error)
+	RunScheduledTransferTx(ctx context.Context, arg RunScheduledTransferTxParams) (sqlc.ScheduledTransferRun, error)
+	PlaceHoldTx(ctx context.Context, arg sqlc.CreateHoldParams) (sqlc.Hold, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	ReleaseHoldTx(ctx context.Context, holdID int64) (sqlc.Hold, error)
+	ExpireHoldTx(ctx context.Context) (sqlc.Hold, error)
+	CreateAPIKeyTx(ctx context.Context, arg sqlc.CreateAPIKeyParams) (sqlc.ApiKey, error)
+	RevokeAPIKeyTx(ctx context.Context, id int64) (sqlc.ApiKey, error)
+	CreateReconciliationRunTx(ctx context.Context, triggeredBy string) (sqlc.ReconciliationRun, error)
+	CreateReconciliationDiscrepancyTx(ctx context.Context, arg sqlc.CreateReconciliationDiscrepancyParams) (sqlc.ReconciliationDiscrepancy, error)
+	FinishReconciliationRunTx(ctx context.Context, arg sqlc.FinishReconciliationRunParams) (sqlc.ReconciliationRun, error)
 }
 
 // SQLStore provides all functions to execute db queries and transactions
@@ -24,33 +53,67 @@ type SQLStore struct {
 func NewStore(db *pgxpool.Pool) Store {
 	return &SQLStore{
 		db:      db,
-		Queries: sqlc.New(db),
+		Queries: newQueries(db),
 	}
 }
 
-func (store *SQLStore) execTo(ctx context.Context, fn func(*sqlc.Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, pgx.TxOptions{})
+// execTo runs fn in a transaction. The audit event fn returns is written in the same
+// transaction, so a change is never committed without its audit row.
+// A transaction failing with a serialization failure or a deadlock is rolled back and run again
+// after a short random wait, up to the attempts allowed by the TxOptions in ctx. fn must not keep
+// anything from a failed attempt, it is called again from the start.
+func (store *SQLStore) execTo(ctx context.Context, fn func(*sqlc.Queries) (*AuditEvent, error)) error {
+	options := TxOptionsFrom(ctx)
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, options, fn)
+		code := retryableCode(err)
+		if code == "" {
+			return err
+		}
+
+		txRetries.Add(code, 1)
+		if attempt >= options.MaxAttempts {
+			txRetries.Add("exhausted", 1)
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+func (store *SQLStore) runTx(ctx context.Context, options TxOptions, fn func(*sqlc.Queries) (*AuditEvent, error)) error {
+	tx, err := store.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: options.IsoLevel})
 	if err != nil {
 		return err
 	}
 
-	q := sqlc.New(tx)
-	err = fn(q)
+	q := newQueries(tx)
+	event, err := fn(q)
+	if err == nil && event != nil {
+		err = writeAuditEvent(ctx, q, event)
+	}
 	// if there any type of error than rollback
 	if err != nil {
 		// if there is even occurs error while rollback then return this error
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 		return err
 	}
-	return tx.Commit(ctx)
+	return translateError(tx.Commit(ctx))
 }
 
 type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	// Amount is debited in the currency of the from account
+	Amount int64 `json:"amount"`
+	// Rate converts Amount into the currency of the to account, nil when both share a currency
+	Rate *exchange.Rate `json:"rate,omitempty"`
 }
 
 type TransferTxResult struct {
@@ -66,107 +129,221 @@ type TransferTxResult struct {
 
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
-	err := store.execTo(ctx, func(q *sqlc.Queries) error {
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
 		var err error
+		result, err = transferMoney(ctx, q, arg)
+		if err != nil {
+			return nil, err
+		}
+		return transferAuditEvent(AuditActionCreateTransfer, result), nil
+	})
+	return result, err
+}
+
+// transferMoney converts the amount with arg.Rate and moves it between the two accounts,
+// TransferTx and the capture of a hold both go through it
+func transferMoney(ctx context.Context, q *sqlc.Queries, arg TransferTxParams) (TransferTxResult, error) {
+	toAmount := arg.Amount
+	rateValue := exchange.RateUnit
+	if arg.Rate != nil {
+		var err error
+		toAmount, err = arg.Rate.Convert(arg.Amount)
+		if err != nil {
+			return TransferTxResult{}, err
+		}
+		rateValue = arg.Rate.Value
+	}
+
+	result, err := moveMoney(ctx, q, sqlc.CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      toAmount,
+		ExchangeRate:  numericRate(rateValue),
+		Kind:          sqlc.TransferKindTransfer,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = checkCurrencies(result.FromAccount, result.ToAccount, arg.Rate)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// moveMoney records the transfer with its two entries and updates both balances.
+// Every money movement goes through it, so rows are always locked in account id order,
+// accounts that are not active can neither send nor receive, and every entry is chained.
+func moveMoney(ctx context.Context, q *sqlc.Queries, arg sqlc.CreateTransferParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	result.Transfer, err = q.CreateTransfer(ctx, arg)
+	if err != nil {
+		return result, err
+	}
 
-		result.Transfer, err = q.CreateTransfer(ctx, sqlc.CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
+	// Update accounts with proper locking order
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, err = q.AddAccountBalance(ctx, sqlc.AddAccountBalanceParams{
+			ID:     arg.FromAccountID,
+			Amount: -arg.Amount,
 		})
 		if err != nil {
-			return err
+			return result, err
+		}
+
+		err = checkActive(result.FromAccount)
+		if err != nil {
+			return result, err
 		}
 
-		result.FromEntry, err = q.CreateEntry(ctx, sqlc.CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+		err = checkOverdraft(result.FromAccount, arg.Amount)
+		if err != nil {
+			return result, err
+		}
+
+		result.ToAccount, err = q.AddAccountBalance(ctx, sqlc.AddAccountBalanceParams{
+			ID:     arg.ToAccountID,
+			Amount: arg.ToAmount,
 		})
 		if err != nil {
-			return err
+			return result, err
 		}
 
-		result.ToEntry, err = q.CreateEntry(ctx, sqlc.CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+		err = checkActive(result.ToAccount)
+		if err != nil {
+			return result, err
+		}
+	} else {
+		result.ToAccount, err = q.AddAccountBalance(ctx, sqlc.AddAccountBalanceParams{
+			ID:     arg.ToAccountID,
+			Amount: arg.ToAmount,
 		})
 		if err != nil {
-			return err
+			return result, err
 		}
 
-		// Update accounts with proper locking order
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, err = q.AddAccountBalance(ctx, sqlc.AddAccountBalanceParams{
-				ID:     arg.FromAccountID,
-				Amount: -arg.Amount,
-			})
-			if err != nil {
-				return err
-			}
-
-			result.ToAccount, err = q.AddAccountBalance(ctx, sqlc.AddAccountBalanceParams{
-				ID:     arg.ToAccountID,
-				Amount: arg.Amount,
-			})
-			if err != nil {
-				return err
-			}
-		} else {
-			result.ToAccount, err = q.AddAccountBalance(ctx, sqlc.AddAccountBalanceParams{
-				ID:     arg.ToAccountID,
-				Amount: arg.Amount,
-			})
-			if err != nil {
-				return err
-			}
-
-			result.FromAccount, err = q.AddAccountBalance(ctx, sqlc.AddAccountBalanceParams{
-				ID:     arg.FromAccountID,
-				Amount: -arg.Amount,
-			})
-			if err != nil {
-				return err
-			}
+		err = checkActive(result.ToAccount)
+		if err != nil {
+			return result, err
 		}
 
-		return nil
-	})
-	return result, err
-}
+		result.FromAccount, err = q.AddAccountBalance(ctx, sqlc.AddAccountBalanceParams{
+			ID:     arg.FromAccountID,
+			Amount: -arg.Amount,
+		})
+		if err != nil {
+			return result, err
+		}
 
-func addMoney(
-	ctx context.Context,
-	q *sqlc.Queries,
-	accountID1 int64,
-	amount1 int64,
-	accountID2 int64,
-	amount2 int64,
-) (account1 sqlc.Account, account2 sqlc.Account, err error) {
-	account1, err = q.GetAccountForUpdate(ctx, accountID1)
-	if err != nil {
-		return account1, account2, err
+		err = checkActive(result.FromAccount)
+		if err != nil {
+			return result, err
+		}
+
+		err = checkOverdraft(result.FromAccount, arg.Amount)
+		if err != nil {
+			return result, err
+		}
 	}
 
-	account1, err = q.UpdateAccount(ctx, sqlc.UpdateAccountParams{
-		ID:      accountID1,
-		Balance: account1.Balance + amount1,
-	})
+	// both rows are locked now, the entries can be appended to the hash chains
+	result.FromEntry, err = appendEntry(ctx, q, arg.FromAccountID, -arg.Amount)
 	if err != nil {
-		return account1, account2, err
+		return result, err
 	}
 
-	account2, err = q.GetAccountForUpdate(ctx, accountID2)
+	result.ToEntry, err = appendEntry(ctx, q, arg.ToAccountID, arg.ToAmount)
 	if err != nil {
-		return account1, account2, err
+		return result, err
 	}
 
-	account2, err = q.UpdateAccount(ctx, sqlc.UpdateAccountParams{
-		ID:      accountID2,
-		Balance: account2.Balance + amount2,
-	})
-	if err != nil {
-		return account1, account2, err
+	return result, nil
+}
+
+// lockAccounts takes the row locks of the accounts in id order, the order moveMoney uses,
+// so transactions that touch several accounts cannot deadlock each other
+func lockAccounts(ctx context.Context, q *sqlc.Queries, accountIDs ...int64) error {
+	ids := slices.Clone(accountIDs)
+	slices.Sort(ids)
+	for _, id := range slices.Compact(ids) {
+		_, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transferAuditEvent records the accounts as they were before and after a money movement,
+// the balances before are worked out from the amounts so no extra read is needed
+func transferAuditEvent(action string, result TransferTxResult) *AuditEvent {
+	fromAccount := result.FromAccount
+	fromAccount.Balance += result.Transfer.Amount
+	fromAccount.AvailableBalance += result.Transfer.Amount
+	toAccount := result.ToAccount
+	toAccount.Balance -= result.Transfer.ToAmount
+	toAccount.AvailableBalance -= result.Transfer.ToAmount
+
+	return &AuditEvent{
+		Action:     action,
+		TargetType: "transfer",
+		TargetID:   auditID(result.Transfer.ID),
+		Before:     []sqlc.Account{fromAccount, toAccount},
+		After:      result,
+	}
+}
+
+func numericRate(value int64) pgtype.Numeric {
+	return pgtype.Numeric{Int: big.NewInt(value), Exp: -exchange.RateScale, Valid: true}
+}
+
+// checkActive runs on the locked row like checkOverdraft, so a concurrent freeze or close
+// either waits for the transfer or makes it roll back
+func checkActive(account sqlc.Account) error {
+	if account.Status == sqlc.AccountStatusActive {
+		return nil
 	}
+	return &AccountNotActiveError{AccountID: account.ID, Status: account.Status}
+}
 
-	return account1, account2, err
+// checkOverdraft is called with the account row already updated (and locked) by the debit or hold,
+// so the check and the change are atomic and returning an error rolls the change back.
+// It checks the available balance, money held for an authorization cannot be spent twice.
+func checkOverdraft(account sqlc.Account, amount int64) error {
+	var floor int64
+	switch account.OverdraftPolicy {
+	case sqlc.OverdraftPolicyUnlimited:
+		return nil
+	case sqlc.OverdraftPolicyLimit:
+		floor = -account.OverdraftLimit
+	}
+
+	if account.AvailableBalance >= floor {
+		return nil
+	}
+
+	return &InsufficientFundsError{
+		AccountID: account.ID,
+		Amount:    amount,
+		// available balance before this debit plus whatever overdraft is allowed
+		Available: account.AvailableBalance + amount - floor,
+	}
+}
+
+// checkCurrencies makes sure the rate applied matches the accounts it was applied to,
+// it runs inside the tx so a stale lookup rolls the transfer back
+func checkCurrencies(fromAccount sqlc.Account, toAccount sqlc.Account, rate *exchange.Rate) error {
+	if rate == nil {
+		return nil
+	}
+
+	if string(fromAccount.Currency) != rate.From || string(toAccount.Currency) != rate.To {
+		return fmt.Errorf("exchange rate %s/%s doesn't match accounts [%d] %s and [%d] %s",
+			rate.From, rate.To, fromAccount.ID, fromAccount.Currency, toAccount.ID, toAccount.Currency)
+	}
+	return nil
 }
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestChangeAccountStatusTx(t *testing.T) {
+	store := db.NewStore(testDB)
+	admin := createRandomUser(t)
+	account := createRandomAccount(t)
+
+	result, err := store.ChangeAccountStatusTx(context.Background(), db.ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    sqlc.AccountStatusFrozen,
+		Reason:    "court order",
+		ChangedBy: admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sqlc.AccountStatusFrozen, result.Account.Status)
+	require.Equal(t, account.ID, result.Change.AccountID)
+	require.Equal(t, sqlc.AccountStatusActive, result.Change.FromStatus)
+	require.Equal(t, sqlc.AccountStatusFrozen, result.Change.ToStatus)
+	require.Equal(t, "court order", result.Change.Reason)
+	require.Equal(t, admin.Username, result.Change.ChangedBy)
+
+	// frozen accounts go back to active before they can be closed
+	_, err = store.ChangeAccountStatusTx(context.Background(), db.ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    sqlc.AccountStatusClosed,
+		Reason:    utils.RandomString(10),
+		ChangedBy: account.Owner,
+	})
+	require.ErrorIs(t, err, db.ErrInvalidStatusTransition)
+
+	_, err = store.ChangeAccountStatusTx(context.Background(), db.ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    sqlc.AccountStatusActive,
+		Reason:    "order lifted",
+		ChangedBy: admin.Username,
+	})
+	require.NoError(t, err)
+
+	changes, err := store.ListAccountStatusChanges(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, sqlc.AccountStatusActive, changes[1].ToStatus)
+}
+
+func TestCloseAccount(t *testing.T) {
+	store := db.NewStore(testDB)
+	account := fundAccount(t, createRandomAccount(t), 10)
+
+	_, err := store.ChangeAccountStatusTx(context.Background(), db.ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    sqlc.AccountStatusClosed,
+		Reason:    utils.RandomString(10),
+		ChangedBy: account.Owner,
+	})
+	require.ErrorIs(t, err, db.ErrAccountNotEmpty)
+
+	account = fundAccount(t, account, -account.Balance)
+	result, err := store.ChangeAccountStatusTx(context.Background(), db.ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    sqlc.AccountStatusClosed,
+		Reason:    utils.RandomString(10),
+		ChangedBy: account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sqlc.AccountStatusClosed, result.Account.Status)
+
+	// closed is final
+	_, err = store.ChangeAccountStatusTx(context.Background(), db.ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    sqlc.AccountStatusActive,
+		Reason:    utils.RandomString(10),
+		ChangedBy: account.Owner,
+	})
+	require.ErrorIs(t, err, db.ErrInvalidStatusTransition)
+}
+
+func TestTransferTxAccountNotActive(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccount(t)
+
+	_, err := testQueries.UpdateAccountStatus(context.Background(), sqlc.UpdateAccountStatusParams{
+		ID:     account2.ID,
+		Status: sqlc.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, db.ErrAccountNotActive)
+
+	var statusErr *db.AccountNotActiveError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, account2.ID, statusErr.AccountID)
+
+	// the debit is rolled back
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
@@ -88,6 +88,37 @@ func TestDeleteAccount(t *testing.T) {
 	require.ErrorIs(t, err, pgx.ErrNoRows)
 }
 
+func TestSetAccountOverdraft(t *testing.T) {
+	account := createRandomAccount(t)
+	require.Equal(t, sqlc.OverdraftPolicyNone, account.OverdraftPolicy)
+	require.Zero(t, account.OverdraftLimit)
+
+	args := sqlc.SetAccountOverdraftParams{
+		ID:              account.ID,
+		OverdraftPolicy: sqlc.OverdraftPolicyLimit,
+		OverdraftLimit:  utils.RandomMoney(),
+	}
+	returnedAccount, err := testQueries.SetAccountOverdraft(context.Background(), args)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, returnedAccount.ID)
+	require.Equal(t, account.Balance, returnedAccount.Balance)
+	require.Equal(t, args.OverdraftPolicy, returnedAccount.OverdraftPolicy)
+	require.Equal(t, args.OverdraftLimit, returnedAccount.OverdraftLimit)
+}
+
+func TestUpdateAccountStatus(t *testing.T) {
+	account := createRandomAccount(t)
+	require.Equal(t, sqlc.AccountStatusActive, account.Status)
+
+	returnedAccount, err := testQueries.UpdateAccountStatus(context.Background(), sqlc.UpdateAccountStatusParams{
+		ID:     account.ID,
+		Status: sqlc.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, returnedAccount.ID)
+	require.Equal(t, sqlc.AccountStatusFrozen, returnedAccount.Status)
+}
+
 func TestListAccounts(t *testing.T) {
 	var lastAccount sqlc.Account
 	for i := 0; i < 10; i++ {
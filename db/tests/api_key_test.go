@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func createRandomAPIKey(t *testing.T, username string) sqlc.ApiKey {
+	_, prefix, hashedKey, err := utils.NewAPIKey()
+	require.NoError(t, err)
+
+	arg := sqlc.CreateAPIKeyParams{
+		Username:  username,
+		Name:      utils.RandomString(8),
+		Prefix:    prefix,
+		HashedKey: hashedKey,
+		Scopes:    []string{utils.AccountsReadScope, utils.TransfersWriteScope},
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	apiKey, err := testQueries.CreateAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, apiKey.ID)
+	require.Equal(t, arg.Username, apiKey.Username)
+	require.Equal(t, arg.Name, apiKey.Name)
+	require.Equal(t, arg.Prefix, apiKey.Prefix)
+	require.Equal(t, arg.HashedKey, apiKey.HashedKey)
+	require.Equal(t, arg.Scopes, apiKey.Scopes)
+	require.WithinDuration(t, arg.ExpiresAt.Time, apiKey.ExpiresAt.Time, time.Second)
+	require.False(t, apiKey.LastUsedAt.Valid)
+	require.False(t, apiKey.RevokedAt.Valid)
+	require.NotZero(t, apiKey.CreatedAt)
+
+	return apiKey
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	createRandomAPIKey(t, createRandomUser(t).Username)
+}
+
+func TestCreateAPIKeyWithoutScopes(t *testing.T) {
+	_, prefix, hashedKey, err := utils.NewAPIKey()
+	require.NoError(t, err)
+
+	_, err = testQueries.CreateAPIKey(context.Background(), sqlc.CreateAPIKeyParams{
+		Username:  createRandomUser(t).Username,
+		Name:      utils.RandomString(8),
+		Prefix:    prefix,
+		HashedKey: hashedKey,
+		Scopes:    []string{},
+	})
+	require.Error(t, err)
+}
+
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t).Username)
+
+	found, err := testQueries.GetAPIKeyByPrefix(context.Background(), apiKey.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, apiKey.ID, found.ID)
+	require.Equal(t, apiKey.HashedKey, found.HashedKey)
+
+	_, err = testQueries.GetAPIKeyByPrefix(context.Background(), "sbk_00000000")
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestListAPIKeys(t *testing.T) {
+	user := createRandomUser(t)
+	apiKey1 := createRandomAPIKey(t, user.Username)
+	apiKey2 := createRandomAPIKey(t, user.Username)
+	createRandomAPIKey(t, createRandomUser(t).Username)
+
+	apiKeys, err := testQueries.ListAPIKeys(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 2)
+	require.Equal(t, apiKey1.ID, apiKeys[0].ID)
+	require.Equal(t, apiKey2.ID, apiKeys[1].ID)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t).Username)
+
+	revoked, err := testQueries.RevokeAPIKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	// a revoked key is not revoked again
+	_, err = testQueries.RevokeAPIKey(context.Background(), apiKey.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestTouchAPIKey(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t).Username)
+
+	err := testQueries.TouchAPIKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+
+	touched, err := testQueries.GetAPIKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+	require.True(t, touched.LastUsedAt.Valid)
+	require.WithinDuration(t, time.Now(), touched.LastUsedAt.Time, time.Minute)
+}
+
+func TestAPIKeyTxAudit(t *testing.T) {
+	store := db.NewStore(testDB)
+	user := createRandomUser(t)
+	ctx := db.WithAuditMetadata(context.Background(), db.AuditMetadata{Actor: user.Username})
+
+	_, prefix, hashedKey, err := utils.NewAPIKey()
+	require.NoError(t, err)
+	apiKey, err := store.CreateAPIKeyTx(ctx, sqlc.CreateAPIKeyParams{
+		Username:  user.Username,
+		Name:      utils.RandomString(8),
+		Prefix:    prefix,
+		HashedKey: hashedKey,
+		Scopes:    []string{utils.AccountsReadScope},
+	})
+	require.NoError(t, err)
+
+	revoked, err := store.RevokeAPIKeyTx(ctx, apiKey.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	_, err = store.RevokeAPIKeyTx(ctx, apiKey.ID)
+	require.ErrorIs(t, err, db.ErrNotFound)
+
+	events, err := store.ListAuditEvents(context.Background(), sqlc.ListAuditEventsParams{
+		TargetType: pgtype.Text{String: "api_key", Valid: true},
+		TargetID:   pgtype.Text{String: strconv.FormatInt(apiKey.ID, 10), Valid: true},
+		PageSize:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	actions := []string{events[0].Action, events[1].Action}
+	require.ElementsMatch(t, []string{db.AuditActionCreateAPIKey, db.AuditActionRevokeAPIKey}, actions)
+	for _, event := range events {
+		require.Equal(t, user.Username, event.Actor)
+		// the key hash never makes it into the audit trail
+		require.NotContains(t, string(event.After), hashedKey)
+	}
+}
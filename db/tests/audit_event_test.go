@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestCreateAccountTxAudit(t *testing.T) {
+	store := db.NewStore(testDB)
+	user := createRandomUser(t)
+
+	metadata := db.AuditMetadata{
+		Actor:     user.Username,
+		RequestID: utils.RandomString(12),
+		ClientIP:  "127.0.0.1",
+		UserAgent: "test",
+	}
+	ctx := db.WithAuditMetadata(context.Background(), metadata)
+
+	account, err := store.CreateAccountTx(ctx, sqlc.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: utils.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	events, err := store.ListAuditEvents(context.Background(), sqlc.ListAuditEventsParams{
+		TargetType: pgtype.Text{String: "account", Valid: true},
+		TargetID:   pgtype.Text{String: strconv.FormatInt(account.ID, 10), Valid: true},
+		PageSize:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	require.Equal(t, db.AuditActionCreateAccount, event.Action)
+	require.Equal(t, metadata.Actor, event.Actor)
+	require.Equal(t, metadata.RequestID, event.RequestID)
+	require.Equal(t, metadata.ClientIP, event.ClientIp)
+	require.Equal(t, metadata.UserAgent, event.UserAgent)
+	require.Nil(t, event.Before)
+
+	var after sqlc.Account
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, account.ID, after.ID)
+}
+
+func TestTransferTxAudit(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	amount := int64(10)
+	account1 := fundAccount(t, createRandomAccount(t), amount)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	events, err := store.ListAuditEvents(context.Background(), sqlc.ListAuditEventsParams{
+		Action:   pgtype.Text{String: db.AuditActionCreateTransfer, Valid: true},
+		TargetID: pgtype.Text{String: strconv.FormatInt(result.Transfer.ID, 10), Valid: true},
+		PageSize: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	// no metadata in the context
+	require.Equal(t, "system", events[0].Actor)
+
+	var before []sqlc.Account
+	require.NoError(t, json.Unmarshal(events[0].Before, &before))
+	require.Len(t, before, 2)
+	require.Equal(t, account1.Balance, before[0].Balance)
+	require.Equal(t, account2.Balance, before[1].Balance)
+}
+
+func TestAuditEventsAppendOnly(t *testing.T) {
+	store := db.NewStore(testDB)
+	user := createRandomUser(t)
+
+	account, err := store.CreateAccountTx(context.Background(), sqlc.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: utils.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	_, err = testDB.Exec(context.Background(), "UPDATE audit_events SET actor = 'someone' WHERE target_id = $1", strconv.FormatInt(account.ID, 10))
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = testDB.Exec(context.Background(), "DELETE FROM audit_events WHERE target_id = $1", strconv.FormatInt(account.ID, 10))
+	require.ErrorContains(t, err, "append-only")
+}
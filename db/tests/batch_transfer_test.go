@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestBatchTransferTx(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), 100)
+	account2 := createAccountInCurrency(t, utils.USD)
+	account3 := createAccountInCurrency(t, utils.USD)
+
+	result, err := store.BatchTransferTx(context.Background(), db.BatchTransferTxParams{
+		Transfers: []db.TransferTxParams{
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 30},
+			{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 20},
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Transfers, 3)
+	require.Equal(t, int64(70), result.Transfers[0].FromAccount.Balance)
+	require.Equal(t, int64(50), result.Transfers[1].FromAccount.Balance)
+	require.Equal(t, int64(40), result.Transfers[2].FromAccount.Balance)
+	require.Equal(t, int64(40), result.Transfers[2].ToAccount.Balance)
+
+	// the second entry of account2 in the batch is chained to the first
+	require.Equal(t, result.Transfers[0].ToEntry.Hash, result.Transfers[2].ToEntry.PrevHash)
+}
+
+func TestBatchTransferTxAllOrNothing(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), 100)
+	account2 := createAccountInCurrency(t, utils.USD)
+
+	_, err := store.BatchTransferTx(context.Background(), db.BatchTransferTxParams{
+		Transfers: []db.TransferTxParams{
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 60},
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 60},
+		},
+	})
+	var batchErr *db.BatchTransferError
+	require.ErrorAs(t, err, &batchErr)
+	require.Equal(t, 1, batchErr.Index)
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	// the first transfer was rolled back with the second
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account.Balance)
+
+	account, err = store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.Balance)
+}
+
+func TestBatchTransferTxDeadlock(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	n := 10
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), 100)
+	account2 := fundAccount(t, createAccountInCurrency(t, utils.USD), 100)
+	account3 := fundAccount(t, createAccountInCurrency(t, utils.USD), 100)
+
+	// half of the batches go around the accounts one way and half the other way
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		transfers := []db.TransferTxParams{
+			{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1},
+			{FromAccountID: account2.ID, ToAccountID: account3.ID, Amount: 1},
+			{FromAccountID: account3.ID, ToAccountID: account1.ID, Amount: 1},
+		}
+		if i%2 == 1 {
+			transfers = []db.TransferTxParams{
+				{FromAccountID: account3.ID, ToAccountID: account2.ID, Amount: 1},
+				{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 1},
+				{FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 1},
+			}
+		}
+
+		go func() {
+			_, err := store.BatchTransferTx(context.Background(), db.BatchTransferTxParams{Transfers: transfers})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	for _, account := range []int64{account1.ID, account2.ID, account3.ID} {
+		updated, err := store.GetAccount(context.Background(), account)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), updated.Balance)
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestGetClearingAccount(t *testing.T) {
+	account, err := testQueries.GetClearingAccount(context.Background(), utils.USD)
+	require.NoError(t, err)
+	require.Equal(t, db.ClearingAccountOwner, account.Owner)
+	require.Equal(t, utils.USD, account.Currency)
+	require.Equal(t, sqlc.OverdraftPolicyUnlimited, account.OverdraftPolicy)
+}
+
+func TestDepositTx(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account := createRandomAccount(t)
+	clearingAccount, err := testQueries.GetClearingAccount(context.Background(), account.Currency)
+	require.NoError(t, err)
+
+	amount := utils.RandomMoney()
+	externalRef := utils.RandomString(16)
+
+	result, err := store.DepositTx(context.Background(), db.DepositTxParams{
+		AccountID:   account.ID,
+		Amount:      amount,
+		ExternalRef: externalRef,
+	})
+	require.NoError(t, err)
+
+	transfer := result.Transfer
+	require.Equal(t, clearingAccount.ID, transfer.FromAccountID)
+	require.Equal(t, account.ID, transfer.ToAccountID)
+	require.Equal(t, amount, transfer.Amount)
+	require.Equal(t, sqlc.TransferKindDeposit, transfer.Kind)
+	require.Equal(t, externalRef, transfer.ExternalRef.String)
+
+	require.Equal(t, -amount, result.FromEntry.Amount)
+	require.Equal(t, amount, result.ToEntry.Amount)
+	require.Equal(t, account.Balance+amount, result.ToAccount.Balance)
+
+	// the same reference cannot be booked twice
+	_, err = store.DepositTx(context.Background(), db.DepositTxParams{
+		AccountID:   account.ID,
+		Amount:      amount,
+		ExternalRef: externalRef,
+	})
+	var pgErr *pgconn.PgError
+	require.ErrorAs(t, err, &pgErr)
+	require.Equal(t, "23505", pgErr.Code)
+
+	updatedAccount, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.ToAccount.Balance, updatedAccount.Balance)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	amount := utils.RandomMoney()
+	account := fundAccount(t, createRandomAccount(t), amount)
+	clearingAccount, err := testQueries.GetClearingAccount(context.Background(), account.Currency)
+	require.NoError(t, err)
+
+	result, err := store.WithdrawTx(context.Background(), db.WithdrawTxParams{
+		AccountID:   account.ID,
+		Amount:      amount,
+		ExternalRef: utils.RandomString(16),
+	})
+	require.NoError(t, err)
+
+	transfer := result.Transfer
+	require.Equal(t, account.ID, transfer.FromAccountID)
+	require.Equal(t, clearingAccount.ID, transfer.ToAccountID)
+	require.Equal(t, sqlc.TransferKindWithdrawal, transfer.Kind)
+	require.Equal(t, account.Balance-amount, result.FromAccount.Balance)
+
+	// the account is empty now, the default overdraft policy rejects another withdrawal
+	_, err = store.WithdrawTx(context.Background(), db.WithdrawTxParams{
+		AccountID:   account.ID,
+		Amount:      amount,
+		ExternalRef: utils.RandomString(16),
+	})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+}
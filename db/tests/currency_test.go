@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestGetCurrency(t *testing.T) {
+	currency, err := testQueries.GetCurrency(context.Background(), utils.USD)
+	require.NoError(t, err)
+	require.Equal(t, utils.USD, currency.Code)
+	require.Equal(t, int16(2), currency.Exponent)
+	require.True(t, currency.Enabled)
+
+	_, err = testQueries.GetCurrency(context.Background(), "XXX")
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	codes := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		codes = append(codes, currency.Code)
+	}
+	require.Subset(t, codes, []string{utils.USD, utils.EUR, utils.GBP})
+	require.IsNonDecreasing(t, codes)
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestStoreErrorNotFound(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	_, err := store.GetAccount(context.Background(), -1)
+	require.ErrorIs(t, err, db.ErrNotFound)
+	// callers still checking the driver error keep working
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestStoreErrorConflict(t *testing.T) {
+	store := db.NewStore(testDB)
+	user := createRandomUser(t)
+
+	_, err := store.CreateUserTx(context.Background(), sqlc.CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          utils.RandomEmail(),
+	})
+	require.ErrorIs(t, err, db.ErrConflict)
+
+	var dbErr *db.Error
+	require.ErrorAs(t, err, &dbErr)
+	require.Equal(t, "users_pkey", dbErr.Constraint)
+}
+
+func TestStoreErrorForeignKey(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	_, err := store.CreateAccountTx(context.Background(), sqlc.CreateAccountParams{
+		Owner:    utils.RandomOwner(),
+		Currency: utils.USD,
+	})
+	require.ErrorIs(t, err, db.ErrForeignKey)
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestListAccountHistory(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	n := 5
+	account1 := fundAccount(t, createRandomAccount(t), int64(n)*10)
+	account2 := createRandomAccount(t)
+
+	for i := 1; i <= n; i++ {
+		_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        int64(i),
+		})
+		require.NoError(t, err)
+	}
+
+	// walk account1's outgoing entries two at a time, newest first
+	var seen []sqlc.Entry
+	var cursor pgtype.Int8
+	for {
+		entries, err := store.ListAccountEntries(context.Background(), sqlc.ListAccountEntriesParams{
+			AccountID: account1.ID,
+			Cursor:    cursor,
+			Direction: pgtype.Text{String: "out", Valid: true},
+			PageSize:  2,
+		})
+		require.NoError(t, err)
+		seen = append(seen, entries...)
+		if len(entries) < 2 {
+			break
+		}
+		cursor = pgtype.Int8{Int64: entries[len(entries)-1].ID, Valid: true}
+	}
+	require.Len(t, seen, n)
+	for i, entry := range seen {
+		require.Equal(t, account1.ID, entry.AccountID)
+		require.Equal(t, -int64(n-i), entry.Amount)
+	}
+
+	// account1 never received anything
+	entries, err := store.ListAccountEntries(context.Background(), sqlc.ListAccountEntriesParams{
+		AccountID: account1.ID,
+		Direction: pgtype.Text{String: "in", Valid: true},
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// incoming transfers for account2 filtered by amount
+	transfers, err := store.ListAccountTransfers(context.Background(), sqlc.ListAccountTransfersParams{
+		AccountID: account2.ID,
+		Direction: pgtype.Text{String: "in", Valid: true},
+		MinAmount: pgtype.Int8{Int64: 2, Valid: true},
+		MaxAmount: pgtype.Int8{Int64: 4, Valid: true},
+		PageSize:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 3)
+	for _, transfer := range transfers {
+		require.Equal(t, account2.ID, transfer.ToAccountID)
+		require.True(t, transfer.Amount >= 2 && transfer.Amount <= 4)
+	}
+}
+
+func TestListAccountTransfersBothDirections(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := fundAccount(t, createRandomAccount(t), 100)
+
+	// alternate directions so every page mixes both halves of the query
+	var want []int64
+	for i := 1; i <= 5; i++ {
+		arg := db.TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: int64(i)}
+		if i%2 == 0 {
+			arg.FromAccountID, arg.ToAccountID = account2.ID, account1.ID
+		}
+		result, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+		want = append([]int64{result.Transfer.ID}, want...)
+	}
+
+	var seen []int64
+	var cursor pgtype.Int8
+	for {
+		transfers, err := store.ListAccountTransfers(context.Background(), sqlc.ListAccountTransfersParams{
+			AccountID: account1.ID,
+			Cursor:    cursor,
+			PageSize:  2,
+		})
+		require.NoError(t, err)
+		for _, transfer := range transfers {
+			seen = append(seen, transfer.ID)
+		}
+		if len(transfers) < 2 {
+			break
+		}
+		cursor = pgtype.Int8{Int64: transfers[len(transfers)-1].ID, Valid: true}
+	}
+	require.Equal(t, want, seen)
+}
+
+func TestListAccountTransfersCrossCurrencyAmount(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), 1000)
+	account2 := createAccountInCurrency(t, utils.EUR)
+
+	rate, err := exchange.ParseRate(utils.USD, utils.EUR, "0.9215")
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		Rate:          &rate,
+	})
+	require.NoError(t, err)
+
+	// 100 USD sent arrive as 92 EUR, each account filters in its own currency
+	testCases := []struct {
+		accountID int64
+		minAmount int64
+		maxAmount int64
+		count     int
+	}{
+		{account1.ID, 100, 100, 1},
+		{account1.ID, 92, 92, 0},
+		{account2.ID, 92, 92, 1},
+		{account2.ID, 100, 100, 0},
+	}
+	for _, tc := range testCases {
+		transfers, err := store.ListAccountTransfers(context.Background(), sqlc.ListAccountTransfersParams{
+			AccountID: tc.accountID,
+			MinAmount: pgtype.Int8{Int64: tc.minAmount, Valid: true},
+			MaxAmount: pgtype.Int8{Int64: tc.maxAmount, Valid: true},
+			PageSize:  10,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, tc.count)
+	}
+}
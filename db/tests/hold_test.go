@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), 100)
+	account2 := createAccountInCurrency(t, utils.USD)
+
+	hold, err := store.PlaceHoldTx(context.Background(), sqlc.CreateHoldParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      80,
+		ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, sqlc.HoldStatusActive, hold.Status)
+
+	held, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), held.Balance)
+	require.Equal(t, int64(80), held.HeldBalance)
+	require.Equal(t, int64(20), held.AvailableBalance)
+
+	// the held money cannot be spent by a transfer
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        21,
+	})
+	var fundsErr *db.InsufficientFundsError
+	require.ErrorAs(t, err, &fundsErr)
+	require.Equal(t, int64(20), fundsErr.Available)
+
+	_, err = store.CaptureHoldTx(context.Background(), db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 81})
+	require.ErrorIs(t, err, db.ErrCaptureExceedsHold)
+
+	result, err := store.CaptureHoldTx(context.Background(), db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 50})
+	require.NoError(t, err)
+	require.Equal(t, sqlc.HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, int64(50), result.Transfer.Amount)
+	require.Equal(t, int64(50), result.ToAccount.Balance)
+
+	// the 30 not captured went back to the available balance
+	require.Equal(t, int64(50), result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.HeldBalance)
+	require.Equal(t, int64(50), result.FromAccount.AvailableBalance)
+
+	_, err = store.CaptureHoldTx(context.Background(), db.CaptureHoldTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, db.ErrHoldNotActive)
+
+	_, err = store.ReleaseHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, db.ErrHoldNotActive)
+}
+
+func TestPlaceHoldTxInsufficientFunds(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), 100)
+	account2 := createAccountInCurrency(t, utils.USD)
+
+	_, err := store.PlaceHoldTx(context.Background(), sqlc.CreateHoldParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      101,
+		ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	var fundsErr *db.InsufficientFundsError
+	require.ErrorAs(t, err, &fundsErr)
+	require.Equal(t, int64(100), fundsErr.Available)
+
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.HeldBalance)
+}
+
+func TestReleaseAndExpireHoldTx(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), 100)
+	account2 := createAccountInCurrency(t, utils.USD)
+
+	placeHold := func(expiresAt time.Time) sqlc.Hold {
+		hold, err := store.PlaceHoldTx(context.Background(), sqlc.CreateHoldParams{
+			AccountID:   account1.ID,
+			ToAccountID: account2.ID,
+			Amount:      40,
+			ExpiresAt:   pgtype.Timestamptz{Time: expiresAt, Valid: true},
+		})
+		require.NoError(t, err)
+		return hold
+	}
+
+	released := placeHold(time.Now().Add(time.Hour))
+	expired := placeHold(time.Now().Add(-time.Second))
+
+	hold, err := store.ReleaseHoldTx(context.Background(), released.ID)
+	require.NoError(t, err)
+	require.Equal(t, sqlc.HoldStatusReleased, hold.Status)
+	require.False(t, hold.TransferID.Valid)
+
+	_, err = store.CaptureHoldTx(context.Background(), db.CaptureHoldTxParams{HoldID: expired.ID})
+	require.ErrorIs(t, err, db.ErrHoldExpired)
+
+	// other tests may leave expired holds behind, expire until this one is gone
+	for {
+		hold, err = store.ExpireHoldTx(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, sqlc.HoldStatusExpired, hold.Status)
+		if hold.ID == expired.ID {
+			break
+		}
+	}
+
+	account, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), account.Balance)
+	require.Zero(t, account.HeldBalance)
+	require.Equal(t, int64(100), account.AvailableBalance)
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func createRandomIdempotencyKey(t *testing.T, expiredAt time.Time) sqlc.IdempotencyKey {
+	user := createRandomUser(t)
+	arg := sqlc.CreateIdempotencyKeyParams{
+		Key:         utils.RandomString(16),
+		Username:    user.Username,
+		RequestHash: utils.RandomString(64),
+		ExpiredAt:   pgtype.Timestamptz{Time: expiredAt, Valid: true},
+	}
+
+	key, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Key, key.Key)
+	require.Equal(t, arg.Username, key.Username)
+	require.Equal(t, arg.RequestHash, key.RequestHash)
+	require.Zero(t, key.ResponseCode)
+	require.Nil(t, key.ResponseBody)
+	require.WithinDuration(t, expiredAt, key.ExpiredAt.Time, time.Second)
+
+	return key
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+}
+
+func TestCreateIdempotencyKeyConflict(t *testing.T) {
+	key := createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+
+	// a live key is never taken over
+	_, err := testQueries.CreateIdempotencyKey(context.Background(), sqlc.CreateIdempotencyKeyParams{
+		Key:         key.Key,
+		Username:    key.Username,
+		RequestHash: utils.RandomString(64),
+		ExpiredAt:   pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestCreateIdempotencyKeyExpired(t *testing.T) {
+	key := createRandomIdempotencyKey(t, time.Now().Add(-time.Minute))
+
+	arg := sqlc.CreateIdempotencyKeyParams{
+		Key:         key.Key,
+		Username:    key.Username,
+		RequestHash: utils.RandomString(64),
+		ExpiredAt:   pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+	renewed, err := testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.RequestHash, renewed.RequestHash)
+	require.Zero(t, renewed.ResponseCode)
+}
+
+func TestUpdateIdempotencyKeyResponse(t *testing.T) {
+	key := createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+
+	arg := sqlc.UpdateIdempotencyKeyResponseParams{
+		Username:     key.Username,
+		Key:          key.Key,
+		ResponseCode: 201,
+		ResponseBody: []byte(`{"id":1}`),
+	}
+	updated, err := testQueries.UpdateIdempotencyKeyResponse(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ResponseCode, updated.ResponseCode)
+	require.JSONEq(t, string(arg.ResponseBody), string(updated.ResponseBody))
+
+	stored, err := testQueries.GetIdempotencyKey(context.Background(), sqlc.GetIdempotencyKeyParams{
+		Username: key.Username,
+		Key:      key.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, updated, stored)
+}
+
+func TestDeleteIdempotencyKey(t *testing.T) {
+	key := createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+
+	err := testQueries.DeleteIdempotencyKey(context.Background(), sqlc.DeleteIdempotencyKeyParams{
+		Username: key.Username,
+		Key:      key.Key,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.GetIdempotencyKey(context.Background(), sqlc.GetIdempotencyKeyParams{
+		Username: key.Username,
+		Key:      key.Key,
+	})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
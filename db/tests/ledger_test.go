@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestEntryHashChain(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	n := 5
+	amount := utils.RandomMoney()
+	account1 := fundAccount(t, createRandomAccount(t), int64(n)*amount)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	// concurrent transfers must still produce a single chain per account
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	for _, accountID := range []int64{account1.ID, account2.ID} {
+		report, err := db.VerifyAccountChain(context.Background(), store, accountID)
+		require.NoError(t, err)
+		require.True(t, report.Intact(), report.Reason)
+		require.Equal(t, int64(n), report.Entries)
+	}
+}
+
+func TestEntryHashChainTampered(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	amount := utils.RandomMoney()
+	account1 := fundAccount(t, createRandomAccount(t), 2*amount)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	var lastResult db.TransferTxResult
+	for i := 0; i < 2; i++ {
+		var err error
+		lastResult, err = store.TransferTx(context.Background(), db.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := testDB.Exec(context.Background(), "UPDATE entries SET amount = amount + 1 WHERE id = $1", lastResult.ToEntry.ID)
+	require.NoError(t, err)
+
+	report, err := db.VerifyAccountChain(context.Background(), store, account2.ID)
+	require.NoError(t, err)
+	require.False(t, report.Intact())
+	require.Equal(t, lastResult.ToEntry.ID, report.BrokenEntryID)
+
+	// the other side of the transfer is untouched
+	report, err = db.VerifyAccountChain(context.Background(), store, account1.ID)
+	require.NoError(t, err)
+	require.True(t, report.Intact())
+}
+
+func TestEntryHashChainTruncated(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	amount := utils.RandomMoney()
+	account1 := fundAccount(t, createRandomAccount(t), 2*amount)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	var results []db.TransferTxResult
+	for i := 0; i < 2; i++ {
+		result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+
+	// every link left is intact, only the stored last entry hash gives the deletion away
+	_, err := testDB.Exec(context.Background(), "DELETE FROM entries WHERE id = $1", results[1].FromEntry.ID)
+	require.NoError(t, err)
+
+	report, err := db.VerifyAccountChain(context.Background(), store, account1.ID)
+	require.NoError(t, err)
+	require.False(t, report.Intact())
+	require.Equal(t, results[0].FromEntry.ID, report.BrokenEntryID)
+	require.Equal(t, int64(1), report.Entries)
+
+	report, err = db.VerifyAccountChain(context.Background(), store, account2.ID)
+	require.NoError(t, err)
+	require.True(t, report.Intact())
+	require.Equal(t, int64(2), report.Entries)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, results[1].ToEntry.Hash, updatedAccount2.LastEntryHash)
+}
+
+func TestEntryHashChainMissingLastEntryHash(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	amount := utils.RandomMoney()
+	account1 := fundAccount(t, createRandomAccount(t), amount)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	// clearing the last entry hash must not hide the chain from verification
+	_, err = testDB.Exec(context.Background(), "UPDATE accounts SET last_entry_hash = NULL WHERE id = $1", account2.ID)
+	require.NoError(t, err)
+
+	report, err := db.VerifyAccountChain(context.Background(), store, account2.ID)
+	require.NoError(t, err)
+	require.False(t, report.Intact())
+	require.Equal(t, result.ToEntry.ID, report.BrokenEntryID)
+
+	// an account that never had an entry is intact
+	report, err = db.VerifyAccountChain(context.Background(), store, createRandomAccount(t).ID)
+	require.NoError(t, err)
+	require.True(t, report.Intact())
+	require.Zero(t, report.Entries)
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/reconcile"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestReconcile(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	// balances set without entries drift, transfers keep both in step
+	amount := utils.RandomMoney()
+	drifted := fundAccount(t, createAccountInCurrency(t, utils.USD), amount)
+	balanced := createAccountInCurrency(t, utils.USD)
+
+	report, err := reconcile.NewReconciler(store).Run(context.Background(), reconcile.TriggeredByCLI)
+	require.NoError(t, err)
+	require.True(t, report.Run.FinishedAt.Valid)
+	require.Equal(t, reconcile.TriggeredByCLI, report.Run.TriggeredBy)
+	require.Equal(t, int64(len(report.Discrepancies)), report.Run.Discrepancies)
+
+	found := false
+	for _, discrepancy := range report.Discrepancies {
+		require.NotEqual(t, balanced.ID, discrepancy.AccountID)
+		if discrepancy.AccountID == drifted.ID {
+			found = true
+			require.Equal(t, amount, discrepancy.Balance)
+			require.Zero(t, discrepancy.EntriesSum)
+			require.Equal(t, amount, discrepancy.Difference)
+		}
+	}
+	require.True(t, found)
+
+	stored, err := testQueries.ListReconciliationDiscrepancies(context.Background(), report.Run.ID)
+	require.NoError(t, err)
+	require.Equal(t, report.Discrepancies, stored)
+
+	// the run is audited from start to finish, along with each discrepancy
+	events, err := testQueries.ListAuditEvents(context.Background(), sqlc.ListAuditEventsParams{
+		TargetType: pgtype.Text{String: "reconciliation_run", Valid: true},
+		TargetID:   pgtype.Text{String: strconv.FormatInt(report.Run.ID, 10), Valid: true},
+		PageSize:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, db.AuditActionFinishReconciliation, events[0].Action)
+	require.Equal(t, db.AuditActionStartReconciliation, events[1].Action)
+
+	for _, discrepancy := range report.Discrepancies {
+		events, err := testQueries.ListAuditEvents(context.Background(), sqlc.ListAuditEventsParams{
+			TargetType: pgtype.Text{String: "reconciliation_discrepancy", Valid: true},
+			TargetID:   pgtype.Text{String: strconv.FormatInt(discrepancy.ID, 10), Valid: true},
+			PageSize:   10,
+		})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, db.AuditActionRecordDiscrepancy, events[0].Action)
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"expvar"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+// hammerOppositeTransfers sends n transfers each way between two accounts at the same time
+// and checks every one of them went through
+func hammerOppositeTransfers(t *testing.T, ctx context.Context, n int) {
+	store := db.NewStore(testDB)
+
+	amount := int64(10)
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), int64(n)*amount)
+	account2 := fundAccount(t, createAccountInCurrency(t, utils.USD), int64(n)*amount)
+
+	errs := make(chan error)
+	for i := 0; i < 2*n; i++ {
+		fromAccountID, toAccountID := account1.ID, account2.ID
+		if i%2 == 1 {
+			fromAccountID, toAccountID = account2.ID, account1.ID
+		}
+
+		go func() {
+			_, err := store.TransferTx(ctx, db.TransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < 2*n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updated1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated1.Balance)
+
+	updated2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updated2.Balance)
+}
+
+func TestTransferTxOppositeDirections(t *testing.T) {
+	hammerOppositeTransfers(t, context.Background(), 10)
+}
+
+// at serializable every transfer touching a row another one updated first fails with 40001,
+// they only all go through because execTo retries them
+func TestTransferTxSerializableRetries(t *testing.T) {
+	retries := expvar.Get("db_tx_retries").(*expvar.Map)
+	var before int64
+	if count := retries.Get("40001"); count != nil {
+		before = count.(*expvar.Int).Value()
+	}
+
+	ctx := db.WithTxOptions(context.Background(), db.TxOptions{
+		IsoLevel:    pgx.Serializable,
+		MaxAttempts: 50,
+	})
+	hammerOppositeTransfers(t, ctx, 5)
+
+	require.NotNil(t, retries.Get("40001"))
+	require.Greater(t, retries.Get("40001").(*expvar.Int).Value(), before)
+}
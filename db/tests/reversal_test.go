@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestReverseTransferTx(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	amount := int64(100)
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), amount)
+	account2 := createAccountInCurrency(t, utils.USD)
+
+	original, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	partial, err := store.ReverseTransferTx(context.Background(), db.ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sqlc.TransferKindReversal, partial.Transfer.Kind)
+	require.Equal(t, original.Transfer.ID, partial.Transfer.ReversedTransferID.Int64)
+	require.Equal(t, account2.ID, partial.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, partial.Transfer.ToAccountID)
+	require.Equal(t, int64(30), partial.Transfer.ToAmount)
+	require.Equal(t, int64(-30), partial.FromEntry.Amount)
+	require.Equal(t, int64(30), partial.ToEntry.Amount)
+
+	_, err = store.ReverseTransferTx(context.Background(), db.ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     71,
+	})
+	var reversalErr *db.ReversalExceedsTransferError
+	require.ErrorAs(t, err, &reversalErr)
+	require.Equal(t, int64(70), reversalErr.Reversible)
+
+	// no amount reverses what is left
+	rest, err := store.ReverseTransferTx(context.Background(), db.ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(70), rest.Transfer.Amount)
+	require.Equal(t, original.FromAccount.Balance+amount, rest.ToAccount.Balance)
+	require.Zero(t, rest.FromAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), db.ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.ErrorIs(t, err, db.ErrReversalExceedsTransfer)
+
+	_, err = store.ReverseTransferTx(context.Background(), db.ReverseTransferTxParams{
+		TransferID: rest.Transfer.ID,
+	})
+	require.ErrorIs(t, err, db.ErrTransferNotReversible)
+}
+
+func TestReverseConvertedTransferTx(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	amount := int64(1001)
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), amount)
+	account2 := createAccountInCurrency(t, utils.EUR)
+
+	rate, err := exchange.ParseRate(utils.USD, utils.EUR, "0.9215")
+	require.NoError(t, err)
+
+	original, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		Rate:          &rate,
+	})
+	require.NoError(t, err)
+
+	// reversing in uneven parts still gives back exactly what was sent
+	var returned int64
+	for _, part := range []int64{1, 333, 0} {
+		result, err := store.ReverseTransferTx(context.Background(), db.ReverseTransferTxParams{
+			TransferID: original.Transfer.ID,
+			Amount:     part,
+		})
+		require.NoError(t, err)
+		returned += result.Transfer.ToAmount
+	}
+	require.Equal(t, amount, returned)
+
+	account2, err = testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Zero(t, account2.Balance)
+}
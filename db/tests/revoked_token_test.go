@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/token"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestSQLRevoker(t *testing.T) {
+	user := createRandomUser(t)
+	revoker := db.NewRevoker(db.NewStore(testDB))
+
+	payload, err := token.NewPayload(user.Username, utils.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	revoked, err := revoker.IsRevoked(context.Background(), payload.ID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = revoker.Revoke(context.Background(), payload)
+	require.NoError(t, err)
+
+	revoked, err = revoker.IsRevoked(context.Background(), payload.ID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// revoking twice is harmless
+	err = revoker.Revoke(context.Background(), payload)
+	require.NoError(t, err)
+
+	events, err := testQueries.ListAuditEvents(context.Background(), sqlc.ListAuditEventsParams{
+		TargetType: pgtype.Text{String: "token", Valid: true},
+		TargetID:   pgtype.Text{String: payload.ID.String(), Valid: true},
+		PageSize:   10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	for _, event := range events {
+		require.Equal(t, db.AuditActionRevokeToken, event.Action)
+	}
+}
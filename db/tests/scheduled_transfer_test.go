@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/schedule"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func createScheduledTransfer(t *testing.T, from sqlc.Account, to sqlc.Account, amount int64, nextRunAt time.Time) sqlc.ScheduledTransfer {
+	store := db.NewStore(testDB)
+	transfer, err := store.CreateScheduledTransferTx(context.Background(), sqlc.CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		StartAt:       pgtype.Timestamptz{Time: nextRunAt, Valid: true},
+		NextRunAt:     pgtype.Timestamptz{Time: nextRunAt, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, sqlc.ScheduledTransferStatusActive, transfer.Status)
+	require.Equal(t, transfer.NextRunAt, transfer.NextAttemptAt)
+	return transfer
+}
+
+func TestClaimDueScheduledTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	due := createScheduledTransfer(t, account1, account2, 1, time.Now().Add(-time.Minute))
+	later := createScheduledTransfer(t, account1, account2, 1, time.Now().Add(time.Hour))
+
+	arg := sqlc.ClaimDueScheduledTransfersParams{LeaseSeconds: 60, BatchSize: 1000}
+	claimed, err := testQueries.ClaimDueScheduledTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Contains(t, scheduledTransferIDs(claimed), due.ID)
+	require.NotContains(t, scheduledTransferIDs(claimed), later.ID)
+
+	// the lease keeps it from being claimed twice
+	claimed, err = testQueries.ClaimDueScheduledTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotContains(t, scheduledTransferIDs(claimed), due.ID)
+}
+
+func TestScheduledTransferWorker(t *testing.T) {
+	amount := utils.RandomMoney()
+	account1 := fundAccount(t, createRandomAccount(t), amount)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	transfer := createScheduledTransfer(t, account1, account2, amount, time.Now().Add(-time.Minute))
+
+	_, err := schedule.NewWorker(db.NewStore(testDB)).RunDue(context.Background())
+	require.NoError(t, err)
+
+	runs, err := testQueries.ListScheduledTransferRuns(context.Background(), sqlc.ListScheduledTransferRunsParams{
+		ScheduledTransferID: transfer.ID,
+		Limit:               5,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.True(t, runs[0].TransferID.Valid)
+	require.False(t, runs[0].Error.Valid)
+	require.Equal(t, int32(1), runs[0].Attempt)
+
+	transfer, err = testQueries.GetScheduledTransfer(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, sqlc.ScheduledTransferStatusCompleted, transfer.Status)
+
+	account2, err = testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, amount, account2.Balance)
+}
+
+func TestRunScheduledTransferTxOnce(t *testing.T) {
+	store := db.NewStore(testDB)
+	amount := utils.RandomMoney()
+	account1 := fundAccount(t, createRandomAccount(t), 2*amount)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	transfer := createScheduledTransfer(t, account1, account2, amount, time.Now().Add(-time.Minute))
+
+	arg := db.RunScheduledTransferTxParams{
+		Transfer: &db.TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+		Run: sqlc.CreateScheduledTransferRunParams{
+			ScheduledTransferID: transfer.ID,
+			Occurrence:          transfer.NextRunAt,
+			Attempt:             1,
+		},
+		Next: sqlc.RescheduleScheduledTransferParams{
+			ID:            transfer.ID,
+			Status:        sqlc.ScheduledTransferStatusCompleted,
+			NextRunAt:     transfer.NextRunAt,
+			NextAttemptAt: transfer.NextRunAt,
+		},
+	}
+	run, err := store.RunScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, run.TransferID.Valid)
+
+	// a worker whose lease ran out tries the same attempt again
+	_, err = store.RunScheduledTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, db.ErrScheduledTransferRunStale)
+
+	account2, err = testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, amount, account2.Balance)
+}
+
+func TestUpdateScheduledTransferTx(t *testing.T) {
+	store := db.NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+	transfer := createScheduledTransfer(t, account1, account2, 10, time.Now().Add(time.Hour))
+
+	paused, err := store.UpdateScheduledTransferTx(context.Background(), db.UpdateScheduledTransferTxParams{
+		ID:     transfer.ID,
+		Status: sqlc.ScheduledTransferStatusPaused,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sqlc.ScheduledTransferStatusPaused, paused.Status)
+	require.Equal(t, transfer.Amount, paused.Amount)
+
+	updated, err := store.UpdateScheduledTransferTx(context.Background(), db.UpdateScheduledTransferTxParams{
+		ID:     transfer.ID,
+		Amount: 20,
+	})
+	require.NoError(t, err)
+	require.Equal(t, sqlc.ScheduledTransferStatusPaused, updated.Status)
+	require.Equal(t, int64(20), updated.Amount)
+
+	_, err = store.UpdateScheduledTransferTx(context.Background(), db.UpdateScheduledTransferTxParams{
+		ID:     transfer.ID,
+		Status: sqlc.ScheduledTransferStatusCancelled,
+	})
+	require.NoError(t, err)
+
+	_, err = store.UpdateScheduledTransferTx(context.Background(), db.UpdateScheduledTransferTxParams{
+		ID:     transfer.ID,
+		Status: sqlc.ScheduledTransferStatusActive,
+	})
+	require.ErrorIs(t, err, db.ErrScheduledTransferEnded)
+}
+
+func scheduledTransferIDs(transfers []sqlc.ScheduledTransfer) []int64 {
+	ids := make([]int64, len(transfers))
+	for i, transfer := range transfers {
+		ids[i] = transfer.ID
+	}
+	return ids
+}
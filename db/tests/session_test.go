@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func createRandomSession(t *testing.T) sqlc.Session {
+	user := createRandomUser(t)
+	arg := sqlc.CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: utils.RandomString(32),
+		UserAgent:    "Go-http-client/1.1",
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.False(t, session.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt.Time, session.ExpiresAt.Time, time.Second)
+	require.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+func TestCreateSession(t *testing.T) {
+	createRandomSession(t)
+}
+
+func TestGetSession(t *testing.T) {
+	session := createRandomSession(t)
+
+	returnedSession, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.Equal(t, session.ID, returnedSession.ID)
+	require.Equal(t, session.Username, returnedSession.Username)
+	require.Equal(t, session.RefreshToken, returnedSession.RefreshToken)
+	require.WithinDuration(t, session.ExpiresAt.Time, returnedSession.ExpiresAt.Time, time.Second)
+}
+
+func TestBlockSession(t *testing.T) {
+	session := createRandomSession(t)
+
+	blockedSession, err := testQueries.BlockSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.Equal(t, session.ID, blockedSession.ID)
+	require.True(t, blockedSession.IsBlocked)
+
+	_, err = testQueries.BlockSession(context.Background(), uuid.New())
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	session := createRandomSession(t)
+
+	arg := sqlc.CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     session.Username,
+		RefreshToken: utils.RandomString(32),
+		ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+	otherSession, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+
+	sessions, err := testQueries.BlockUserSessions(context.Background(), session.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	for _, blocked := range sessions {
+		require.True(t, blocked.IsBlocked)
+		require.Contains(t, []uuid.UUID{session.ID, otherSession.ID}, blocked.ID)
+	}
+
+	// already blocked sessions are not returned again
+	sessions, err = testQueries.BlockUserSessions(context.Background(), session.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestSessionTxAudit(t *testing.T) {
+	store := db.NewStore(testDB)
+	user := createRandomUser(t)
+	ctx := db.WithAuditMetadata(context.Background(), db.AuditMetadata{Actor: user.Username})
+
+	newSession := func() sqlc.Session {
+		session, err := store.CreateSessionTx(ctx, sqlc.CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     user.Username,
+			RefreshToken: utils.RandomString(32),
+			ExpiresAt:    pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+		})
+		require.NoError(t, err)
+		return session
+	}
+	loggedOut := newSession()
+	other := newSession()
+
+	_, err := store.BlockSessionTx(ctx, loggedOut.ID)
+	require.NoError(t, err)
+
+	sessions, err := store.BlockUserSessionsTx(ctx, user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, other.ID, sessions[0].ID)
+
+	requireSessionEvents := func(targetType string, targetID string, actions ...string) {
+		events, err := store.ListAuditEvents(context.Background(), sqlc.ListAuditEventsParams{
+			TargetType: pgtype.Text{String: targetType, Valid: true},
+			TargetID:   pgtype.Text{String: targetID, Valid: true},
+			PageSize:   10,
+		})
+		require.NoError(t, err)
+		require.Len(t, events, len(actions))
+		for i, event := range events {
+			require.Equal(t, actions[i], event.Action)
+			require.Equal(t, user.Username, event.Actor)
+			// refresh tokens stay out of the audit trail
+			require.NotContains(t, string(event.After), loggedOut.RefreshToken)
+			require.NotContains(t, string(event.After), other.RefreshToken)
+		}
+	}
+	requireSessionEvents("session", loggedOut.ID.String(), db.AuditActionLogout, db.AuditActionCreateSession)
+	requireSessionEvents("session", other.ID.String(), db.AuditActionCreateSession)
+	requireSessionEvents("user", user.Username, db.AuditActionRevokeSessions)
+}
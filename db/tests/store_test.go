@@ -7,20 +7,23 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/exchange"
 	"github.com/suryansh74/simplebank/utils"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := db.NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	// defining number of go routines
+	n := 5
 	amount := utils.RandomMoney()
 
-	fmt.Println(">> before:", account1.Balance, account2.Balance)
+	// account1 must be able to cover every transfer under the default overdraft policy
+	account1 := fundAccount(t, createRandomAccount(t), int64(n)*amount)
+	account2 := createRandomAccount(t)
 
-	// defining number of go routines
-	n := 5
+	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	// channels to get result and err
 	errs := make(chan error)
@@ -53,6 +56,8 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, account1.ID, transfer.FromAccountID)
 		require.Equal(t, account2.ID, transfer.ToAccountID)
 		require.Equal(t, amount, transfer.Amount)
+		require.Equal(t, amount, transfer.ToAmount)
+		require.Equal(t, sqlc.TransferKindTransfer, transfer.Kind)
 		require.NotZero(t, transfer.ID)
 		require.NotZero(t, transfer.CreatedAt)
 
@@ -113,3 +118,157 @@ func TestTransferTx(t *testing.T) {
 	require.Equal(t, account1.Balance-int64(n)*amount, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance+int64(n)*amount, updatedAccount2.Balance)
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	amount := account1.Balance + 1
+
+	_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	var fundsErr *db.InsufficientFundsError
+	require.ErrorAs(t, err, &fundsErr)
+	require.Equal(t, account1.ID, fundsErr.AccountID)
+	require.Equal(t, amount, fundsErr.Amount)
+	require.Equal(t, account1.Balance, fundsErr.Available)
+
+	// the whole transaction is rolled back
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestTransferTxOverdraftLimit(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	limit := int64(100)
+
+	account1, err := store.SetAccountOverdraft(context.Background(), sqlc.SetAccountOverdraftParams{
+		ID:              account1.ID,
+		OverdraftPolicy: sqlc.OverdraftPolicyLimit,
+		OverdraftLimit:  limit,
+	})
+	require.NoError(t, err)
+
+	// spending exactly down to the limit is allowed
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + limit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, -limit, result.FromAccount.Balance)
+
+	// one more unit is not
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	var fundsErr *db.InsufficientFundsError
+	require.ErrorAs(t, err, &fundsErr)
+	require.Zero(t, fundsErr.Available)
+}
+
+func TestTransferTxUnlimitedOverdraft(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	account1, err := store.SetAccountOverdraft(context.Background(), sqlc.SetAccountOverdraftParams{
+		ID:              account1.ID,
+		OverdraftPolicy: sqlc.OverdraftPolicyUnlimited,
+	})
+	require.NoError(t, err)
+
+	amount := account1.Balance + utils.RandomInt(1, 1000)
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, result.FromAccount.Balance)
+	require.Negative(t, result.FromAccount.Balance)
+}
+
+func TestTransferTxCrossCurrency(t *testing.T) {
+	store := db.NewStore(testDB)
+
+	account1 := fundAccount(t, createAccountInCurrency(t, utils.USD), 1000)
+	account2 := createAccountInCurrency(t, utils.EUR)
+
+	rate, err := exchange.ParseRate(utils.USD, utils.EUR, "0.9215")
+	require.NoError(t, err)
+
+	amount := int64(100)
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+		Rate:          &rate,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, int64(92), result.Transfer.ToAmount)
+
+	exchangeRate, err := result.Transfer.ExchangeRate.Float64Value()
+	require.NoError(t, err)
+	require.InDelta(t, 0.9215, exchangeRate.Float64, 1e-9)
+
+	require.Equal(t, -amount, result.FromEntry.Amount)
+	require.Equal(t, int64(92), result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+92, result.ToAccount.Balance)
+
+	// a rate for the wrong pair rolls the whole transfer back
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+		Rate:          &rate,
+	})
+	require.Error(t, err)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.FromAccount.Balance, updatedAccount1.Balance)
+}
+
+func createAccountInCurrency(t *testing.T, currency string) sqlc.Account {
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), sqlc.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+// fundAccount adds amount to the account balance directly, bypassing entries
+func fundAccount(t *testing.T, account sqlc.Account, amount int64) sqlc.Account {
+	account, err := testQueries.AddAccountBalance(context.Background(), sqlc.AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: amount,
+	})
+	require.NoError(t, err)
+	return account
+}
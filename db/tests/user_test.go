@@ -29,6 +29,7 @@ func createRandomUser(t *testing.T) sqlc.User {
 	require.Equal(t, arg.FullName, user.FullName)
 	require.Equal(t, arg.Email, user.Email)
 	require.True(t, user.PasswordChangedAt.Time.IsZero())
+	require.Equal(t, sqlc.UserRoleDepositor, user.Role)
 
 	require.NotZero(t, user.CreatedAt)
 
@@ -53,3 +54,15 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user.PasswordChangedAt.Time, returnedUser.PasswordChangedAt.Time, time.Second)
 	require.WithinDuration(t, user.CreatedAt.Time, returnedUser.CreatedAt.Time, time.Second)
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	user := createRandomUser(t)
+
+	updatedUser, err := testQueries.UpdateUserRole(context.Background(), sqlc.UpdateUserRoleParams{
+		Username: user.Username,
+		Role:     sqlc.UserRoleBanker,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, updatedUser.Username)
+	require.Equal(t, sqlc.UserRoleBanker, updatedUser.Role)
+}
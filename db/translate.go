@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// translateError turns the driver errors callers act on into an *Error and returns any other error as is
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Kind: ErrNotFound, Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	switch pgErr.Code {
+	case uniqueViolation:
+		return &Error{Kind: ErrConflict, Constraint: pgErr.ConstraintName, Err: err}
+	case foreignKeyViolation:
+		return &Error{Kind: ErrForeignKey, Constraint: pgErr.ConstraintName, Err: err}
+	default:
+		return err
+	}
+}
+
+// translatingDB wraps the pool or a transaction so every query made through sqlc
+// returns translated errors
+type translatingDB struct {
+	db sqlc.DBTX
+}
+
+func newQueries(db sqlc.DBTX) *sqlc.Queries {
+	return sqlc.New(translatingDB{db: db})
+}
+
+func (t translatingDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := t.db.Exec(ctx, sql, args...)
+	return tag, translateError(err)
+}
+
+func (t translatingDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := t.db.Query(ctx, sql, args...)
+	if err != nil {
+		return rows, translateError(err)
+	}
+	return translatingRows{Rows: rows}, nil
+}
+
+func (t translatingDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return translatingRow{row: t.db.QueryRow(ctx, sql, args...)}
+}
+
+type translatingRow struct {
+	row pgx.Row
+}
+
+func (r translatingRow) Scan(dest ...any) error {
+	return translateError(r.row.Scan(dest...))
+}
+
+type translatingRows struct {
+	pgx.Rows
+}
+
+func (r translatingRows) Scan(dest ...any) error {
+	return translateError(r.Rows.Scan(dest...))
+}
+
+func (r translatingRows) Err() error {
+	return translateError(r.Rows.Err())
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// CreateUserTx registers a user, the audit snapshot leaves the password hash out
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.User, error) {
+	var user sqlc.User
+	err := store.execTo(ctx, func(q *sqlc.Queries) (*AuditEvent, error) {
+		var err error
+		user, err = q.CreateUser(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot := user
+		snapshot.HashedPassword = ""
+		return &AuditEvent{
+			Action:     AuditActionCreateUser,
+			TargetType: "user",
+			TargetID:   user.Username,
+			After:      snapshot,
+		}, nil
+	})
+	return user, err
+}
@@ -0,0 +1,93 @@
+// Package exchange converts amounts between currencies
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// RateScale is the number of decimal places kept for a rate
+const RateScale = 8
+
+// RateUnit is the Value of a rate of exactly 1
+const RateUnit int64 = 100_000_000
+
+var (
+	ErrRateNotFound   = errors.New("exchange rate not found")
+	ErrAmountTooSmall = errors.New("amount is too small to convert")
+)
+
+// RateProvider looks up the rate to convert from one currency into another
+type RateProvider interface {
+	Rate(ctx context.Context, from string, to string) (Rate, error)
+}
+
+// Rate converts amounts of From into To. Value is the number of To units one From unit
+// is worth, scaled by 10^RateScale so no floats are involved.
+type Rate struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value int64  `json:"value"`
+}
+
+// ParseRate reads a decimal rate such as "0.9215", at most RateScale decimal places are allowed
+func ParseRate(from string, to string, value string) (Rate, error) {
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Rate{}, fmt.Errorf("invalid rate %q for %s/%s", value, from, to)
+	}
+
+	rat.Mul(rat, new(big.Rat).SetInt64(RateUnit))
+	if !rat.IsInt() || rat.Sign() <= 0 || !rat.Num().IsInt64() {
+		return Rate{}, fmt.Errorf("rate %q for %s/%s must be positive with at most %d decimal places", value, from, to, RateScale)
+	}
+
+	return Rate{From: from, To: to, Value: rat.Num().Int64()}, nil
+}
+
+// Convert turns amount of From into To, rounding down so the bank never pays out more than the rate allows
+func (rate Rate) Convert(amount int64) (int64, error) {
+	converted := new(big.Int).Mul(big.NewInt(amount), big.NewInt(rate.Value))
+	converted.Quo(converted, big.NewInt(RateUnit))
+
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("converting %d %s to %s overflows", amount, rate.From, rate.To)
+	}
+	if amount > 0 && converted.Sign() == 0 {
+		return 0, ErrAmountTooSmall
+	}
+	return converted.Int64(), nil
+}
+
+// Inverse returns the rate converting To back into From
+func (rate Rate) Inverse() Rate {
+	value := new(big.Int).Mul(big.NewInt(RateUnit), big.NewInt(RateUnit))
+	value.Quo(value, big.NewInt(rate.Value))
+	return Rate{From: rate.To, To: rate.From, Value: value.Int64()}
+}
+
+// ForMinorUnits adapts a rate quoted between major units to amounts kept in minor units,
+// e.g. USD (2 digits) to JPY (0 digits) at 150 becomes 1.5 cents to yen
+func (rate Rate) ForMinorUnits(fromExponent int16, toExponent int16) Rate {
+	value := big.NewInt(rate.Value)
+	shift := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExponent-fromExponent))), nil)
+	if toExponent > fromExponent {
+		value.Mul(value, shift)
+	} else {
+		value.Quo(value, shift)
+	}
+	return Rate{From: rate.From, To: rate.To, Value: value.Int64()}
+}
+
+func abs(value int16) int16 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func (rate Rate) String() string {
+	return new(big.Rat).SetFrac64(rate.Value, RateUnit).FloatString(RateScale)
+}
@@ -0,0 +1,75 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("USD", "EUR", "0.9215")
+	require.NoError(t, err)
+	require.Equal(t, "USD", rate.From)
+	require.Equal(t, "EUR", rate.To)
+	require.Equal(t, int64(92_150_000), rate.Value)
+	require.Equal(t, "0.92150000", rate.String())
+
+	for _, value := range []string{"", "abc", "0", "-1.5", "0.123456789"} {
+		_, err = ParseRate("USD", "EUR", value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	rate, err := ParseRate("USD", "EUR", "0.9215")
+	require.NoError(t, err)
+
+	converted, err := rate.Convert(1000)
+	require.NoError(t, err)
+	require.Equal(t, int64(921), converted)
+
+	// rounds down
+	converted, err = rate.Convert(3)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), converted)
+
+	_, err = rate.Convert(1)
+	require.True(t, errors.Is(err, ErrAmountTooSmall))
+
+	huge, err := ParseRate("USD", "JPY", "150")
+	require.NoError(t, err)
+	_, err = huge.Convert(1 << 62)
+	require.Error(t, err)
+}
+
+func TestInverse(t *testing.T) {
+	rate, err := ParseRate("USD", "EUR", "0.8")
+	require.NoError(t, err)
+
+	inverse := rate.Inverse()
+	require.Equal(t, "EUR", inverse.From)
+	require.Equal(t, "USD", inverse.To)
+	require.Equal(t, "1.25000000", inverse.String())
+}
+
+func TestForMinorUnits(t *testing.T) {
+	rate, err := ParseRate("USD", "JPY", "150")
+	require.NoError(t, err)
+
+	minor := rate.ForMinorUnits(2, 0)
+	require.Equal(t, "1.50000000", minor.String())
+
+	// 10.00 USD is 1500 JPY
+	converted, err := minor.Convert(1000)
+	require.NoError(t, err)
+	require.Equal(t, int64(1500), converted)
+
+	inverse := rate.Inverse().ForMinorUnits(0, 2)
+	converted, err = inverse.Convert(1500)
+	require.NoError(t, err)
+	require.Equal(t, int64(999), converted)
+
+	same := rate.ForMinorUnits(2, 2)
+	require.Equal(t, rate, same)
+}
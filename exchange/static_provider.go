@@ -0,0 +1,67 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StaticProvider serves rates from a fixed table, when only one direction
+// of a pair is listed the other one is derived from it
+type StaticProvider struct {
+	rates map[string]Rate
+}
+
+// NewStaticProvider takes rates keyed by "FROM/TO", e.g. {"USD/EUR": "0.92"}
+func NewStaticProvider(rates map[string]string) (RateProvider, error) {
+	provider := &StaticProvider{rates: make(map[string]Rate, len(rates))}
+
+	for pair, value := range rates {
+		from, to, ok := strings.Cut(pair, "/")
+		if !ok || len(from) == 0 || len(to) == 0 {
+			return nil, fmt.Errorf("invalid currency pair %q, expected FROM/TO", pair)
+		}
+
+		rate, err := ParseRate(from, to, value)
+		if err != nil {
+			return nil, err
+		}
+		provider.rates[pair] = rate
+	}
+
+	return provider, nil
+}
+
+// NewFileProvider loads a static table from a JSON file in the format NewStaticProvider takes
+func NewFileProvider(path string) (RateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read exchange rates: %w", err)
+	}
+
+	var rates map[string]string
+	err = json.Unmarshal(data, &rates)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse exchange rates %s: %w", path, err)
+	}
+
+	return NewStaticProvider(rates)
+}
+
+func (provider *StaticProvider) Rate(_ context.Context, from string, to string) (Rate, error) {
+	if from == to {
+		return Rate{From: from, To: to, Value: RateUnit}, nil
+	}
+
+	if rate, ok := provider.rates[from+"/"+to]; ok {
+		return rate, nil
+	}
+
+	if rate, ok := provider.rates[to+"/"+from]; ok {
+		return rate.Inverse(), nil
+	}
+
+	return Rate{}, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+}
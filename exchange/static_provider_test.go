@@ -0,0 +1,55 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticProvider(t *testing.T) {
+	provider, err := NewStaticProvider(map[string]string{"USD/EUR": "0.8"})
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "0.80000000", rate.String())
+
+	rate, err = provider.Rate(context.Background(), "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "1.25000000", rate.String())
+
+	rate, err = provider.Rate(context.Background(), "GBP", "GBP")
+	require.NoError(t, err)
+	require.Equal(t, RateUnit, rate.Value)
+
+	_, err = provider.Rate(context.Background(), "USD", "GBP")
+	require.True(t, errors.Is(err, ErrRateNotFound))
+}
+
+func TestStaticProviderInvalidPair(t *testing.T) {
+	_, err := NewStaticProvider(map[string]string{"USDEUR": "0.8"})
+	require.Error(t, err)
+
+	_, err = NewStaticProvider(map[string]string{"USD/EUR": "-0.8"})
+	require.Error(t, err)
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{"USD/GBP": "0.79"}`), 0o600)
+	require.NoError(t, err)
+
+	provider, err := NewFileProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "GBP")
+	require.NoError(t, err)
+	require.Equal(t, "0.79000000", rate.String())
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
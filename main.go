@@ -3,10 +3,13 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/suryansh74/simplebank/api"
 	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/reconcile"
+	"github.com/suryansh74/simplebank/schedule"
 	"github.com/suryansh74/simplebank/utils"
 )
 
@@ -22,10 +25,31 @@ func main() {
 	}
 
 	store := db.NewStore(conn)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "verify-ledger":
+			os.Exit(verifyLedger(context.Background(), store, os.Args[2:], os.Stdout))
+		case "reconcile":
+			os.Exit(runReconcile(context.Background(), reconcile.NewReconciler(store), os.Stdout))
+		}
+	}
+
 	server, err := api.NewServer(config, store)
 	if err != nil {
 		log.Fatal("unable to run server:", err)
 		return
 	}
+	if config.ReconcileInterval > 0 {
+		go reconcile.NewReconciler(store).Schedule(context.Background(), config.ReconcileInterval)
+	}
+	if config.SchedulerInterval > 0 {
+		go schedule.NewWorker(store).Poll(context.Background(), config.SchedulerInterval)
+	}
+	if config.HoldExpiryInterval > 0 {
+		go schedule.NewWorker(store).PollHolds(context.Background(), config.HoldExpiryInterval)
+	} else {
+		log.Println("HOLD_EXPIRY_INTERVAL is 0, expired holds keep their funds until another instance releases them")
+	}
 	server.Start(config.ServerAddress)
 }
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/suryansh74/simplebank/reconcile"
+)
+
+// runReconcile runs the reconcile subcommand and returns the process exit code:
+// 0 when every balance matches its entries, 1 when one does not and 2 when it could not run.
+//
+//	simplebank reconcile
+func runReconcile(ctx context.Context, reconciler *reconcile.Reconciler, out io.Writer) int {
+	report, err := reconciler.Run(ctx, reconcile.TriggeredByCLI)
+	if err != nil {
+		fmt.Fprintln(out, "cannot reconcile:", err)
+		return 2
+	}
+
+	for _, discrepancy := range report.Discrepancies {
+		fmt.Fprintf(out, "account %d: balance %d, entries sum to %d, off by %d\n",
+			discrepancy.AccountID, discrepancy.Balance, discrepancy.EntriesSum, discrepancy.Difference)
+	}
+	fmt.Fprintf(out, "run %d: %d accounts checked, %d discrepancies\n",
+		report.Run.ID, report.Run.AccountsChecked, report.Run.Discrepancies)
+
+	if report.Run.Discrepancies > 0 {
+		return 1
+	}
+	return 0
+}
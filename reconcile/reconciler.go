@@ -0,0 +1,123 @@
+// Package reconcile checks that every account balance equals the sum of its entries
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// callers recorded in reconciliation_runs.triggered_by, admins are recorded by username
+const (
+	TriggeredByScheduler = "scheduler"
+	TriggeredByCLI       = "cli"
+)
+
+// pageSize is how many accounts are compared per query
+const pageSize = 500
+
+// Store is the part of the store the reconciler needs, every row it writes is audited
+type Store interface {
+	CreateReconciliationRunTx(ctx context.Context, triggeredBy string) (sqlc.ReconciliationRun, error)
+	FinishReconciliationRunTx(ctx context.Context, arg sqlc.FinishReconciliationRunParams) (sqlc.ReconciliationRun, error)
+	CreateReconciliationDiscrepancyTx(ctx context.Context, arg sqlc.CreateReconciliationDiscrepancyParams) (sqlc.ReconciliationDiscrepancy, error)
+	ListAccountBalanceSums(ctx context.Context, arg sqlc.ListAccountBalanceSumsParams) ([]sqlc.ListAccountBalanceSumsRow, error)
+}
+
+// Report is a finished run with the discrepancies it found
+type Report struct {
+	Run           sqlc.ReconciliationRun           `json:"run"`
+	Discrepancies []sqlc.ReconciliationDiscrepancy `json:"discrepancies"`
+}
+
+type Reconciler struct {
+	store Store
+}
+
+func NewReconciler(store Store) *Reconciler {
+	return &Reconciler{store: store}
+}
+
+// Run compares every account and records the ones whose balance drifted from their entries.
+// A run that fails half way keeps the discrepancies found so far and no finished_at.
+func (reconciler *Reconciler) Run(ctx context.Context, triggeredBy string) (Report, error) {
+	report := Report{Discrepancies: []sqlc.ReconciliationDiscrepancy{}}
+
+	run, err := reconciler.store.CreateReconciliationRunTx(ctx, triggeredBy)
+	if err != nil {
+		return report, fmt.Errorf("cannot start reconciliation run: %w", err)
+	}
+	report.Run = run
+
+	var checked int64
+	var afterID int64
+	for {
+		sums, err := reconciler.store.ListAccountBalanceSums(ctx, sqlc.ListAccountBalanceSumsParams{
+			AfterID:  afterID,
+			PageSize: pageSize,
+		})
+		if err != nil {
+			return report, fmt.Errorf("reconciliation run %d: %w", run.ID, err)
+		}
+
+		for _, sum := range sums {
+			checked++
+			afterID = sum.AccountID
+			if sum.Balance == sum.EntriesSum {
+				continue
+			}
+
+			discrepancy, err := reconciler.store.CreateReconciliationDiscrepancyTx(ctx, sqlc.CreateReconciliationDiscrepancyParams{
+				RunID:      run.ID,
+				AccountID:  sum.AccountID,
+				Balance:    sum.Balance,
+				EntriesSum: sum.EntriesSum,
+				Difference: sum.Balance - sum.EntriesSum,
+			})
+			if err != nil {
+				return report, fmt.Errorf("reconciliation run %d: %w", run.ID, err)
+			}
+			report.Discrepancies = append(report.Discrepancies, discrepancy)
+		}
+
+		if len(sums) < pageSize {
+			break
+		}
+	}
+
+	report.Run, err = reconciler.store.FinishReconciliationRunTx(ctx, sqlc.FinishReconciliationRunParams{
+		ID:              run.ID,
+		AccountsChecked: checked,
+		Discrepancies:   int64(len(report.Discrepancies)),
+	})
+	if err != nil {
+		return report, fmt.Errorf("reconciliation run %d: %w", run.ID, err)
+	}
+	return report, nil
+}
+
+// Schedule runs a reconciliation every interval until ctx is done. Failed runs are logged
+// and retried at the next tick.
+func (reconciler *Reconciler) Schedule(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := reconciler.Run(ctx, TriggeredByScheduler)
+			if err != nil {
+				log.Println("reconciliation failed:", err)
+				continue
+			}
+			if report.Run.Discrepancies > 0 {
+				log.Printf("reconciliation run %d found %d discrepancies", report.Run.ID, report.Run.Discrepancies)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+func TestRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	run := sqlc.ReconciliationRun{ID: 3, TriggeredBy: TriggeredByCLI}
+
+	store.EXPECT().
+		CreateReconciliationRunTx(gomock.Any(), gomock.Eq(TriggeredByCLI)).
+		Times(1).
+		Return(run, nil)
+
+	store.EXPECT().
+		ListAccountBalanceSums(gomock.Any(), gomock.Eq(sqlc.ListAccountBalanceSumsParams{AfterID: 0, PageSize: pageSize})).
+		Times(1).
+		Return([]sqlc.ListAccountBalanceSumsRow{
+			{AccountID: 1, Balance: 100, EntriesSum: 100},
+			{AccountID: 2, Balance: 150, EntriesSum: 100},
+			{AccountID: 3, Balance: 0, EntriesSum: 0},
+		}, nil)
+
+	arg := sqlc.CreateReconciliationDiscrepancyParams{
+		RunID:      run.ID,
+		AccountID:  2,
+		Balance:    150,
+		EntriesSum: 100,
+		Difference: 50,
+	}
+	store.EXPECT().
+		CreateReconciliationDiscrepancyTx(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(sqlc.ReconciliationDiscrepancy{ID: 1, RunID: run.ID, AccountID: 2, Difference: 50}, nil)
+
+	finished := run
+	finished.AccountsChecked = 3
+	finished.Discrepancies = 1
+	store.EXPECT().
+		FinishReconciliationRunTx(gomock.Any(), gomock.Eq(sqlc.FinishReconciliationRunParams{ID: run.ID, AccountsChecked: 3, Discrepancies: 1})).
+		Times(1).
+		Return(finished, nil)
+
+	report, err := NewReconciler(store).Run(context.Background(), TriggeredByCLI)
+	require.NoError(t, err)
+	require.Equal(t, finished, report.Run)
+	require.Len(t, report.Discrepancies, 1)
+	require.Equal(t, int64(50), report.Discrepancies[0].Difference)
+}
+
+func TestRunPages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateReconciliationRunTx(gomock.Any(), gomock.Any()).
+		Return(sqlc.ReconciliationRun{ID: 1}, nil)
+
+	firstPage := make([]sqlc.ListAccountBalanceSumsRow, pageSize)
+	for i := range firstPage {
+		firstPage[i] = sqlc.ListAccountBalanceSumsRow{AccountID: int64(i + 1)}
+	}
+	gomock.InOrder(
+		store.EXPECT().
+			ListAccountBalanceSums(gomock.Any(), gomock.Eq(sqlc.ListAccountBalanceSumsParams{AfterID: 0, PageSize: pageSize})).
+			Return(firstPage, nil),
+		store.EXPECT().
+			ListAccountBalanceSums(gomock.Any(), gomock.Eq(sqlc.ListAccountBalanceSumsParams{AfterID: pageSize, PageSize: pageSize})).
+			Return([]sqlc.ListAccountBalanceSumsRow{}, nil),
+	)
+
+	store.EXPECT().
+		FinishReconciliationRunTx(gomock.Any(), gomock.Eq(sqlc.FinishReconciliationRunParams{ID: 1, AccountsChecked: pageSize})).
+		Return(sqlc.ReconciliationRun{ID: 1, AccountsChecked: pageSize}, nil)
+
+	report, err := NewReconciler(store).Run(context.Background(), TriggeredByScheduler)
+	require.NoError(t, err)
+	require.Empty(t, report.Discrepancies)
+}
+
+func TestRunError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateReconciliationRunTx(gomock.Any(), gomock.Any()).
+		Return(sqlc.ReconciliationRun{ID: 1}, nil)
+
+	store.EXPECT().
+		ListAccountBalanceSums(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("connection reset"))
+
+	store.EXPECT().
+		FinishReconciliationRunTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err := NewReconciler(store).Run(context.Background(), TriggeredByScheduler)
+	require.ErrorContains(t, err, "connection reset")
+}
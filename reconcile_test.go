@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+	"github.com/suryansh74/simplebank/reconcile"
+)
+
+func TestRunReconcile(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mock.MockStore)
+		exitCode   int
+		wantOutput string
+	}{
+		{
+			name: "Balanced",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateReconciliationRunTx(gomock.Any(), gomock.Eq(reconcile.TriggeredByCLI)).
+					Return(sqlc.ReconciliationRun{ID: 1}, nil)
+				store.EXPECT().ListAccountBalanceSums(gomock.Any(), gomock.Any()).
+					Return([]sqlc.ListAccountBalanceSumsRow{{AccountID: 4, Balance: 10, EntriesSum: 10}}, nil)
+				store.EXPECT().CreateReconciliationDiscrepancyTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().FinishReconciliationRunTx(gomock.Any(), gomock.Any()).
+					Return(sqlc.ReconciliationRun{ID: 1, AccountsChecked: 1}, nil)
+			},
+			exitCode:   0,
+			wantOutput: "run 1: 1 accounts checked, 0 discrepancies\n",
+		},
+		{
+			name: "Discrepancy",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateReconciliationRunTx(gomock.Any(), gomock.Eq(reconcile.TriggeredByCLI)).
+					Return(sqlc.ReconciliationRun{ID: 2}, nil)
+				store.EXPECT().ListAccountBalanceSums(gomock.Any(), gomock.Any()).
+					Return([]sqlc.ListAccountBalanceSumsRow{{AccountID: 4, Balance: 15, EntriesSum: 10}}, nil)
+				store.EXPECT().CreateReconciliationDiscrepancyTx(gomock.Any(), gomock.Any()).
+					Return(sqlc.ReconciliationDiscrepancy{RunID: 2, AccountID: 4, Balance: 15, EntriesSum: 10, Difference: 5}, nil)
+				store.EXPECT().FinishReconciliationRunTx(gomock.Any(), gomock.Any()).
+					Return(sqlc.ReconciliationRun{ID: 2, AccountsChecked: 1, Discrepancies: 1}, nil)
+			},
+			exitCode:   1,
+			wantOutput: "account 4: balance 15, entries sum to 10, off by 5\nrun 2: 1 accounts checked, 1 discrepancies\n",
+		},
+		{
+			name: "StoreError",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateReconciliationRunTx(gomock.Any(), gomock.Any()).
+					Return(sqlc.ReconciliationRun{}, errors.New("connection refused"))
+			},
+			exitCode:   2,
+			wantOutput: "cannot reconcile: cannot start reconciliation run: connection refused\n",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			var out bytes.Buffer
+			code := runReconcile(context.Background(), reconcile.NewReconciler(store), &out)
+			require.Equal(t, tc.exitCode, code)
+			require.Equal(t, tc.wantOutput, out.String())
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Package schedule works out when scheduled transfers are due and runs them,
+// its worker also releases holds once they expire
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNeverRuns is returned for cron expressions that match no date, like 0 0 30 2 *
+var ErrNeverRuns = errors.New("cron expression never runs")
+
+// cronSearchYears bounds the search for the next match, long enough to reach the next 29 February
+const cronSearchYears = 5
+
+// Cron is a parsed five field cron expression: minute hour day-of-month month day-of-week.
+// Fields take *, numbers, ranges a-b, steps */n or a-b/n and comma separated lists of those.
+// Day-of-week runs from 0 (Sunday) to 6, 7 is also Sunday. As in cron, when both day fields
+// are restricted a day matches if either of them does. Times are in UTC.
+type Cron struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	// set when that day field is *, only the other one is checked
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func ParseCron(expr string) (Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return Cron{}, fmt.Errorf("cron expression %q must have %d fields", expr, len(cronFields))
+	}
+
+	bits := make([]uint64, len(cronFields))
+	for i, field := range cronFields {
+		var err error
+		bits[i], err = parseCronField(parts[i], field)
+		if err != nil {
+			return Cron{}, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+	}
+
+	// 7 is another way to write Sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	cron := Cron{
+		minutes:       bits[0],
+		hours:         bits[1],
+		daysOfMonth:   bits[2],
+		months:        bits[3],
+		daysOfWeek:    bits[4],
+		anyDayOfMonth: parts[2] == "*",
+		anyDayOfWeek:  parts[4] == "*",
+	}
+	if cron.Next(time.Unix(0, 0)).IsZero() {
+		return Cron{}, fmt.Errorf("%w: %q", ErrNeverRuns, expr)
+	}
+	return cron, nil
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, field.name)
+			}
+		}
+
+		low, high := field.min, field.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			low, err = parseCronValue(lowPart, field)
+			if err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				high, err = parseCronValue(highPart, field)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// n/step starts at n and runs to the end of the field
+				high = field.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, field.name)
+			}
+		}
+
+		for i := low; i <= high; i += step {
+			bits |= 1 << i
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, field cronField) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < field.min || n > field.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %q", field.name, field.min, field.max, value)
+	}
+	return n, nil
+}
+
+// Next returns the first minute matching the expression strictly after t,
+// or the zero time when there is none within cronSearchYears
+func (cron Cron) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if cron.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !cron.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if cron.hours&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if cron.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (cron Cron) matchesDay(t time.Time) bool {
+	dayOfMonth := cron.daysOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := cron.daysOfWeek&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case cron.anyDayOfMonth:
+		return dayOfWeek
+	case cron.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
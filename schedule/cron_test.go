@@ -0,0 +1,126 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		expr string
+	}{
+		{name: "TooFewFields", expr: "0 9 1 *"},
+		{name: "TooManyFields", expr: "0 9 1 * * 2030"},
+		{name: "MinuteOutOfRange", expr: "60 9 1 * *"},
+		{name: "MonthOutOfRange", expr: "0 9 1 13 *"},
+		{name: "NotANumber", expr: "0 nine 1 * *"},
+		{name: "ReversedRange", expr: "0 17-9 * * *"},
+		{name: "ZeroStep", expr: "*/0 * * * *"},
+		{name: "NeverRuns", expr: "0 9 30 2 *"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCron(tc.expr)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name  string
+		expr  string
+		after time.Time
+		next  time.Time
+	}{
+		{
+			name:  "EveryMinute",
+			expr:  "* * * * *",
+			after: at(2030, time.March, 4, 10, 15).Add(30 * time.Second),
+			next:  at(2030, time.March, 4, 10, 16),
+		},
+		{
+			name:  "MonthlyOnTheFirst",
+			expr:  "0 9 1 * *",
+			after: at(2030, time.January, 1, 9, 0),
+			next:  at(2030, time.February, 1, 9, 0),
+		},
+		{
+			name:  "EndOfYear",
+			expr:  "30 23 31 12 *",
+			after: at(2030, time.December, 31, 23, 30),
+			next:  at(2031, time.December, 31, 23, 30),
+		},
+		{
+			name:  "WeekdaysEveryQuarterHour",
+			expr:  "*/15 9-17 * * 1-5",
+			after: at(2030, time.March, 8, 17, 45), // a Friday
+			next:  at(2030, time.March, 11, 9, 0),
+		},
+		{
+			name:  "SundayAsSeven",
+			expr:  "0 0 * * 7",
+			after: at(2030, time.March, 4, 0, 0), // a Monday
+			next:  at(2030, time.March, 10, 0, 0),
+		},
+		{
+			name:  "EitherDayField",
+			expr:  "0 12 15 * 1",
+			after: at(2030, time.March, 12, 0, 0), // a Tuesday
+			next:  at(2030, time.March, 15, 12, 0),
+		},
+		{
+			name:  "LeapDay",
+			expr:  "0 0 29 2 *",
+			after: at(2030, time.January, 1, 0, 0),
+			next:  at(2032, time.February, 29, 0, 0),
+		},
+		{
+			name:  "ListAndStartStep",
+			expr:  "5,20/20 * * * *",
+			after: at(2030, time.March, 4, 10, 21),
+			next:  at(2030, time.March, 4, 10, 40),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			cron, err := ParseCron(tc.expr)
+			require.NoError(t, err)
+			require.Equal(t, tc.next, cron.Next(tc.after))
+		})
+	}
+}
+
+func TestEveryNext(t *testing.T) {
+	start := time.Date(2030, time.March, 4, 10, 0, 0, 0, time.UTC)
+	every := Every{Start: start, Interval: time.Hour}
+
+	require.Equal(t, start, every.Next(start.Add(-time.Minute)))
+	require.Equal(t, start.Add(time.Hour), every.Next(start))
+	require.Equal(t, start.Add(3*time.Hour), every.Next(start.Add(150*time.Minute)))
+}
+
+func TestFirstRun(t *testing.T) {
+	startAt := time.Date(2030, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+	require.Equal(t, startAt, FirstRun(startAt, nil))
+	require.Equal(t, startAt, FirstRun(startAt, Every{Start: startAt, Interval: time.Hour}))
+
+	cron, err := ParseCron("0 9 * * *")
+	require.NoError(t, err)
+	require.Equal(t, startAt, FirstRun(startAt, cron))
+	require.Equal(t, startAt.AddDate(0, 0, 1), FirstRun(startAt.Add(time.Second), cron))
+}
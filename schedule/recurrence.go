@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// Recurrence gives the occurrences of a scheduled transfer
+type Recurrence interface {
+	// Next returns the first occurrence strictly after t, the zero time when there is none
+	Next(t time.Time) time.Time
+}
+
+// Every recurs at a fixed interval from Start
+type Every struct {
+	Start    time.Time
+	Interval time.Duration
+}
+
+func (every Every) Next(t time.Time) time.Time {
+	if t.Before(every.Start) {
+		return every.Start
+	}
+	n := t.Sub(every.Start)/every.Interval + 1
+	return every.Start.Add(n * every.Interval)
+}
+
+// RecurrenceOf returns how a scheduled transfer recurs, nil when it only runs once
+func RecurrenceOf(transfer sqlc.ScheduledTransfer) (Recurrence, error) {
+	switch {
+	case transfer.Cron.Valid:
+		return ParseCron(transfer.Cron.String)
+	case transfer.IntervalSeconds.Valid:
+		return Every{
+			Start:    transfer.StartAt.Time,
+			Interval: time.Duration(transfer.IntervalSeconds.Int64) * time.Second,
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// FirstRun is the first occurrence at or after startAt
+func FirstRun(startAt time.Time, recurrence Recurrence) time.Time {
+	if recurrence == nil {
+		return startAt
+	}
+	return recurrence.Next(startAt.Add(-time.Nanosecond))
+}
@@ -0,0 +1,212 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+const (
+	// claimBatchSize is how many due transfers a worker takes at a time
+	claimBatchSize = 10
+	// leaseDuration keeps other workers away from a claimed batch, it must outlast running the batch
+	leaseDuration = 5 * time.Minute
+	// retryBackoff is the wait after the first failed attempt, it doubles after each one
+	retryBackoff = time.Minute
+)
+
+// MaxAttempts is how many times an occurrence is tried before it is given up
+const MaxAttempts = 5
+
+// txOptions runs the transactions of the worker at read committed, which the row locks of
+// RunScheduledTransferTx and ExpireHoldTx rely on. Nobody waits on the worker, so it retries
+// more often than a request before an occurrence counts as failed.
+var txOptions = db.TxOptions{
+	IsoLevel:    pgx.ReadCommitted,
+	MaxAttempts: 2 * db.DefaultMaxAttempts,
+}
+
+// Store is the part of the store the worker needs
+type Store interface {
+	ClaimDueScheduledTransfers(ctx context.Context, arg sqlc.ClaimDueScheduledTransfersParams) ([]sqlc.ScheduledTransfer, error)
+	RunScheduledTransferTx(ctx context.Context, arg db.RunScheduledTransferTxParams) (sqlc.ScheduledTransferRun, error)
+	ExpireHoldTx(ctx context.Context) (sqlc.Hold, error)
+}
+
+// Worker runs scheduled transfers when they are due. Several workers can share a database,
+// a due row is claimed by one of them with FOR UPDATE SKIP LOCKED and leased for leaseDuration.
+// The transfer is recorded and rescheduled in its own transaction, a worker that dies before
+// committing leaves the lease to expire and the occurrence runs again.
+type Worker struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewWorker(store Store) *Worker {
+	return &Worker{store: store, now: time.Now}
+}
+
+// Poll runs the due transfers every interval until ctx is done
+func (worker *Worker) Poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := worker.RunDue(ctx); err != nil {
+				log.Println("scheduled transfers failed:", err)
+			}
+		}
+	}
+}
+
+// PollHolds releases expired holds every interval until ctx is done
+func (worker *Worker) PollHolds(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := worker.ExpireHolds(ctx); err != nil {
+				log.Println("expiring holds failed:", err)
+			}
+		}
+	}
+}
+
+// RunDue runs every transfer that is due and returns how many attempts it recorded.
+// A transfer that cannot be recorded is logged and left leased, the rest of the batch still runs.
+func (worker *Worker) RunDue(ctx context.Context) (int, error) {
+	attempts := 0
+	for {
+		due, err := worker.store.ClaimDueScheduledTransfers(ctx, sqlc.ClaimDueScheduledTransfersParams{
+			LeaseSeconds: int64(leaseDuration / time.Second),
+			BatchSize:    claimBatchSize,
+		})
+		if err != nil {
+			return attempts, fmt.Errorf("cannot claim scheduled transfers: %w", err)
+		}
+
+		for _, transfer := range due {
+			err = worker.run(ctx, transfer)
+			if err != nil {
+				log.Printf("scheduled transfer %d failed: %v", transfer.ID, err)
+				continue
+			}
+			attempts++
+		}
+
+		if len(due) < claimBatchSize {
+			return attempts, nil
+		}
+	}
+}
+
+// ExpireHolds releases every active hold past its expiry and returns how many it released
+func (worker *Worker) ExpireHolds(ctx context.Context) (int, error) {
+	ctx = db.WithTxOptions(ctx, txOptions)
+	expired := 0
+	for {
+		_, err := worker.store.ExpireHoldTx(ctx)
+		if errors.Is(err, db.ErrNotFound) {
+			return expired, nil
+		}
+		if err != nil {
+			return expired, fmt.Errorf("cannot expire hold: %w", err)
+		}
+		expired++
+	}
+}
+
+func (worker *Worker) run(ctx context.Context, transfer sqlc.ScheduledTransfer) error {
+	ctx = db.WithTxOptions(ctx, txOptions)
+	run := sqlc.CreateScheduledTransferRunParams{
+		ScheduledTransferID: transfer.ID,
+		Occurrence:          transfer.NextRunAt,
+		Attempt:             transfer.Attempts + 1,
+	}
+
+	recurrence, err := RecurrenceOf(transfer)
+	if err == nil {
+		_, err = worker.store.RunScheduledTransferTx(ctx, db.RunScheduledTransferTxParams{
+			Transfer: &db.TransferTxParams{
+				FromAccountID: transfer.FromAccountID,
+				ToAccountID:   transfer.ToAccountID,
+				Amount:        transfer.Amount,
+			},
+			Run:  run,
+			Next: nextAttempt(transfer, recurrence, run, worker.now()),
+		})
+		if err == nil || errors.Is(err, db.ErrScheduledTransferRunStale) {
+			return nil
+		}
+	}
+
+	// the transfer was rolled back, the failed attempt is recorded on its own
+	run.Error = pgtype.Text{String: err.Error(), Valid: true}
+	_, err = worker.store.RunScheduledTransferTx(ctx, db.RunScheduledTransferTxParams{
+		Run:  run,
+		Next: nextAttempt(transfer, recurrence, run, worker.now()),
+	})
+	if errors.Is(err, db.ErrScheduledTransferRunStale) {
+		return nil
+	}
+	return err
+}
+
+// nextAttempt retries a failed occurrence with back-off, and moves on to the next occurrence once it
+// succeeded or ran out of attempts. Occurrences missed while no worker was running are skipped.
+func nextAttempt(transfer sqlc.ScheduledTransfer, recurrence Recurrence, run sqlc.CreateScheduledTransferRunParams, now time.Time) sqlc.RescheduleScheduledTransferParams {
+	arg := sqlc.RescheduleScheduledTransferParams{
+		ID:            transfer.ID,
+		Status:        sqlc.ScheduledTransferStatusActive,
+		NextRunAt:     transfer.NextRunAt,
+		NextAttemptAt: transfer.NextRunAt,
+		Attempts:      run.Attempt,
+	}
+
+	failed := run.Error.Valid
+	if failed && run.Attempt < MaxAttempts {
+		arg.NextAttemptAt = timestamptz(now.Add(retryBackoff << (run.Attempt - 1)))
+		return arg
+	}
+
+	arg.Attempts = 0
+	if recurrence == nil {
+		arg.Status = sqlc.ScheduledTransferStatusCompleted
+		if failed {
+			arg.Status = sqlc.ScheduledTransferStatusFailed
+		}
+		return arg
+	}
+
+	after := transfer.NextRunAt.Time
+	if now.After(after) {
+		after = now
+	}
+	next := recurrence.Next(after)
+	if next.IsZero() {
+		arg.Status = sqlc.ScheduledTransferStatusCompleted
+		return arg
+	}
+	arg.NextRunAt = timestamptz(next)
+	arg.NextAttemptAt = arg.NextRunAt
+	return arg
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
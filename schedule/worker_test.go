@@ -0,0 +1,340 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// eqTxOptionsMatcher matches a context carrying the transaction options the worker runs with
+type eqTxOptionsMatcher struct {
+	options db.TxOptions
+}
+
+func (e eqTxOptionsMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && db.TxOptionsFrom(ctx) == e.options
+}
+
+func (e eqTxOptionsMatcher) String() string {
+	return fmt.Sprintf("is a context with tx options %+v", e.options)
+}
+
+func eqTxOptions(options db.TxOptions) gomock.Matcher {
+	return eqTxOptionsMatcher{options}
+}
+
+func TestRunDue(t *testing.T) {
+	now := time.Date(2030, time.March, 4, 9, 0, 30, 0, time.UTC)
+	occurrence := time.Date(2030, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+	due := sqlc.ScheduledTransfer{
+		ID:              3,
+		FromAccountID:   1,
+		ToAccountID:     2,
+		Amount:          10,
+		StartAt:         timestamptz(occurrence.Add(-24 * time.Hour)),
+		IntervalSeconds: pgtype.Int8{Int64: 3600, Valid: true},
+		Status:          sqlc.ScheduledTransferStatusActive,
+		NextRunAt:       timestamptz(occurrence),
+	}
+
+	transferArg := &db.TransferTxParams{FromAccountID: 1, ToAccountID: 2, Amount: 10}
+
+	// expectFailedRun makes the transfer fail with err, and expects the failed run to be recorded with next
+	expectFailedRun := func(store *mock.MockStore, transfer sqlc.ScheduledTransfer, err error, next sqlc.RescheduleScheduledTransferParams) {
+		run := sqlc.CreateScheduledTransferRunParams{
+			ScheduledTransferID: transfer.ID,
+			Occurrence:          transfer.NextRunAt,
+			Attempt:             transfer.Attempts + 1,
+		}
+		gomock.InOrder(
+			store.EXPECT().
+				RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(sqlc.ScheduledTransferRun{}, err),
+			store.EXPECT().
+				RunScheduledTransferTx(gomock.Any(), gomock.Eq(db.RunScheduledTransferTxParams{
+					Run: func() sqlc.CreateScheduledTransferRunParams {
+						run.Error = pgtype.Text{String: err.Error(), Valid: true}
+						return run
+					}(),
+					Next: next,
+				})).
+				Times(1),
+		)
+	}
+
+	testCases := []struct {
+		name       string
+		transfer   sqlc.ScheduledTransfer
+		buildStubs func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer)
+	}{
+		{
+			name:     "Succeeded",
+			transfer: due,
+			buildStubs: func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer) {
+				arg := db.RunScheduledTransferTxParams{
+					Transfer: transferArg,
+					Run: sqlc.CreateScheduledTransferRunParams{
+						ScheduledTransferID: transfer.ID,
+						Occurrence:          transfer.NextRunAt,
+						Attempt:             1,
+					},
+					Next: sqlc.RescheduleScheduledTransferParams{
+						ID:            transfer.ID,
+						Status:        sqlc.ScheduledTransferStatusActive,
+						NextRunAt:     timestamptz(occurrence.Add(time.Hour)),
+						NextAttemptAt: timestamptz(occurrence.Add(time.Hour)),
+					},
+				}
+				store.EXPECT().
+					RunScheduledTransferTx(eqTxOptions(txOptions), gomock.Eq(arg)).
+					Times(1).
+					Return(sqlc.ScheduledTransferRun{TransferID: pgtype.Int8{Int64: 42, Valid: true}}, nil)
+			},
+		},
+		{
+			name: "FailedIsRetried",
+			transfer: func() sqlc.ScheduledTransfer {
+				transfer := due
+				transfer.Attempts = 2
+				return transfer
+			}(),
+			buildStubs: func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer) {
+				// third failure waits four times the first back-off, the occurrence stays the same
+				expectFailedRun(store, transfer, db.ErrInsufficientFunds, sqlc.RescheduleScheduledTransferParams{
+					ID:            transfer.ID,
+					Status:        sqlc.ScheduledTransferStatusActive,
+					NextRunAt:     transfer.NextRunAt,
+					NextAttemptAt: timestamptz(now.Add(4 * retryBackoff)),
+					Attempts:      3,
+				})
+			},
+		},
+		{
+			name: "LastAttemptMovesOn",
+			transfer: func() sqlc.ScheduledTransfer {
+				transfer := due
+				transfer.Attempts = MaxAttempts - 1
+				return transfer
+			}(),
+			buildStubs: func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer) {
+				expectFailedRun(store, transfer, db.ErrAccountNotActive, sqlc.RescheduleScheduledTransferParams{
+					ID:            transfer.ID,
+					Status:        sqlc.ScheduledTransferStatusActive,
+					NextRunAt:     timestamptz(occurrence.Add(time.Hour)),
+					NextAttemptAt: timestamptz(occurrence.Add(time.Hour)),
+				})
+			},
+		},
+		{
+			name: "OnceCompleted",
+			transfer: func() sqlc.ScheduledTransfer {
+				transfer := due
+				transfer.IntervalSeconds = pgtype.Int8{}
+				return transfer
+			}(),
+			buildStubs: func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer) {
+				next := sqlc.RescheduleScheduledTransferParams{
+					ID:            transfer.ID,
+					Status:        sqlc.ScheduledTransferStatusCompleted,
+					NextRunAt:     transfer.NextRunAt,
+					NextAttemptAt: transfer.NextRunAt,
+				}
+				store.EXPECT().
+					RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Do(func(ctx context.Context, arg db.RunScheduledTransferTxParams) {
+						require.NotNil(t, arg.Transfer)
+						require.Equal(t, next, arg.Next)
+					})
+			},
+		},
+		{
+			name: "OnceFailed",
+			transfer: func() sqlc.ScheduledTransfer {
+				transfer := due
+				transfer.IntervalSeconds = pgtype.Int8{}
+				transfer.Attempts = MaxAttempts - 1
+				return transfer
+			}(),
+			buildStubs: func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer) {
+				expectFailedRun(store, transfer, db.ErrInsufficientFunds, sqlc.RescheduleScheduledTransferParams{
+					ID:            transfer.ID,
+					Status:        sqlc.ScheduledTransferStatusFailed,
+					NextRunAt:     transfer.NextRunAt,
+					NextAttemptAt: transfer.NextRunAt,
+				})
+			},
+		},
+		{
+			name: "MissedOccurrencesSkipped",
+			transfer: func() sqlc.ScheduledTransfer {
+				transfer := due
+				transfer.NextRunAt = timestamptz(occurrence.Add(-5 * time.Hour))
+				return transfer
+			}(),
+			buildStubs: func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer) {
+				next := sqlc.RescheduleScheduledTransferParams{
+					ID:            transfer.ID,
+					Status:        sqlc.ScheduledTransferStatusActive,
+					NextRunAt:     timestamptz(occurrence.Add(time.Hour)),
+					NextAttemptAt: timestamptz(occurrence.Add(time.Hour)),
+				}
+				store.EXPECT().
+					RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Do(func(ctx context.Context, arg db.RunScheduledTransferTxParams) {
+						require.NotNil(t, arg.Transfer)
+						require.Equal(t, next, arg.Next)
+					})
+			},
+		},
+		{
+			name:     "AlreadyRun",
+			transfer: due,
+			buildStubs: func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer) {
+				// another worker ran the attempt once the lease ran out, there is nothing to record
+				store.EXPECT().
+					RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sqlc.ScheduledTransferRun{}, db.ErrScheduledTransferRunStale)
+			},
+		},
+		{
+			name: "InvalidCron",
+			transfer: func() sqlc.ScheduledTransfer {
+				transfer := due
+				transfer.IntervalSeconds = pgtype.Int8{}
+				transfer.Cron = pgtype.Text{String: "every day", Valid: true}
+				return transfer
+			}(),
+			buildStubs: func(t *testing.T, store *mock.MockStore, transfer sqlc.ScheduledTransfer) {
+				store.EXPECT().
+					RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Do(func(ctx context.Context, arg db.RunScheduledTransferTxParams) {
+						require.Nil(t, arg.Transfer)
+						require.True(t, arg.Run.Error.Valid)
+					})
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().
+				ClaimDueScheduledTransfers(gomock.Any(), gomock.Eq(sqlc.ClaimDueScheduledTransfersParams{
+					LeaseSeconds: int64(leaseDuration / time.Second),
+					BatchSize:    claimBatchSize,
+				})).
+				Times(1).
+				Return([]sqlc.ScheduledTransfer{tc.transfer}, nil)
+			tc.buildStubs(t, store, tc.transfer)
+
+			worker := NewWorker(store)
+			worker.now = func() time.Time { return now }
+
+			attempts, err := worker.RunDue(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, attempts)
+		})
+	}
+}
+
+func TestRunDueContinuesAfterError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	due := []sqlc.ScheduledTransfer{
+		{ID: 1, IntervalSeconds: pgtype.Int8{Int64: 3600, Valid: true}, NextRunAt: timestamptz(time.Now())},
+		{ID: 2, IntervalSeconds: pgtype.Int8{Int64: 3600, Valid: true}, NextRunAt: timestamptz(time.Now())},
+	}
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		ClaimDueScheduledTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(due, nil)
+	// the first transfer can be neither run nor recorded as failed
+	store.EXPECT().
+		RunScheduledTransferTx(gomock.Any(), gomock.Any()).
+		Times(3).
+		DoAndReturn(func(ctx context.Context, arg db.RunScheduledTransferTxParams) (sqlc.ScheduledTransferRun, error) {
+			if arg.Run.ScheduledTransferID == 1 {
+				return sqlc.ScheduledTransferRun{}, errors.New("connection refused")
+			}
+			return sqlc.ScheduledTransferRun{}, nil
+		})
+
+	attempts, err := NewWorker(store).RunDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts)
+}
+
+func TestRunDueClaimError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		ClaimDueScheduledTransfers(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, errors.New("connection refused"))
+
+	attempts, err := NewWorker(store).RunDue(context.Background())
+	require.Error(t, err)
+	require.Zero(t, attempts)
+}
+
+func TestExpireHolds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			ExpireHoldTx(eqTxOptions(txOptions)).
+			Times(2).
+			Return(sqlc.Hold{Status: sqlc.HoldStatusExpired}, nil),
+		store.EXPECT().
+			ExpireHoldTx(gomock.Any()).
+			Times(1).
+			Return(sqlc.Hold{}, db.ErrNotFound),
+	)
+
+	expired, err := NewWorker(store).ExpireHolds(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, expired)
+}
+
+func TestExpireHoldsError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		ExpireHoldTx(gomock.Any()).
+		Times(1).
+		Return(sqlc.Hold{}, errors.New("connection refused"))
+
+	expired, err := NewWorker(store).ExpireHolds(context.Background())
+	require.Error(t, err)
+	require.Zero(t, expired)
+}
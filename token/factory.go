@@ -0,0 +1,112 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+)
+
+// Token types selected with TOKEN_TYPE
+const (
+	// TypePaseto is PASETO v2.local, encrypted with the symmetric key
+	TypePaseto = "paseto"
+	// TypeJWT is a JWT signed with HS256 and the symmetric key
+	TypeJWT = "jwt"
+	// TypePasetoV4Public is PASETO v4.public, signed with the Ed25519 private key
+	TypePasetoV4Public = "paseto_v4_public"
+	// TypeJWTEdDSA is a JWT signed with EdDSA and the Ed25519 private key
+	TypeJWTEdDSA = "jwt_eddsa"
+)
+
+// MakerConfig selects the token type and the keys a maker signs and verifies with
+type MakerConfig struct {
+	// Type is one of the token types, empty is TypePaseto
+	Type string
+	// SymmetricKey is the key of the symmetric types
+	SymmetricKey string
+	// PrivateKey is the hex encoded seed of an Ed25519 key for the asymmetric types
+	PrivateKey string
+	// KeyID names the configured key, it signs new tokens. Empty is DefaultKeyID.
+	KeyID string
+	// KeysDir holds more keys in <key id>.key files, those other than KeyID only verify tokens.
+	// Rotating a key means adding the new one and moving KeyID to it, the old file is removed
+	// once the tokens it signed have expired.
+	KeysDir string
+	// Claims are stamped on new tokens and required of the verified ones
+	Claims Claims
+}
+
+// NewMaker creates the maker for config.Type. Symmetric types use the symmetric keys,
+// asymmetric ones the private keys.
+func NewMaker(config MakerConfig) (Maker, error) {
+	switch config.Type {
+	case "", TypePaseto, TypeJWT:
+		keys, err := loadKeyring(config, config.SymmetricKey, func(key string) ([]byte, error) {
+			return []byte(key), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if config.Type == TypeJWT {
+			return NewJWTKeyringMaker(keys, config.Claims)
+		}
+		return NewPasetoKeyringMaker(keys, config.Claims)
+	case TypePasetoV4Public, TypeJWTEdDSA:
+		keys, err := loadKeyring(config, config.PrivateKey, ParseEd25519PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		if config.Type == TypePasetoV4Public {
+			return NewPasetoV4PublicKeyringMaker(keys, config.Claims), nil
+		}
+		return NewEdDSAJWTKeyringMaker(keys, config.Claims), nil
+	default:
+		return nil, fmt.Errorf("unknown token type %q", config.Type)
+	}
+}
+
+// loadKeyring parses the configured key and the keys of config.KeysDir into a keyring
+// that signs with config.KeyID
+func loadKeyring[K any](config MakerConfig, configured string, parse func(string) (K, error)) (*Keyring[K], error) {
+	currentID := config.KeyID
+	if currentID == "" {
+		currentID = DefaultKeyID
+	}
+
+	encoded := make(map[string]string)
+	if config.KeysDir != "" {
+		var err error
+		encoded, err = LoadKeys(config.KeysDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if configured != "" {
+		if existing, found := encoded[currentID]; found && existing != configured {
+			return nil, fmt.Errorf("key %q is configured with a different value than in %s", currentID, config.KeysDir)
+		}
+		encoded[currentID] = configured
+	}
+
+	keys := make(map[string]K, len(encoded))
+	for id, value := range encoded {
+		key, err := parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(currentID, keys)
+}
+
+// ParseEd25519PrivateKey decodes the hex encoded 32 byte seed of an Ed25519 key
+func ParseEd25519PrivateKey(seed string) (ed25519.PrivateKey, error) {
+	data, err := hex.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	if len(data) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key: must be a %d byte Ed25519 seed", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(data), nil
+}
@@ -0,0 +1,124 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestNewMaker(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	symmetricKey := utils.RandomString(32)
+	seed := hex.EncodeToString(privateKey.Seed())
+
+	testCases := []struct {
+		name      string
+		tokenType string
+		seed      string
+		check     func(t *testing.T, maker Maker, err error)
+	}{
+		{
+			name:      "Default",
+			tokenType: "",
+			check: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoMaker{}, maker)
+			},
+		},
+		{
+			name:      "JWT",
+			tokenType: TypeJWT,
+			check: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JWTMaker{}, maker)
+			},
+		},
+		{
+			name:      "PasetoV4Public",
+			tokenType: TypePasetoV4Public,
+			seed:      seed,
+			check: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoV4PublicMaker{}, maker)
+				require.Equal(t, privateKey.Public(), maker.(AsymmetricMaker).PublicKey())
+			},
+		},
+		{
+			name:      "JWTEdDSA",
+			tokenType: TypeJWTEdDSA,
+			seed:      seed,
+			check: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &EdDSAJWTMaker{}, maker)
+				require.Equal(t, privateKey.Public(), maker.(AsymmetricMaker).PublicKey())
+			},
+		},
+		{
+			name:      "MissingPrivateKey",
+			tokenType: TypeJWTEdDSA,
+			check: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:      "InvalidPrivateKey",
+			tokenType: TypePasetoV4Public,
+			seed:      "not hex",
+			check: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:      "UnknownType",
+			tokenType: "saml",
+			check: func(t *testing.T, maker Maker, err error) {
+				require.EqualError(t, err, `unknown token type "saml"`)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewMaker(MakerConfig{Type: tc.tokenType, SymmetricKey: symmetricKey, PrivateKey: tc.seed})
+			tc.check(t, maker, err)
+		})
+	}
+}
+
+func TestNewMakerKeysDir(t *testing.T) {
+	oldKey := utils.RandomString(32)
+	newKey := utils.RandomString(32)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2024.key"), []byte(oldKey+"\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("not a key"), 0o600))
+
+	oldMaker, err := NewMaker(MakerConfig{Type: TypeJWT, KeyID: "2024", KeysDir: dir})
+	require.NoError(t, err)
+	oldToken, _, err := oldMaker.CreateToken(utils.RandomOwner(), utils.DepositorRole, AccessToken, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	// the new key comes from the config, the old one is still in the directory
+	maker, err := NewMaker(MakerConfig{Type: TypeJWT, SymmetricKey: newKey, KeyID: "2025", KeysDir: dir})
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	_, err = NewMaker(MakerConfig{Type: TypeJWT, SymmetricKey: newKey, KeyID: "2024", KeysDir: dir})
+	require.Error(t, err)
+
+	_, err = NewMaker(MakerConfig{Type: TypeJWT, KeyID: "2025", KeysDir: dir})
+	require.EqualError(t, err, `current key "2025" is not in the keyring`)
+}
@@ -0,0 +1,63 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+)
+
+// JWK is an Ed25519 public key in the JSON Web Key format of RFC 8037
+type JWK struct {
+	KeyType string `json:"kty"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	// Algorithm is set for JWT keys, PASETO keys are tied to their version instead
+	Algorithm string `json:"alg,omitempty"`
+}
+
+// JWKS is the JSON Web Key Set other services fetch to verify tokens
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWKS publishes every public key of maker, including those kept to verify tokens
+// signed before a rotation
+func NewJWKS(maker AsymmetricMaker) JWKS {
+	algorithm := ""
+	if _, ok := maker.(*EdDSAJWTMaker); ok {
+		algorithm = "EdDSA"
+	}
+
+	publicKeys := maker.PublicKeys()
+	jwks := JWKS{Keys: []JWK{}}
+	for _, id := range publicKeys.IDs() {
+		key, _ := publicKeys.Key(id)
+		jwks.Keys = append(jwks.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key),
+			KeyID:     id,
+			Use:       "sig",
+			Algorithm: algorithm,
+		})
+	}
+	return jwks
+}
+
+// Keyring reads the public keys of jwks into a keyring a verifier can use
+func (jwks JWKS) Keyring() (*Keyring[ed25519.PublicKey], error) {
+	keys := make(map[string]ed25519.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("key %q is not an Ed25519 key", jwk.KeyID)
+		}
+		key, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %q has an invalid public key", jwk.KeyID)
+		}
+		keys[jwk.KeyID] = ed25519.PublicKey(key)
+	}
+	return NewKeyring("", keys)
+}
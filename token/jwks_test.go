@@ -0,0 +1,79 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestJWKS(t *testing.T) {
+	privateKeys := make(map[string]ed25519.PrivateKey)
+	for _, id := range []string{"old", "new"} {
+		_, privateKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		privateKeys[id] = privateKey
+	}
+	keyring, err := NewKeyring("new", privateKeys)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		maker     AsymmetricMaker
+		algorithm string
+		verifier  func(t *testing.T, publicKeys *Keyring[ed25519.PublicKey]) Verifier
+	}{
+		{
+			name:      "JWTEdDSA",
+			maker:     NewEdDSAJWTKeyringMaker(keyring, Claims{}),
+			algorithm: "EdDSA",
+			verifier: func(t *testing.T, publicKeys *Keyring[ed25519.PublicKey]) Verifier {
+				return NewEdDSAJWTKeyringVerifier(publicKeys, Claims{})
+			},
+		},
+		{
+			name:  "PasetoV4Public",
+			maker: NewPasetoV4PublicKeyringMaker(keyring, Claims{}),
+			verifier: func(t *testing.T, publicKeys *Keyring[ed25519.PublicKey]) Verifier {
+				verifier, err := NewPasetoV4PublicKeyringVerifier(publicKeys, Claims{})
+				require.NoError(t, err)
+				return verifier
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			jwks := NewJWKS(tc.maker)
+			require.Len(t, jwks.Keys, 2)
+			for _, jwk := range jwks.Keys {
+				require.Equal(t, "OKP", jwk.KeyType)
+				require.Equal(t, "Ed25519", jwk.Curve)
+				require.Equal(t, "sig", jwk.Use)
+				require.Equal(t, tc.algorithm, jwk.Algorithm)
+			}
+
+			publicKeys, err := jwks.Keyring()
+			require.NoError(t, err)
+			require.Equal(t, []string{"new", "old"}, publicKeys.IDs())
+
+			token, _, err := tc.maker.CreateToken(utils.RandomOwner(), utils.DepositorRole, AccessToken, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+			_, err = tc.verifier(t, publicKeys).VerifyToken(token)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestJWKSInvalidKey(t *testing.T) {
+	_, err := JWKS{Keys: []JWK{{KeyType: "RSA", KeyID: "rsa"}}}.Keyring()
+	require.Error(t, err)
+
+	_, err = JWKS{Keys: []JWK{{KeyType: "OKP", Curve: "Ed25519", X: "short", KeyID: "short"}}}.Keyring()
+	require.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// EdDSAJWTVerifier checks JWTs signed with EdDSA using the public key alone
+type EdDSAJWTVerifier struct {
+	publicKeys *Keyring[ed25519.PublicKey]
+	claims     Claims
+}
+
+func NewEdDSAJWTVerifier(publicKey ed25519.PublicKey) *EdDSAJWTVerifier {
+	return NewEdDSAJWTKeyringVerifier(SingleKeyring(publicKey), Claims{})
+}
+
+// NewEdDSAJWTKeyringVerifier checks every token with the public key its kid header names
+// and requires the issuer and audience of claims
+func NewEdDSAJWTKeyringVerifier(publicKeys *Keyring[ed25519.PublicKey], claims Claims) *EdDSAJWTVerifier {
+	return &EdDSAJWTVerifier{publicKeys: publicKeys, claims: claims}
+}
+
+func (verifier *EdDSAJWTVerifier) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		_, ok := token.Method.(*jwt.SigningMethodEd25519)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return verifier.publicKeys.Key(jwtKeyID(token))
+	}
+	return parseJWT(token, keyFunc, verifier.claims)
+}
+
+// EdDSAJWTMaker signs JWTs with an Ed25519 key, other services can verify them
+// with an EdDSAJWTVerifier and the public key
+type EdDSAJWTMaker struct {
+	*EdDSAJWTVerifier
+	privateKeys *Keyring[ed25519.PrivateKey]
+}
+
+func NewEdDSAJWTMaker(privateKey ed25519.PrivateKey) AsymmetricMaker {
+	return NewEdDSAJWTKeyringMaker(SingleKeyring(privateKey), Claims{})
+}
+
+// NewEdDSAJWTKeyringMaker signs with the current key of privateKeys and verifies with
+// the public half of the key the kid header names
+func NewEdDSAJWTKeyringMaker(privateKeys *Keyring[ed25519.PrivateKey], claims Claims) AsymmetricMaker {
+	return &EdDSAJWTMaker{
+		EdDSAJWTVerifier: NewEdDSAJWTKeyringVerifier(publicKeyring(privateKeys), claims),
+		privateKeys:      privateKeys,
+	}
+}
+
+func (maker *EdDSAJWTMaker) CreateToken(username string, role string, use TokenUse, sessionID uuid.UUID, duration time.Duration, scopes ...string) (string, *Payload, error) {
+	payload, err := maker.claims.newPayload(username, role, use, sessionID, duration, scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	keyID, key := maker.privateKeys.Current()
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, newJWTClaims(payload))
+	jwtToken.Header[jwtKeyIDHeader] = keyID
+	token, err := jwtToken.SignedString(key)
+	return token, payload, err
+}
+
+func (maker *EdDSAJWTMaker) PublicKey() ed25519.PublicKey {
+	_, key := maker.publicKeys.Current()
+	return key
+}
+
+func (maker *EdDSAJWTMaker) PublicKeys() *Keyring[ed25519.PublicKey] {
+	return maker.publicKeys
+}
@@ -0,0 +1,70 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func newTestEdDSAJWTMaker(t *testing.T) AsymmetricMaker {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	return NewEdDSAJWTMaker(privateKey)
+}
+
+func TestEdDSAJWTMaker(t *testing.T) {
+	maker := newTestEdDSAJWTMaker(t)
+
+	username := utils.RandomOwner()
+	role := utils.BankerRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	sessionID := uuid.New()
+	token, payload, err := maker.CreateToken(username, role, AccessToken, sessionID, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	// a service holding only the public key can verify the token
+	for _, v := range []Verifier{maker, NewEdDSAJWTVerifier(maker.PublicKey())} {
+		payload, err = v.VerifyToken(token)
+		require.NoError(t, err)
+		require.Equal(t, username, payload.Username)
+		require.Equal(t, role, payload.Role)
+		require.Equal(t, AccessToken, payload.Use)
+		require.Equal(t, sessionID, payload.SessionID)
+		require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+		require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+	}
+}
+
+func TestExpiredEdDSAJWTToken(t *testing.T) {
+	maker := newTestEdDSAJWTMaker(t)
+
+	token, _, err := maker.CreateToken(utils.RandomOwner(), utils.DepositorRole, AccessToken, uuid.Nil, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestEdDSAJWTTokenSignedWithHMAC(t *testing.T) {
+	secret := utils.RandomString(32)
+	hmacMaker, err := NewJWTMaker(secret)
+	require.NoError(t, err)
+
+	token, _, err := hmacMaker.CreateToken(utils.RandomOwner(), utils.DepositorRole, AccessToken, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := newTestEdDSAJWTMaker(t).VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
@@ -5,30 +5,47 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 type JWTMaker struct {
-	secretKey string
+	keys   *Keyring[[]byte]
+	claims Claims
 }
 
 const minSecretKeySize = 32
 
+// jwtKeyIDHeader names the key a JWT was signed with
+const jwtKeyIDHeader = "kid"
+
 func NewJWTMaker(secretKey string) (Maker, error) {
-	if len(secretKey) < minSecretKeySize {
-		return nil, fmt.Errorf("invalid size key: must be atleast %d long", minSecretKeySize)
+	return NewJWTKeyringMaker(SingleKeyring([]byte(secretKey)), Claims{})
+}
+
+// NewJWTKeyringMaker signs with the current key of keys and verifies with the key
+// the kid header names
+func NewJWTKeyringMaker(keys *Keyring[[]byte], claims Claims) (Maker, error) {
+	for _, id := range keys.IDs() {
+		key, _ := keys.Key(id)
+		if len(key) < minSecretKeySize {
+			return nil, fmt.Errorf("invalid size key: must be atleast %d long", minSecretKeySize)
+		}
 	}
 
-	return &JWTMaker{secretKey: secretKey}, nil
+	return &JWTMaker{keys: keys, claims: claims}, nil
 }
 
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *JWTMaker) CreateToken(username string, role string, use TokenUse, sessionID uuid.UUID, duration time.Duration, scopes ...string) (string, *Payload, error) {
+	payload, err := maker.claims.newPayload(username, role, use, sessionID, duration, scopes)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	return jwtToken.SignedString([]byte(maker.secretKey))
+	keyID, key := maker.keys.Current()
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, newJWTClaims(payload))
+	jwtToken.Header[jwtKeyIDHeader] = keyID
+	token, err := jwtToken.SignedString(key)
+	return token, payload, err
 }
 
 func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
@@ -37,20 +54,68 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		if !ok {
 			return nil, ErrInvalidToken
 		}
-		return []byte(maker.secretKey), nil
+		return maker.keys.Key(jwtKeyID(token))
+	}
+	return parseJWT(token, keyFunc, maker.claims)
+}
+
+// jwtKeyID reads the kid header, tokens issued before key IDs have none
+func jwtKeyID(token *jwt.Token) string {
+	keyID, _ := token.Header[jwtKeyIDHeader].(string)
+	return keyID
+}
+
+// jwtClaims carries a Payload with its times as NumericDate, the seconds since the epoch
+// the JWT registered claims use. They shadow the RFC 3339 times of the Payload.
+type jwtClaims struct {
+	*Payload
+	IssuedAt  *jwt.NumericDate `json:"iat"`
+	NotBefore *jwt.NumericDate `json:"nbf"`
+	ExpiresAt *jwt.NumericDate `json:"exp"`
+}
+
+func newJWTClaims(payload *Payload) *jwtClaims {
+	return &jwtClaims{
+		Payload:   payload,
+		IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+		NotBefore: jwt.NewNumericDate(payload.NotBefore),
+		ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
 	}
+}
 
-	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+// Valid copies the times into the payload and checks them, a token missing one is invalid
+func (claims *jwtClaims) Valid() error {
+	if claims.IssuedAt == nil || claims.NotBefore == nil || claims.ExpiresAt == nil {
+		return ErrInvalidToken
+	}
+	claims.Payload.IssuedAt = claims.IssuedAt.Time
+	claims.Payload.NotBefore = claims.NotBefore.Time
+	claims.Payload.ExpiredAt = claims.ExpiresAt.Time
+	return claims.Payload.Valid()
+}
+
+// parseJWT checks the signature with the key keyFunc returns, the payload checks the times
+// and claims the issuer and audience
+func parseJWT(token string, keyFunc jwt.Keyfunc, claims Claims) (*Payload, error) {
+	jwtToken, err := jwt.ParseWithClaims(token, &jwtClaims{Payload: &Payload{}}, keyFunc)
 	if err != nil {
 		verr, ok := err.(*jwt.ValidationError)
 		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
 			return nil, ErrExpiredToken
 		}
+		if ok && errors.Is(verr.Inner, ErrTokenNotValidYet) {
+			return nil, ErrTokenNotValidYet
+		}
 		return nil, ErrInvalidToken
 	}
-	payload, ok := jwtToken.Claims.(*Payload)
+	parsed, ok := jwtToken.Claims.(*jwtClaims)
 	if !ok {
 		return nil, ErrInvalidToken
 	}
+	payload := parsed.Payload
+	err = claims.validate(payload)
+	if err != nil {
+		return nil, err
+	}
 	return payload, nil
 }
@@ -0,0 +1,109 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultKeyID names the configured key when no key ID is set
+const DefaultKeyID = "default"
+
+// keyFileExt marks the files of a key directory, the name of the file is the key ID
+const keyFileExt = ".key"
+
+// Keyring holds every key tokens are verified with by key ID, and which of them new tokens
+// are signed with. Rotating a key means adding the new one as current while keeping the old one
+// until the tokens it signed have expired.
+type Keyring[K any] struct {
+	currentID string
+	keys      map[string]K
+}
+
+// NewKeyring creates a keyring that signs with the key currentID names. A keyring that only
+// verifies tokens, such as one made of public keys, has no current key and an empty currentID.
+func NewKeyring[K any](currentID string, keys map[string]K) (*Keyring[K], error) {
+	if _, found := keys[currentID]; currentID != "" && !found {
+		return nil, fmt.Errorf("current key %q is not in the keyring", currentID)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring has no keys")
+	}
+	return &Keyring[K]{currentID: currentID, keys: maps.Clone(keys)}, nil
+}
+
+// SingleKeyring holds key alone under DefaultKeyID
+func SingleKeyring[K any](key K) *Keyring[K] {
+	return &Keyring[K]{currentID: DefaultKeyID, keys: map[string]K{DefaultKeyID: key}}
+}
+
+// Current returns the key new tokens are signed with and its ID
+func (keyring *Keyring[K]) Current() (string, K) {
+	return keyring.currentID, keyring.keys[keyring.currentID]
+}
+
+// Key returns the key a token names with id. Tokens issued before key IDs have none
+// and are checked with the current key.
+func (keyring *Keyring[K]) Key(id string) (K, error) {
+	if id == "" {
+		id = keyring.currentID
+	}
+	key, found := keyring.keys[id]
+	if !found {
+		return key, ErrInvalidToken
+	}
+	return key, nil
+}
+
+// IDs lists the key IDs in order
+func (keyring *Keyring[K]) IDs() []string {
+	return slices.Sorted(maps.Keys(keyring.keys))
+}
+
+// publicKeyring holds the public half of every private key under the same ID
+func publicKeyring(privateKeys *Keyring[ed25519.PrivateKey]) *Keyring[ed25519.PublicKey] {
+	keys := make(map[string]ed25519.PublicKey, len(privateKeys.keys))
+	for id, key := range privateKeys.keys {
+		keys[id] = key.Public().(ed25519.PublicKey)
+	}
+	return &Keyring[ed25519.PublicKey]{currentID: privateKeys.currentID, keys: keys}
+}
+
+// LoadKeys reads a key directory, each <key id>.key file holds one key. Surrounding
+// whitespace is dropped so the files can end with a newline.
+func LoadKeys(dir string) (map[string]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+keyFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read key: %w", err)
+		}
+		keys[strings.TrimSuffix(filepath.Base(path), keyFileExt)] = strings.TrimSpace(string(data))
+	}
+	return keys, nil
+}
+
+// keyFooter is the PASETO footer naming the key a token was signed with
+type keyFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// footerKeyID reads the key ID from a PASETO footer, tokens issued before key IDs have none
+func footerKeyID(footer []byte) (string, error) {
+	if len(footer) == 0 {
+		return "", nil
+	}
+	var keyFooter keyFooter
+	err := json.Unmarshal(footer, &keyFooter)
+	return keyFooter.KeyID, err
+}
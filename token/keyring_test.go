@@ -0,0 +1,130 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestKeyRotation(t *testing.T) {
+	symmetricKeys := map[string][]byte{
+		"old": []byte(utils.RandomString(32)),
+		"new": []byte(utils.RandomString(32)),
+	}
+
+	privateKeys := make(map[string]ed25519.PrivateKey)
+	for _, id := range []string{"old", "new"} {
+		_, privateKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		privateKeys[id] = privateKey
+	}
+
+	symmetricMaker := func(newMaker func(*Keyring[[]byte], Claims) (Maker, error)) func(t *testing.T, currentID string, ids ...string) Maker {
+		return func(t *testing.T, currentID string, ids ...string) Maker {
+			keys := make(map[string][]byte)
+			for _, id := range ids {
+				keys[id] = symmetricKeys[id]
+			}
+			keyring, err := NewKeyring(currentID, keys)
+			require.NoError(t, err)
+			maker, err := newMaker(keyring, Claims{})
+			require.NoError(t, err)
+			return maker
+		}
+	}
+	asymmetricMaker := func(newMaker func(*Keyring[ed25519.PrivateKey], Claims) AsymmetricMaker) func(t *testing.T, currentID string, ids ...string) Maker {
+		return func(t *testing.T, currentID string, ids ...string) Maker {
+			keys := make(map[string]ed25519.PrivateKey)
+			for _, id := range ids {
+				keys[id] = privateKeys[id]
+			}
+			keyring, err := NewKeyring(currentID, keys)
+			require.NoError(t, err)
+			return newMaker(keyring, Claims{})
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		newMaker func(t *testing.T, currentID string, ids ...string) Maker
+	}{
+		{name: "Paseto", newMaker: symmetricMaker(NewPasetoKeyringMaker)},
+		{name: "JWT", newMaker: symmetricMaker(NewJWTKeyringMaker)},
+		{name: "PasetoV4Public", newMaker: asymmetricMaker(NewPasetoV4PublicKeyringMaker)},
+		{name: "JWTEdDSA", newMaker: asymmetricMaker(NewEdDSAJWTKeyringMaker)},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			username := utils.RandomOwner()
+
+			oldToken, _, err := tc.newMaker(t, "old", "old").CreateToken(username, utils.DepositorRole, AccessToken, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+
+			// after the rotation the retired key is still trusted
+			rotated := tc.newMaker(t, "new", "old", "new")
+			payload, err := rotated.VerifyToken(oldToken)
+			require.NoError(t, err)
+			require.Equal(t, username, payload.Username)
+
+			newToken, _, err := rotated.CreateToken(username, utils.DepositorRole, AccessToken, uuid.Nil, time.Minute)
+			require.NoError(t, err)
+			_, err = rotated.VerifyToken(newToken)
+			require.NoError(t, err)
+
+			// once the retired key is removed its tokens no longer verify
+			payload, err = tc.newMaker(t, "new", "new").VerifyToken(oldToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestKeyringPublicVerifier(t *testing.T) {
+	privateKeys := make(map[string]ed25519.PrivateKey)
+	for _, id := range []string{"old", "new"} {
+		_, privateKey, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		privateKeys[id] = privateKey
+	}
+
+	oldKeyring, err := NewKeyring("old", map[string]ed25519.PrivateKey{"old": privateKeys["old"]})
+	require.NoError(t, err)
+	oldToken, _, err := NewEdDSAJWTKeyringMaker(oldKeyring, Claims{}).CreateToken(utils.RandomOwner(), utils.DepositorRole, AccessToken, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	rotatedKeyring, err := NewKeyring("new", privateKeys)
+	require.NoError(t, err)
+	maker := NewEdDSAJWTKeyringMaker(rotatedKeyring, Claims{})
+	require.Equal(t, []string{"new", "old"}, maker.PublicKeys().IDs())
+	require.Equal(t, privateKeys["new"].Public(), maker.PublicKey())
+
+	// a service holding only the public keys verifies tokens of both keys
+	verifier := NewEdDSAJWTKeyringVerifier(maker.PublicKeys(), Claims{})
+	_, err = verifier.VerifyToken(oldToken)
+	require.NoError(t, err)
+}
+
+func TestKeyringUnkeyedToken(t *testing.T) {
+	key := utils.RandomString(32)
+
+	keyring, err := NewKeyring("new", map[string][]byte{"new": []byte(key)})
+	require.NoError(t, err)
+	maker, err := NewPasetoKeyringMaker(keyring, Claims{})
+	require.NoError(t, err)
+
+	// tokens issued before key IDs have no footer and are checked with the current key
+	payload, err := NewPayload(utils.RandomOwner(), utils.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	token, err := maker.(*PasetoMaker).paseto.Encrypt([]byte(key), payload, nil)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+}
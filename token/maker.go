@@ -1,8 +1,31 @@
 package token
 
-import "time"
+import (
+	"crypto/ed25519"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, error)
+	// CreateToken also returns the payload so callers can keep track of the token ID and expiry,
+	// a token with scopes can only be used for those. sessionID ties an access token to the
+	// session of the refresh token it was issued along with, uuid.Nil for any other token.
+	CreateToken(username string, role string, use TokenUse, sessionID uuid.UUID, duration time.Duration, scopes ...string) (string, *Payload, error)
+	Verifier
+}
+
+// Verifier checks tokens without being able to create them
+type Verifier interface {
 	VerifyToken(token string) (*Payload, error)
 }
+
+// AsymmetricMaker signs tokens with an Ed25519 private key,
+// anyone holding PublicKeys can verify them with a Verifier
+type AsymmetricMaker interface {
+	Maker
+	// PublicKey is the key new tokens are verified with
+	PublicKey() ed25519.PublicKey
+	// PublicKeys also holds the keys of tokens signed before a rotation
+	PublicKeys() *Keyring[ed25519.PublicKey]
+}
@@ -5,42 +5,67 @@ import (
 	"time"
 
 	"github.com/aead/chacha20poly1305"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 )
 
 type PasetoMaker struct {
-	paseto       *paseto.V2
-	symmetricKey []byte
+	paseto *paseto.V2
+	keys   *Keyring[[]byte]
+	claims Claims
 }
 
 func NewPasetoMaker(symmetricKey string) (Maker, error) {
-	if len(symmetricKey) != chacha20poly1305.KeySize {
-		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	return NewPasetoKeyringMaker(SingleKeyring([]byte(symmetricKey)), Claims{})
+}
+
+// NewPasetoKeyringMaker encrypts with the current key of keys and decrypts with the key
+// the token footer names
+func NewPasetoKeyringMaker(keys *Keyring[[]byte], claims Claims) (Maker, error) {
+	for _, id := range keys.IDs() {
+		key, _ := keys.Key(id)
+		if len(key) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+		}
 	}
 
 	maker := &PasetoMaker{
-		paseto:       paseto.NewV2(),
-		symmetricKey: []byte(symmetricKey),
+		paseto: paseto.NewV2(),
+		keys:   keys,
+		claims: claims,
 	}
 	return maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *PasetoMaker) CreateToken(username string, role string, use TokenUse, sessionID uuid.UUID, duration time.Duration, scopes ...string) (string, *Payload, error) {
+	payload, err := maker.claims.newPayload(username, role, use, sessionID, duration, scopes)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+	keyID, key := maker.keys.Current()
+	token, err := maker.paseto.Encrypt(key, payload, keyFooter{KeyID: keyID})
+	return token, payload, err
 }
 
 func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+	// the footer is only trusted to pick the key, decrypting authenticates it
+	footer := keyFooter{}
+	err := paseto.ParseFooter(token, &footer)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	key, err := maker.keys.Key(footer.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
 	payload := &Payload{}
-	err := maker.paseto.Decrypt(token, maker.symmetricKey, payload, nil)
+	err = maker.paseto.Decrypt(token, key, payload, nil)
 	if err != nil {
 		return nil, err
 	}
-	err = payload.Valid()
+	err = maker.claims.validate(payload)
 	if err != nil {
 		return nil, err
 	}
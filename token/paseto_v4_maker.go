@@ -0,0 +1,129 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+)
+
+// PasetoV4PublicVerifier checks PASETO v4.public tokens with the public key alone
+type PasetoV4PublicVerifier struct {
+	publicKeys *Keyring[ed25519.PublicKey]
+	claims     Claims
+}
+
+func NewPasetoV4PublicVerifier(publicKey ed25519.PublicKey) (*PasetoV4PublicVerifier, error) {
+	return NewPasetoV4PublicKeyringVerifier(SingleKeyring(publicKey), Claims{})
+}
+
+// NewPasetoV4PublicKeyringVerifier checks every token with the public key its footer names
+// and requires the issuer and audience of claims
+func NewPasetoV4PublicKeyringVerifier(publicKeys *Keyring[ed25519.PublicKey], claims Claims) (*PasetoV4PublicVerifier, error) {
+	for _, id := range publicKeys.IDs() {
+		key, _ := publicKeys.Key(id)
+		_, err := paseto.NewV4AsymmetricPublicKeyFromEd25519(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &PasetoV4PublicVerifier{publicKeys: publicKeys, claims: claims}, nil
+}
+
+func (verifier *PasetoV4PublicVerifier) VerifyToken(token string) (*Payload, error) {
+	// the expiry is checked on the payload like for the other makers
+	parser := paseto.NewParserWithoutExpiryCheck()
+
+	// the footer is only trusted to pick the key, the signature covers it
+	footer, err := parser.UnsafeParseFooter(paseto.V4Public, token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	keyID, err := footerKeyID(footer)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	key, err := verifier.publicKeys.Key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := paseto.NewV4AsymmetricPublicKeyFromEd25519(key)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	parsed, err := parser.ParseV4Public(publicKey, token, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	err = json.Unmarshal(parsed.ClaimsJSON(), payload)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	err = verifier.claims.validate(payload)
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// PasetoV4PublicMaker signs PASETO v4.public tokens, other services can verify them
+// with a PasetoV4PublicVerifier and the public key
+type PasetoV4PublicMaker struct {
+	*PasetoV4PublicVerifier
+	keyID     string
+	secretKey paseto.V4AsymmetricSecretKey
+}
+
+func NewPasetoV4PublicMaker(privateKey ed25519.PrivateKey) AsymmetricMaker {
+	return NewPasetoV4PublicKeyringMaker(SingleKeyring(privateKey), Claims{})
+}
+
+// NewPasetoV4PublicKeyringMaker signs with the current key of privateKeys and verifies with
+// the public half of the key the footer names
+func NewPasetoV4PublicKeyringMaker(privateKeys *Keyring[ed25519.PrivateKey], claims Claims) AsymmetricMaker {
+	// keys built by ed25519 are always well formed, so these cannot fail
+	keyID, privateKey := privateKeys.Current()
+	secretKey, _ := paseto.NewV4AsymmetricSecretKeyFromEd25519(privateKey)
+	verifier, _ := NewPasetoV4PublicKeyringVerifier(publicKeyring(privateKeys), claims)
+
+	return &PasetoV4PublicMaker{
+		PasetoV4PublicVerifier: verifier,
+		keyID:                  keyID,
+		secretKey:              secretKey,
+	}
+}
+
+func (maker *PasetoV4PublicMaker) CreateToken(username string, role string, use TokenUse, sessionID uuid.UUID, duration time.Duration, scopes ...string) (string, *Payload, error) {
+	payload, err := maker.claims.newPayload(username, role, use, sessionID, duration, scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	claims, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, err
+	}
+	footer, err := json.Marshal(keyFooter{KeyID: maker.keyID})
+	if err != nil {
+		return "", nil, err
+	}
+	token, err := paseto.NewTokenFromClaimsJSON(claims, footer)
+	if err != nil {
+		return "", nil, err
+	}
+	return token.V4Sign(maker.secretKey, nil), payload, nil
+}
+
+func (maker *PasetoV4PublicMaker) PublicKey() ed25519.PublicKey {
+	_, key := maker.publicKeys.Current()
+	return key
+}
+
+func (maker *PasetoV4PublicMaker) PublicKeys() *Keyring[ed25519.PublicKey] {
+	return maker.publicKeys
+}
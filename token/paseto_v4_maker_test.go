@@ -0,0 +1,70 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func newTestPasetoV4PublicMaker(t *testing.T) AsymmetricMaker {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	return NewPasetoV4PublicMaker(privateKey)
+}
+
+func TestPasetoV4PublicMaker(t *testing.T) {
+	maker := newTestPasetoV4PublicMaker(t)
+
+	username := utils.RandomOwner()
+	role := utils.BankerRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	sessionID := uuid.New()
+	token, payload, err := maker.CreateToken(username, role, AccessToken, sessionID, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+	require.Regexp(t, `^v4\.public\.`, token)
+
+	// a service holding only the public key can verify the token
+	verifier, err := NewPasetoV4PublicVerifier(maker.PublicKey())
+	require.NoError(t, err)
+
+	for _, v := range []Verifier{maker, verifier} {
+		payload, err = v.VerifyToken(token)
+		require.NoError(t, err)
+		require.Equal(t, username, payload.Username)
+		require.Equal(t, role, payload.Role)
+		require.Equal(t, AccessToken, payload.Use)
+		require.Equal(t, sessionID, payload.SessionID)
+		require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+		require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+	}
+}
+
+func TestExpiredPasetoV4PublicToken(t *testing.T) {
+	maker := newTestPasetoV4PublicMaker(t)
+
+	token, _, err := maker.CreateToken(utils.RandomOwner(), utils.DepositorRole, AccessToken, uuid.Nil, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoV4PublicTokenOtherKey(t *testing.T) {
+	token, _, err := newTestPasetoV4PublicMaker(t).CreateToken(utils.RandomOwner(), utils.DepositorRole, AccessToken, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := newTestPasetoV4PublicMaker(t).VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
@@ -3,34 +3,66 @@ package token
 
 import (
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrInvalidToken = errors.New("token is invalid")
-	ErrExpiredToken = errors.New("token is expired")
+	ErrInvalidToken     = errors.New("token is invalid")
+	ErrExpiredToken     = errors.New("token is expired")
+	ErrRevokedToken     = errors.New("token has been revoked")
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+)
+
+// notBeforeLeeway allows for the clocks of the servers sharing the keys to drift apart,
+// a token created on one is accepted right away on another
+const notBeforeLeeway = time.Minute
+
+// TokenUse tells access tokens apart from refresh tokens, a refresh token can only be
+// exchanged for an access token and an access token cannot be renewed
+type TokenUse string
+
+const (
+	AccessToken  TokenUse = "access"
+	RefreshToken TokenUse = "refresh"
 )
 
 // Payload contain payload data of token
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
-	IssuedAt  time.Time `json:"issued_at"`
-	ExpiredAt time.Time `json:"expired_at"`
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	Use      TokenUse  `json:"token_use"`
+	// SessionID is the login session an access token was issued for, the token stops working
+	// once that session is blocked. Refresh tokens are the session and carry uuid.Nil.
+	SessionID uuid.UUID `json:"sid"`
+	// Issuer, Audience and the times use the registered claim names so other services can check
+	// them. The times are RFC 3339 strings as in PASETO, JWTs carry them as NumericDate.
+	Issuer   string   `json:"iss,omitempty"`
+	Audience []string `json:"aud,omitempty"`
+	// Scopes restrict what the token may be used for, a token without any has
+	// everything the role allows
+	Scopes    []string  `json:"scopes,omitempty"`
+	IssuedAt  time.Time `json:"iat"`
+	NotBefore time.Time `json:"nbf"`
+	ExpiredAt time.Time `json:"exp"`
 }
 
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, role string, duration time.Duration) (*Payload, error) {
 	id, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
 	return &Payload{
 		ID:        id,
 		Username:  username,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		Role:      role,
+		IssuedAt:  now,
+		NotBefore: now,
+		ExpiredAt: now.Add(duration),
 	}, nil
 }
 
@@ -38,5 +70,52 @@ func (payload *Payload) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
 		return ErrExpiredToken
 	}
+	if time.Now().Add(notBeforeLeeway).Before(payload.NotBefore) {
+		return ErrTokenNotValidYet
+	}
+	return nil
+}
+
+// HasScope reports whether the token may be used for scope
+func (payload *Payload) HasScope(scope string) bool {
+	return len(payload.Scopes) == 0 || slices.Contains(payload.Scopes, scope)
+}
+
+// Claims are the issuer and audience a server puts in the tokens it creates, and requires
+// of the tokens it verifies. Empty fields are neither set nor checked.
+type Claims struct {
+	Issuer string
+	// Audience names the services the tokens are meant for, a token is accepted
+	// when it was issued for this one
+	Audience string
+}
+
+func (claims Claims) newPayload(username string, role string, use TokenUse, sessionID uuid.UUID, duration time.Duration, scopes []string) (*Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return nil, err
+	}
+	payload.Use = use
+	payload.SessionID = sessionID
+	payload.Issuer = claims.Issuer
+	if claims.Audience != "" {
+		payload.Audience = []string{claims.Audience}
+	}
+	payload.Scopes = scopes
+	return payload, nil
+}
+
+// validate checks the times of payload and that it was issued by and for this server
+func (claims Claims) validate(payload *Payload) error {
+	err := payload.Valid()
+	if err != nil {
+		return err
+	}
+	if claims.Issuer != "" && payload.Issuer != claims.Issuer {
+		return ErrInvalidToken
+	}
+	if claims.Audience != "" && !slices.Contains(payload.Audience, claims.Audience) {
+		return ErrInvalidToken
+	}
 	return nil
 }
@@ -0,0 +1,116 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestClaims(t *testing.T) {
+	symmetricKey := []byte(utils.RandomString(32))
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		newMaker func(t *testing.T, claims Claims) Maker
+	}{
+		{
+			name: "Paseto",
+			newMaker: func(t *testing.T, claims Claims) Maker {
+				maker, err := NewPasetoKeyringMaker(SingleKeyring(symmetricKey), claims)
+				require.NoError(t, err)
+				return maker
+			},
+		},
+		{
+			name: "JWT",
+			newMaker: func(t *testing.T, claims Claims) Maker {
+				maker, err := NewJWTKeyringMaker(SingleKeyring(symmetricKey), claims)
+				require.NoError(t, err)
+				return maker
+			},
+		},
+		{
+			name: "PasetoV4Public",
+			newMaker: func(t *testing.T, claims Claims) Maker {
+				return NewPasetoV4PublicKeyringMaker(SingleKeyring(privateKey), claims)
+			},
+		},
+		{
+			name: "JWTEdDSA",
+			newMaker: func(t *testing.T, claims Claims) Maker {
+				return NewEdDSAJWTKeyringMaker(SingleKeyring(privateKey), claims)
+			},
+		},
+	}
+
+	bank := Claims{Issuer: "https://bank.example", Audience: "simplebank"}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker := tc.newMaker(t, bank)
+
+			token, payload, err := maker.CreateToken(utils.RandomOwner(), utils.DepositorRole, AccessToken, uuid.Nil, time.Minute, utils.TransfersWriteScope)
+			require.NoError(t, err)
+			require.Equal(t, bank.Issuer, payload.Issuer)
+			require.Equal(t, []string{bank.Audience}, payload.Audience)
+
+			payload, err = maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, bank.Issuer, payload.Issuer)
+			require.Equal(t, []string{bank.Audience}, payload.Audience)
+			require.Equal(t, []string{utils.TransfersWriteScope}, payload.Scopes)
+			require.WithinDuration(t, payload.IssuedAt, payload.NotBefore, 0)
+
+			// the same keys with another issuer or audience reject the token
+			for _, claims := range []Claims{
+				{Issuer: "https://other.example", Audience: bank.Audience},
+				{Issuer: bank.Issuer, Audience: "reporting"},
+			} {
+				payload, err = tc.newMaker(t, claims).VerifyToken(token)
+				require.EqualError(t, err, ErrInvalidToken.Error())
+				require.Nil(t, payload)
+			}
+
+			// a server that doesn't check the claims accepts it
+			_, err = tc.newMaker(t, Claims{}).VerifyToken(token)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPayloadValid(t *testing.T) {
+	payload, err := NewPayload(utils.RandomOwner(), utils.DepositorRole, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, payload.Valid())
+
+	// a little clock drift between servers is allowed
+	payload.NotBefore = time.Now().Add(notBeforeLeeway / 2)
+	require.NoError(t, payload.Valid())
+
+	payload.NotBefore = time.Now().Add(2 * notBeforeLeeway)
+	require.EqualError(t, payload.Valid(), ErrTokenNotValidYet.Error())
+
+	payload.NotBefore = payload.IssuedAt
+	payload.ExpiredAt = time.Now().Add(-time.Second)
+	require.EqualError(t, payload.Valid(), ErrExpiredToken.Error())
+}
+
+func TestPayloadHasScope(t *testing.T) {
+	payload, err := NewPayload(utils.RandomOwner(), utils.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// a token without scopes has everything the role allows
+	require.True(t, payload.HasScope(utils.TransfersWriteScope))
+
+	payload.Scopes = []string{utils.AccountsReadScope}
+	require.True(t, payload.HasScope(utils.AccountsReadScope))
+	require.False(t, payload.HasScope(utils.TransfersWriteScope))
+}
@@ -0,0 +1,82 @@
+package token
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Revoker keeps track of tokens that must be rejected before they expire, keyed by Payload.ID
+type Revoker interface {
+	Revoke(ctx context.Context, payload *Payload) error
+	IsRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// MemoryRevoker is an in-process LRU of revoked token IDs.
+// Once full the least recently touched ID is forgotten, so it is meant for tests and single-node setups.
+type MemoryRevoker struct {
+	mutex    sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[uuid.UUID]*list.Element
+}
+
+type revokedToken struct {
+	id        uuid.UUID
+	expiredAt time.Time
+}
+
+func NewMemoryRevoker(capacity int) Revoker {
+	return &MemoryRevoker{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[uuid.UUID]*list.Element),
+	}
+}
+
+func (revoker *MemoryRevoker) Revoke(_ context.Context, payload *Payload) error {
+	revoker.mutex.Lock()
+	defer revoker.mutex.Unlock()
+
+	if element, ok := revoker.items[payload.ID]; ok {
+		revoker.order.MoveToFront(element)
+		return nil
+	}
+
+	revoker.items[payload.ID] = revoker.order.PushFront(revokedToken{
+		id:        payload.ID,
+		expiredAt: payload.ExpiredAt,
+	})
+
+	for revoker.order.Len() > revoker.capacity {
+		revoker.remove(revoker.order.Back())
+	}
+	return nil
+}
+
+func (revoker *MemoryRevoker) IsRevoked(_ context.Context, id uuid.UUID) (bool, error) {
+	revoker.mutex.Lock()
+	defer revoker.mutex.Unlock()
+
+	element, ok := revoker.items[id]
+	if !ok {
+		return false, nil
+	}
+
+	// an expired token is rejected by Valid anyway, no need to keep it around
+	if time.Now().After(element.Value.(revokedToken).expiredAt) {
+		revoker.remove(element)
+		return false, nil
+	}
+
+	revoker.order.MoveToFront(element)
+	return true, nil
+}
+
+func (revoker *MemoryRevoker) remove(element *list.Element) {
+	revoker.order.Remove(element)
+	delete(revoker.items, element.Value.(revokedToken).id)
+}
@@ -0,0 +1,79 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/utils"
+)
+
+func TestMemoryRevoker(t *testing.T) {
+	revoker := NewMemoryRevoker(10)
+
+	payload, err := NewPayload(utils.RandomOwner(), utils.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	revoked, err := revoker.IsRevoked(context.Background(), payload.ID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = revoker.Revoke(context.Background(), payload)
+	require.NoError(t, err)
+
+	revoked, err = revoker.IsRevoked(context.Background(), payload.ID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// revoking twice is harmless
+	err = revoker.Revoke(context.Background(), payload)
+	require.NoError(t, err)
+}
+
+func TestMemoryRevokerEviction(t *testing.T) {
+	capacity := 3
+	revoker := NewMemoryRevoker(capacity)
+
+	payloads := make([]*Payload, capacity+1)
+	for i := range payloads {
+		payload, err := NewPayload(utils.RandomOwner(), utils.DepositorRole, time.Minute)
+		require.NoError(t, err)
+		payloads[i] = payload
+	}
+
+	for _, payload := range payloads[:capacity] {
+		require.NoError(t, revoker.Revoke(context.Background(), payload))
+	}
+
+	// touch the oldest one so the second becomes least recently used
+	revoked, err := revoker.IsRevoked(context.Background(), payloads[0].ID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	require.NoError(t, revoker.Revoke(context.Background(), payloads[capacity]))
+
+	revoked, err = revoker.IsRevoked(context.Background(), payloads[1].ID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	for _, payload := range []*Payload{payloads[0], payloads[2], payloads[3]} {
+		revoked, err = revoker.IsRevoked(context.Background(), payload.ID)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	}
+}
+
+func TestMemoryRevokerExpiredToken(t *testing.T) {
+	revoker := NewMemoryRevoker(10)
+
+	payload, err := NewPayload(utils.RandomOwner(), utils.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+
+	err = revoker.Revoke(context.Background(), payload)
+	require.NoError(t, err)
+
+	revoked, err := revoker.IsRevoked(context.Background(), payload.ID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
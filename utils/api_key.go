@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// API keys look like sbk_<prefix>_<secret>, sbk_<prefix> identifies the key and is not secret.
+// The prefix is long enough for the unique constraint on it to never be hit in practice.
+const (
+	apiKeyTag         = "sbk"
+	apiKeyPrefixBytes = 8
+	apiKeySecretBytes = 32
+)
+
+var ErrInvalidAPIKey = errors.New("api key is invalid")
+
+// NewAPIKey generates a key, the prefix it is looked up by and the hash stored in its place
+func NewAPIKey() (key string, prefix string, hashedKey string, err error) {
+	random := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	_, err = rand.Read(random)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix = apiKeyTag + "_" + hex.EncodeToString(random[:apiKeyPrefixBytes])
+	key = prefix + "_" + hex.EncodeToString(random[apiKeyPrefixBytes:])
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// APIKeyPrefix returns the prefix of key, or ErrInvalidAPIKey when key is not shaped like one
+func APIKeyPrefix(key string) (string, error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag ||
+		len(parts[1]) != 2*apiKeyPrefixBytes || len(parts[2]) != 2*apiKeySecretBytes {
+		return "", ErrInvalidAPIKey
+	}
+	return parts[0] + "_" + parts[1], nil
+}
+
+// HashAPIKey returns the SHA-256 of key, keys are random enough not to need a slow hash
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckAPIKey checks key against the stored hash in constant time
+func CheckAPIKey(key string, hashedKey string) error {
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hashedKey)) != 1 {
+		return ErrInvalidAPIKey
+	}
+	return nil
+}
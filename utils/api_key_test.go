@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey(t *testing.T) {
+	key, prefix, hashedKey, err := NewAPIKey()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(key, prefix+"_"))
+	require.NotContains(t, hashedKey, key)
+
+	parsedPrefix, err := APIKeyPrefix(key)
+	require.NoError(t, err)
+	require.Equal(t, prefix, parsedPrefix)
+	require.NoError(t, CheckAPIKey(key, hashedKey))
+
+	// another key with the same prefix does not match the hash
+	otherKey, _, _, err := NewAPIKey()
+	require.NoError(t, err)
+	forged := prefix + otherKey[len(prefix):]
+	require.EqualError(t, CheckAPIKey(forged, hashedKey), ErrInvalidAPIKey.Error())
+
+	// keys are never equal
+	require.NotEqual(t, key, otherKey)
+}
+
+func TestAPIKeyPrefixInvalid(t *testing.T) {
+	for _, key := range []string{"", "sbk", "sbk_1234", "xyz_1234567890abcdef_" + strings.Repeat("a", 64), "sbk_12345678_" + strings.Repeat("a", 64)} {
+		_, err := APIKeyPrefix(key)
+		require.EqualError(t, err, ErrInvalidAPIKey.Error(), key)
+	}
+}
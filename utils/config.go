@@ -7,10 +7,25 @@ import (
 )
 
 type Config struct {
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	TokenType            string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenPrivateKey      string        `mapstructure:"TOKEN_PRIVATE_KEY"`
+	TokenKeyID           string        `mapstructure:"TOKEN_KEY_ID"`
+	TokenKeysDir         string        `mapstructure:"TOKEN_KEYS_DIR"`
+	TokenIssuer          string        `mapstructure:"TOKEN_ISSUER"`
+	TokenAudience        string        `mapstructure:"TOKEN_AUDIENCE"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	IdempotencyKeyTTL    time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+	TokenRevocationStore string        `mapstructure:"TOKEN_REVOCATION_STORE"`
+	ExchangeRatesFile    string        `mapstructure:"EXCHANGE_RATES_FILE"`
+	MaxDepositAmount     int64         `mapstructure:"MAX_DEPOSIT_AMOUNT"`
+	MaxWithdrawalAmount  int64         `mapstructure:"MAX_WITHDRAWAL_AMOUNT"`
+	ReconcileInterval    time.Duration `mapstructure:"RECONCILE_INTERVAL"`
+	SchedulerInterval    time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	HoldExpiryInterval   time.Duration `mapstructure:"HOLD_EXPIRY_INTERVAL"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -1,14 +1,8 @@
 package utils
 
+// codes of the currencies seeded by the migrations, the full list lives in the currencies table
 const (
 	USD = "USD"
 	EUR = "EUR"
+	GBP = "GBP"
 )
-
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR:
-		return true
-	}
-	return false
-}
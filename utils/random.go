@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"math/rand"
 	"time"
-
-	"github.com/suryansh74/simplebank/db/sqlc"
 )
 
 func init() {
@@ -38,11 +36,8 @@ func RandomMoney() int64 {
 }
 
 // RandomCurrency generates a random currency code
-func RandomCurrency() sqlc.Currency {
-	currencies := []sqlc.Currency{
-		sqlc.CurrencyEUR,
-		sqlc.CurrencyUSD,
-	}
+func RandomCurrency() string {
+	currencies := []string{EUR, USD, GBP}
 	n := len(currencies)
 	return currencies[rand.Intn(n)]
 }
@@ -0,0 +1,8 @@
+package utils
+
+// roles a user can have, they match the UserRole enum in the database
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+	AdminRole     = "admin"
+)
@@ -0,0 +1,28 @@
+package utils
+
+import "slices"
+
+// scopes a token can be restricted to, a token without any has everything its role allows
+const (
+	AccountsReadScope         = "accounts:read"
+	AccountsWriteScope        = "accounts:write"
+	TransfersWriteScope       = "transfers:write"
+	AuditReadScope            = "audit:read"
+	ReconciliationsReadScope  = "reconciliations:read"
+	ReconciliationsWriteScope = "reconciliations:write"
+)
+
+// Scopes lists every scope a token can be given
+var Scopes = []string{
+	AccountsReadScope,
+	AccountsWriteScope,
+	TransfersWriteScope,
+	AuditReadScope,
+	ReconciliationsReadScope,
+	ReconciliationsWriteScope,
+}
+
+// IsSupportedScope reports whether scope is one of Scopes
+func IsSupportedScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
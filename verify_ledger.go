@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+// accountPageSize is how many account ids are read at a time when verifying every account
+const accountPageSize = 500
+
+// verifyLedger runs the verify-ledger subcommand and returns the process exit code:
+// 0 when every chain checked is intact, 1 when one is broken and 2 when it could not run.
+//
+//	simplebank verify-ledger [-account id]
+func verifyLedger(ctx context.Context, store db.Store, args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("verify-ledger", flag.ContinueOnError)
+	flags.SetOutput(out)
+	accountID := flags.Int64("account", 0, "account to verify, every account when not set")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	accountIDs := []int64{*accountID}
+	broken := false
+	for afterID := int64(0); ; {
+		if *accountID == 0 {
+			var err error
+			accountIDs, err = store.ListAccountIDs(ctx, sqlc.ListAccountIDsParams{
+				AfterID:  afterID,
+				PageSize: accountPageSize,
+			})
+			if err != nil {
+				fmt.Fprintln(out, "cannot list accounts:", err)
+				return 2
+			}
+		}
+
+		for _, id := range accountIDs {
+			report, err := db.VerifyAccountChain(ctx, store, id)
+			if err != nil {
+				fmt.Fprintf(out, "account %d: cannot verify: %v\n", id, err)
+				return 2
+			}
+
+			if report.Intact() {
+				fmt.Fprintf(out, "account %d: %d entries, intact\n", id, report.Entries)
+				continue
+			}
+			broken = true
+			fmt.Fprintf(out, "account %d: broken at entry %d: %s\n", id, report.BrokenEntryID, report.Reason)
+		}
+
+		if *accountID != 0 || len(accountIDs) < accountPageSize {
+			break
+		}
+		afterID = accountIDs[len(accountIDs)-1]
+	}
+
+	if broken {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/suryansh74/simplebank/db"
+	"github.com/suryansh74/simplebank/db/mock"
+	"github.com/suryansh74/simplebank/db/sqlc"
+)
+
+func TestVerifyLedger(t *testing.T) {
+	accountID := int64(7)
+	chain := randomChain(accountID, 3)
+
+	tampered := randomChain(accountID, 3)
+	tampered[1].Amount++
+
+	unlinked := randomChain(accountID, 3)
+	unlinked = append(unlinked[:1], unlinked[2:]...)
+
+	testCases := []struct {
+		name          string
+		lastEntryHash []byte
+		entries       []sqlc.Entry
+		exitCode      int
+		wantOutput    string
+	}{
+		{
+			name:          "Intact",
+			lastEntryHash: chain[2].Hash,
+			entries:       chain,
+			exitCode:      0,
+			wantOutput:    "account 7: 3 entries, intact\n",
+		},
+		{
+			name:          "EditedEntry",
+			lastEntryHash: tampered[2].Hash,
+			entries:       tampered,
+			exitCode:      1,
+			wantOutput:    "account 7: broken at entry 2: hash doesn't match the content of the entry\n",
+		},
+		{
+			name:          "DeletedEntry",
+			lastEntryHash: unlinked[1].Hash,
+			entries:       unlinked,
+			exitCode:      1,
+			wantOutput:    "account 7: broken at entry 3: prev_hash doesn't match the hash of the previous entry\n",
+		},
+		{
+			name:          "DeletedNewestEntry",
+			lastEntryHash: chain[2].Hash,
+			entries:       chain[:2],
+			exitCode:      1,
+			wantOutput:    "account 7: broken at entry 2: chain doesn't end on the last entry hash of the account\n",
+		},
+		{
+			name:          "AppendedWhileVerifying",
+			lastEntryHash: chain[1].Hash,
+			entries:       chain,
+			exitCode:      0,
+			wantOutput:    "account 7: 2 entries, intact\n",
+		},
+		{
+			name:       "Empty",
+			entries:    []sqlc.Entry{},
+			exitCode:   0,
+			wantOutput: "account 7: 0 entries, intact\n",
+		},
+		{
+			name:       "MissingLastEntryHash",
+			entries:    chain,
+			exitCode:   1,
+			wantOutput: "account 7: broken at entry 1: account has entries but no last entry hash\n",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			if tc.lastEntryHash == nil {
+				// without a last entry hash only the first entry is looked for, and the
+				// account read again when there is one
+				first := tc.entries[:min(1, len(tc.entries))]
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(accountID)).
+					Times(1+len(first)).
+					Return(sqlc.Account{ID: accountID}, nil)
+				store.EXPECT().
+					ListAccountChain(gomock.Any(), gomock.Eq(sqlc.ListAccountChainParams{AccountID: accountID, PageSize: 1})).
+					Times(1).
+					Return(first, nil)
+			} else {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(accountID)).
+					Times(1).
+					Return(sqlc.Account{ID: accountID, LastEntryHash: tc.lastEntryHash}, nil)
+				store.EXPECT().
+					ListAccountChain(gomock.Any(), gomock.Eq(sqlc.ListAccountChainParams{AccountID: accountID, PageSize: 1000})).
+					Times(1).
+					Return(tc.entries, nil)
+			}
+
+			var out bytes.Buffer
+			code := verifyLedger(context.Background(), store, []string{"-account", "7"}, &out)
+			require.Equal(t, tc.exitCode, code)
+			require.Equal(t, tc.wantOutput, out.String())
+		})
+	}
+}
+
+func TestVerifyLedgerAllAccounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountIDs(gomock.Any(), gomock.Eq(sqlc.ListAccountIDsParams{PageSize: accountPageSize})).
+		Times(1).
+		Return([]int64{1, 2}, nil)
+
+	for _, id := range []int64{1, 2} {
+		chain := randomChain(id, 2)
+		store.EXPECT().
+			GetAccount(gomock.Any(), gomock.Eq(id)).
+			Times(1).
+			Return(sqlc.Account{ID: id, LastEntryHash: chain[1].Hash}, nil)
+		store.EXPECT().
+			ListAccountChain(gomock.Any(), gomock.Eq(sqlc.ListAccountChainParams{AccountID: id, PageSize: 1000})).
+			Times(1).
+			Return(chain, nil)
+	}
+
+	var out bytes.Buffer
+	code := verifyLedger(context.Background(), store, nil, &out)
+	require.Equal(t, 0, code)
+	require.Equal(t, "account 1: 2 entries, intact\naccount 2: 2 entries, intact\n", out.String())
+}
+
+func randomChain(accountID int64, n int) []sqlc.Entry {
+	entries := make([]sqlc.Entry, n)
+	var prevHash []byte
+	createdAt := time.Now().Truncate(time.Microsecond)
+	for i := range entries {
+		amount := int64(10 * (i + 1))
+		entries[i] = sqlc.Entry{
+			ID:        int64(i + 1),
+			AccountID: accountID,
+			Amount:    amount,
+			CreatedAt: pgtype.Timestamptz{Time: createdAt, Valid: true},
+			PrevHash:  prevHash,
+			Hash:      db.EntryHash(prevHash, accountID, amount, createdAt),
+		}
+		prevHash = entries[i].Hash
+	}
+	return entries
+}